@@ -8,21 +8,54 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"queue-bite/internal/config"
 	"queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hsimpl "queue-bite/internal/features/history/repository"
+	hs "queue-bite/internal/features/history/service"
 	hdimpl "queue-bite/internal/features/hostdesk/repository"
 	hd "queue-bite/internal/features/hostdesk/service"
+	rsd "queue-bite/internal/features/reservation/domain"
+	rsimpl "queue-bite/internal/features/reservation/repository"
+	rs "queue-bite/internal/features/reservation/service"
 	sm "queue-bite/internal/features/seatmanager/service"
+	stimpl "queue-bite/internal/features/servicetime/repository"
 	st "queue-bite/internal/features/servicetime/service"
+	wrepo "queue-bite/internal/features/waitlist/repository"
 	wimpl "queue-bite/internal/features/waitlist/repository/redis"
+	ws "queue-bite/internal/features/waitlist/service"
 	"queue-bite/internal/platform"
+	dlimpl "queue-bite/internal/platform/deadline/redis"
 	eb "queue-bite/internal/platform/eventbus"
+	ebmem "queue-bite/internal/platform/eventbus/inmemory"
 	ebimpl "queue-bite/internal/platform/eventbus/redis"
+	"queue-bite/internal/platform/metrics"
 	"queue-bite/internal/server"
 	_ "queue-bite/pkg/env/autoload"
 )
 
+var partyProcessingStrategies = map[string]func() sm.PartyProcessingStrategy{
+	"fair":    sm.NewFairOrderStrategy,
+	"instant": sm.NewInstantServingStrategy,
+}
+
+var partySelectionStrategies = map[string]func(ws.QueuedPartyProvider, *config.RestaurantConfig) sm.PartySelectionStrategy{
+	"ordered": func(waitlist ws.QueuedPartyProvider, _ *config.RestaurantConfig) sm.PartySelectionStrategy {
+		return sm.NewOrderedSeatingStrategy(waitlist)
+	},
+	"preference": func(waitlist ws.QueuedPartyProvider, _ *config.RestaurantConfig) sm.PartySelectionStrategy {
+		return sm.NewPreferenceSeatingStrategy(waitlist)
+	},
+	"best-fit": func(waitlist ws.QueuedPartyProvider, cfg *config.RestaurantConfig) sm.PartySelectionStrategy {
+		return sm.NewBestFitSeatingStrategy(waitlist, cfg.BestFitWindow)
+	},
+	"size-bucket": func(waitlist ws.QueuedPartyProvider, _ *config.RestaurantConfig) sm.PartySelectionStrategy {
+		return sm.NewSizeBucketSeatingStrategy(waitlist)
+	},
+}
+
 func main() {
 	ctx := context.Background()
 	if err := run(ctx, os.Args, os.Getenv, os.Stdin, os.Stdout, os.Stderr); err != nil {
@@ -48,26 +81,101 @@ func run(
 	logger := log.NewZerologLogger(stdout, cfg.Dev)
 	redis := platform.NewRedis(cfg, logger)
 	eventRegistry := eb.NewEventRegistry()
-	eventbus := ebimpl.NewRedisEventBus(logger, redis.Client, eventRegistry)
-	instantHost := hd.NewInstantServeHostDesk(logger,
-		cfg.HostDesk.InstantServeHostDeskSeatCapacity,
-		hdimpl.NewRedisHostDeskRepository(logger, redis.Client),
-		// hdimpl.NewInMemoryHostDeskRepository(logger),
-		eventbus,
-		hd.NewLinearServiceTimer(logger, cfg.HostDesk.LinearServiceTimerDurationPerGuest))
+	registry := metrics.NewRegistry()
+	bus, err := newEventBus(ctx, cfg, logger, redis, eventRegistry)
+	if err != nil {
+		logger.LogErr(log.Server, err, "could not start event bus")
+		return err
+	}
+	eventbus := eb.WithMetrics(bus, registry)
+	hostDeskRepos := map[string]func(d.RestaurantID) hdimpl.HostDeskRepository{
+		"redis": func(id d.RestaurantID) hdimpl.HostDeskRepository {
+			return hdimpl.NewRedisHostDeskRepository(logger, redis.Client, id)
+		},
+		"memory": func(d.RestaurantID) hdimpl.HostDeskRepository {
+			return hdimpl.NewInMemoryHostDeskRepository(logger)
+		},
+	}
+	waitlistRepos := map[string]func(d.RestaurantID) wrepo.WaitlistRepositoy{
+		"redis": func(id d.RestaurantID) wrepo.WaitlistRepositoy {
+			return wimpl.NewRedisWaitlistRepository(logger, redis.Client, id, cfg.Waitlist.EntityTTL, cfg.Waitlist.ScanChunkSize)
+		},
+		"memory": func(d.RestaurantID) wrepo.WaitlistRepositoy {
+			return wrepo.NewInMemoryWaitlistRepository(logger, cfg.Waitlist.EntityTTL, cfg.Waitlist.ScanChunkSize)
+		},
+	}
+	serviceTimers := []hd.ServiceTimer{}
+	restaurants := []*server.RestaurantComponents{}
+	for _, restaurant := range cfg.Restaurants {
+		id := d.RestaurantID(restaurant.ID)
+		var serviceTimeEstimator st.ServiceTimeEstimator = st.NewFixedRateEstimator(cfg.ServiceEstimator.FixedRateUnit)
+		if cfg.ServiceEstimator.Strategy == "learning" {
+			learning := st.NewLearningEstimator(logger,
+				id,
+				stimpl.NewRedisServiceTimeRepository(logger, redis.Client, id, cfg.ServiceEstimator.LearningWindow),
+				eventbus,
+				serviceTimeEstimator,
+				cfg.ServiceEstimator.LearningMinSamples)
+			if err := learning.WatchServiceCompletion(ctx); err != nil {
+				logger.LogErr(log.Server, err, "could not learn service time from completed services", "restaurant", id)
+			}
+			serviceTimeEstimator = learning
+		}
+
+		// service timer holds seats as long as the estimate diners were told on joining
+		serviceTimer := hd.NewDurableServiceTimer(logger,
+			dlimpl.NewRedisDeadlineQueue(logger, redis.Client, "service:"+restaurant.ID),
+			serviceTimeEstimator,
+			cfg.HostDesk.ServiceTimerPollInterval)
+		serviceTimers = append(serviceTimers, serviceTimer)
+		reservations := rs.NewReservationBook(logger,
+			restaurant.Tables,
+			rsimpl.NewRedisReservationRepository(logger, redis.Client, id),
+			rsd.HoldWindow{Before: cfg.Reservation.HoldBefore, Grace: cfg.Reservation.GracePeriod},
+			cfg.Reservation.TurnTime,
+			dlimpl.NewRedisDeadlineQueue(logger, redis.Client, "reservation:"+restaurant.ID),
+			cfg.Reservation.HoldExpiryPollInterval)
+		instantHost := hd.NewInstantServeHostDesk(logger,
+			id,
+			restaurant.Tables,
+			hostDeskRepos[cfg.HostDesk.Storage](id),
+			eventbus,
+			serviceTimer,
+			hd.WithCapacityHolds(reservations),
+			hd.WithServiceTimeEstimator(serviceTimeEstimator))
+		if err := instantHost.WatchServiceTimer(ctx); err != nil {
+			logger.LogErr(log.Server, err, "could not watch service timer", "restaurant", id)
+		}
 
-	server := server.NewServer(
+		newSelection := partySelectionStrategies[restaurant.PartySelectionStrategy]
+		partySelection := func(waitlist ws.QueuedPartyProvider) sm.PartySelectionStrategy {
+			return newSelection(waitlist, restaurant)
+		}
+		restaurants = append(restaurants, &server.RestaurantComponents{
+			ID:                            id,
+			WaitlistRepo:                  waitlistRepos[cfg.Waitlist.Storage](id),
+			HostDesk:                      instantHost,
+			Reservations:                  reservations,
+			History:                       hs.NewPartyHistory(logger, id, hsimpl.NewFilePartyHistoryRepository(logger, cfg.History.Dir, id)),
+			ServiceTimeEstimator:          serviceTimeEstimator,
+			PartyProcessingStrategy:       partyProcessingStrategies[restaurant.PartyProcessingStrategy](),
+			PartySelectionStrategyFactory: partySelection,
+		})
+	}
+
+	server, err := server.NewServer(
 		cfg,
 		logger,
 		redis,
 		eventRegistry,
 		eventbus,
-		st.NewFixedRateEstimator(cfg.ServiceEstimator.FixedRateUnit),
-		wimpl.NewRedisWaitlistRepository(logger, redis.Client, cfg.Waitlist.EntityTTL, cfg.Waitlist.ScanChunkSize),
-		instantHost,
-		sm.NewFairOrderStrategy(),
-		sm.NewOrderedSeatingStrategy,
+		registry,
+		restaurants,
 	)
+	if err != nil {
+		logger.LogErr(log.Server, err, "could not set up server")
+		return err
+	}
 	serverError := make(chan error, 1)
 
 	go func() {
@@ -91,6 +199,11 @@ func run(
 		sctx, stop := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 		defer stop()
 
+		for _, serviceTimer := range serviceTimers {
+			if err := serviceTimer.Unwatch(sctx); err != nil {
+				logger.LogErr(log.Server, err, "failed to unwatch service timer")
+			}
+		}
 		if err := server.Shutdown(sctx); err != nil {
 			logger.LogErr(log.Server, err, "server forced to shutdown")
 			return fmt.Errorf("server forced to shutdown with error: %w", err)
@@ -99,3 +212,58 @@ func run(
 		return nil
 	}
 }
+
+// newEventBus picks the event bus of cfg, instances on streams consume as a group named by host unless configured,
+// and destroy groups left idle by instances which are gone until ctx is done.
+// Memory bus only reaches handlers of this instance, it suits a single instance deployment.
+func newEventBus(ctx context.Context, cfg *config.Config, logger log.Logger, redis *platform.RedisComponent, registry *eb.EventRegistry) (eb.EventBus, error) {
+	switch cfg.EventBus.Driver {
+	case "memory":
+		return ebmem.NewInMemoryEventBus(logger, registry), nil
+	case "pubsub":
+		return ebimpl.NewRedisEventBus(logger, redis.Client, registry), nil
+	}
+
+	// broadcast events reach every group once, instances sharing a group would split them
+	group := cfg.EventBus.Group
+	if group == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			return nil, fmt.Errorf("could not name event bus consumer group after host, set EVENTBUS_GROUP unique to this instance: %w", err)
+		}
+		group = hostname
+	}
+	if cfg.EventBus.IdleGroupTimeout > 0 {
+		go destroyIdleEventGroups(ctx, logger, redis, registry, group, cfg.EventBus.IdleGroupTimeout)
+	}
+	return ebimpl.NewRedisStreamEventBus(logger,
+		redis.Client,
+		registry,
+		group,
+		fmt.Sprintf("%s-%d", group, os.Getpid()),
+		ebimpl.WithStreamMaxLen(int64(cfg.EventBus.StreamMaxLen)),
+		ebimpl.WithRetries(int64(cfg.EventBus.MaxDeliveries), cfg.EventBus.RetryBackoff, cfg.EventBus.MaxRetryBackoff),
+		ebimpl.WithWorkers(cfg.EventBus.StreamWorkers)), nil
+}
+
+// destroyIdleEventGroups periodically destroys consumer groups on event streams whose consumers have been idle for longer than idle,
+// such as groups of hosts replaced on deploys, until ctx is done. Group of this instance is kept.
+func destroyIdleEventGroups(ctx context.Context, logger log.Logger, redis *platform.RedisComponent, registry *eb.EventRegistry, group string, idle time.Duration) {
+	ticker := time.NewTicker(min(idle, time.Hour))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			destroyed, err := ebimpl.DestroyIdleGroups(ctx, redis.Client, registry.Topics(), idle, group)
+			if err != nil && ctx.Err() == nil {
+				logger.LogErr(log.Server, err, "could not destroy idle event bus consumer groups")
+			}
+			if len(destroyed) > 0 {
+				logger.LogInfo(log.Server, "destroyed idle event bus consumer groups", "groups", destroyed)
+			}
+		}
+	}
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
 	"queue-bite/pkg/env"
 )
 
@@ -11,12 +14,35 @@ type Config struct {
 	Dev bool
 	// TODO: validate encryption key length by adding functionality with github.com/go-playground/validator/v10 in env module
 	CookieEncryptionKey string `env:"SECRET_COOKIE_ENCRYPTION_KEY" required:"T"`
+	// CookiePreviousKeys lists keys rotated out, separated by comma, cookies sealed by them are still read
+	CookiePreviousKeys string `env:"SECRET_COOKIE_PREVIOUS_KEYS"`
+
+	StreamToken struct {
+		// Key signs tokens granting access to the status stream of a party and its recovery codes, at least 32 bytes long
+		Key string        `env:"SECRET_STREAM_TOKEN_KEY" required:"T"`
+		TTL time.Duration `env:"STREAM_TOKEN_TTL" default:"1h"`
+	}
+
+	Recovery struct {
+		// LinkTTL is how long the signed link letting a party resume its session on another device is valid
+		LinkTTL time.Duration `env:"RECOVERY_LINK_TTL" default:"6h"`
+		// MaxAttempts is how many failed resume attempts a client, or guesses of a single code, could make within AttemptWindow
+		MaxAttempts   int           `env:"RECOVERY_MAX_ATTEMPTS" default:"5"`
+		AttemptWindow time.Duration `env:"RECOVERY_ATTEMPT_WINDOW" default:"15m"`
+		// MaxRestaurantAttempts is how many failed resume attempts all clients together could make at a restaurant within AttemptWindow
+		// before every attempt is slowed down by RestaurantBackoff, bounding guesses spread over many addresses
+		MaxRestaurantAttempts int           `env:"RECOVERY_MAX_RESTAURANT_ATTEMPTS" default:"200"`
+		RestaurantBackoff     time.Duration `env:"RECOVERY_RESTAURANT_BACKOFF" default:"2s"`
+	}
 
 	Server struct {
 		Host               string        `env:"SERVER_HOST" default:"localhost"`
 		Port               int           `env:"SERVER_PORT" default:"55666"`
 		ShutdownTimeout    time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT_SECONDS" default:"5s"`
 		HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"5s"`
+		// ClientIPHeader is the header the reverse proxy in front sets to the client address, such as X-Forwarded-For,
+		// its last value is taken. Empty takes the connection address, as clients could set any header themselves
+		ClientIPHeader string `env:"SERVER_CLIENT_IP_HEADER"`
 	}
 
 	Redis struct {
@@ -25,27 +51,141 @@ type Config struct {
 		Port     int    `env:"WAITLIST_REDIS_PORT" default:"6379"`
 		Password string `env:"WAITLIST_REDIS_PASSWORD"`
 	}
+	EventBus struct {
+		// Driver is either pubsub, lost by instances not connected at the time, stream, kept until consumed,
+		// or memory, within a single instance
+		Driver string `env:"EVENTBUS_DRIVER" default:"pubsub"`
+		// Group is consumer group of this instance on streams, every group receives every event, defaults to host name.
+		// It must be unique among instances, startup fails when it is not configured and host name is unknown
+		Group string `env:"EVENTBUS_GROUP"`
+		// IdleGroupTimeout is how long consumers of a group on streams are idle before the group is destroyed,
+		// dropping groups of instances which are gone, 0 keeps them
+		IdleGroupTimeout time.Duration `env:"EVENTBUS_IDLE_GROUP_TIMEOUT" default:"24h"`
+		// StreamMaxLen is about how many latest events a stream of topic keeps
+		StreamMaxLen int `env:"EVENTBUS_STREAM_MAX_LEN" default:"10000"`
+		// MaxDeliveries is how many times a failing event is delivered before it moves to the dead-letter stream
+		MaxDeliveries   int           `env:"EVENTBUS_MAX_DELIVERIES" default:"5"`
+		RetryBackoff    time.Duration `env:"EVENTBUS_RETRY_BACKOFF" default:"5s"`
+		MaxRetryBackoff time.Duration `env:"EVENTBUS_MAX_RETRY_BACKOFF" default:"1m"`
+		// StreamWorkers is how many events of a subscription on streams are handled at once, 1 handles them in order
+		StreamWorkers int `env:"EVENTBUS_STREAM_WORKERS" default:"10"`
+	}
 	Waitlist struct {
+		// Storage is either redis, shared by instances, or memory, for a single instance
+		Storage       string        `env:"WAITLIST_STORAGE" default:"redis"`
 		ScanChunkSize int           `env:"WAITLIST_SCAN_CHUNK_SIZE" default:"5"`
 		EntityTTL     time.Duration `env:"WAITLIST_ENTITY_TTL" default:"24h"`
+		// ForecastPushThreshold is how far seating forecast of a waiting party has to move before it is pushed again
+		ForecastPushThreshold time.Duration `env:"WAITLIST_FORECAST_PUSH_THRESHOLD" default:"1m"`
 	}
 	ServiceEstimator struct {
+		// Strategy is either fixed, time per guest, or learning, median of how long parties actually stayed
+		Strategy      string        `env:"SERVICE_ESTIMATOR" default:"learning"`
 		FixedRateUnit time.Duration `env:"FIXED_RATE_SERVICE_ESTIMATOR_UNIT" default:"3s"`
+		// LearningWindow is how many latest samples of a party size and hour of day are kept
+		LearningWindow int `env:"LEARNING_SERVICE_ESTIMATOR_WINDOW" default:"50"`
+		// LearningMinSamples is how many samples are needed before they replace the fixed rate
+		LearningMinSamples int `env:"LEARNING_SERVICE_ESTIMATOR_MIN_SAMPLES" default:"5"`
 	}
 	HostDesk struct {
-		InstantServeHostDeskSeatCapacity   int           `env:"INSTANT_SERVE_HOST_DESK_SEAT_CAPACITY" default:"10"`
-		LinearServiceTimerDurationPerGuest time.Duration `env:"LINEAR_SERVICE_TIMER_DURATION_PER_GUEST" default:"3s"`
+		// Storage is either redis, shared by instances, or memory, for a single instance
+		Storage                  string        `env:"HOST_DESK_STORAGE" default:"redis"`
+		ServiceTimerPollInterval time.Duration `env:"SERVICE_TIMER_POLL_INTERVAL" default:"1s"`
 	}
+	// RestaurantIDs lists venues served by this deployment, separated by comma
+	RestaurantIDs string `env:"RESTAURANT_IDS" default:"default"`
+	// Restaurants holds settings of every venue in RestaurantIDs
+	Restaurants []*RestaurantConfig
 	SeatManager struct {
 		PreserveMaxRetries int `env:"PRESERVE_SEAT_MAX_RETRIES" default:"3"`
+		// CheckInTimeout bounds how long seats stay preserved for a ready party, zero disables it
+		CheckInTimeout       time.Duration `env:"CHECK_IN_TIMEOUT" default:"5m"`
+		CheckInTimeoutPolicy string        `env:"CHECK_IN_TIMEOUT_POLICY" default:"skip"`
+		CheckInPollInterval  time.Duration `env:"CHECK_IN_POLL_INTERVAL" default:"1s"`
+		// MaxPartySkips is how many times vacancy could go to parties behind a waiting party, zero means no limit
+		MaxPartySkips int `env:"MAX_PARTY_SKIPS" default:"3"`
+		// StarvationOverdueLimit is how long a party could wait past its estimated end of service, zero means no limit
+		StarvationOverdueLimit time.Duration `env:"STARVATION_OVERDUE_LIMIT" default:"15m"`
+	}
+	Reservation struct {
+		// HoldBefore is how long before its slot a reservation starts holding tables
+		HoldBefore time.Duration `env:"RESERVATION_HOLD_BEFORE" default:"15m"`
+		// GracePeriod is how long past its slot tables are still held, a party arriving within it is seated ahead of walk-ins
+		GracePeriod time.Duration `env:"RESERVATION_GRACE_PERIOD" default:"15m"`
+		// TurnTime is how long a reserved party keeps its tables, reservations closer than it compete for tables
+		TurnTime               time.Duration `env:"RESERVATION_TURN_TIME" default:"2h"`
+		HoldExpiryPollInterval time.Duration `env:"RESERVATION_HOLD_EXPIRY_POLL_INTERVAL" default:"1s"`
+	}
+	History struct {
+		// Dir keeps party lifecycle log, a file per restaurant and day
+		Dir string `env:"HISTORY_DIR" default:"data/history"`
 	}
+	HostDashboard struct {
+		Username string `env:"HOST_DASHBOARD_USERNAME" default:"host"`
+		Password string `env:"HOST_DASHBOARD_PASSWORD" required:"T"`
+	}
+	SSE struct {
+		// HeartbeatInterval is how often idle connections get a comment, so proxies do not drop them
+		HeartbeatInterval time.Duration `env:"SSE_HEARTBEAT_INTERVAL" default:"15s"`
+		// RetryInterval is how long browsers wait before reconnecting a dropped connection
+		RetryInterval time.Duration `env:"SSE_RETRY_INTERVAL" default:"3s"`
+	}
+}
+
+// RestaurantConfig is settings of a single venue.
+// Every value can be overridden per restaurant by prefixing its key with RESTAURANT_<ID>_,
+// e.g. RESTAURANT_DOWNTOWN_INSTANT_SERVE_HOST_DESK_SEAT_CAPACITY, otherwise the unprefixed key applies.
+type RestaurantConfig struct {
+	ID                               string
+	InstantServeHostDeskSeatCapacity int `env:"INSTANT_SERVE_HOST_DESK_SEAT_CAPACITY" default:"10"`
+	// InstantServeHostDeskCounterSeatCapacity is seats at counter, zero means no counter seating
+	InstantServeHostDeskCounterSeatCapacity int    `env:"INSTANT_SERVE_HOST_DESK_COUNTER_SEAT_CAPACITY" default:"0"`
+	PartyProcessingStrategy                 string `env:"PARTY_PROCESSING_STRATEGY" default:"fair"`
+	PartySelectionStrategy                  string `env:"PARTY_SELECTION_STRATEGY" default:"preference"`
+	// BestFitWindow is how many waiting parties from the head of queue best-fit selection looks at
+	BestFitWindow int `env:"BEST_FIT_WINDOW" default:"5"`
+	// TablesSpec is table inventory as ID:MIN-MAX:AREA[:COMBINABLE|...] separated by comma,
+	// empty means both areas are pools of single seats sized by the capacities above
+	TablesSpec string `env:"TABLES"`
+	// Tables is inventory parsed from TablesSpec
+	Tables []*hdd.Table
 }
 
 func LoadEnvConfig(getenv func(string) string) (*Config, error) {
 	loader := env.NewEnvLoader(env.WithEnvSource(getenv))
 	cfg := &Config{}
-	err := loader.Parse(cfg)
-	return cfg, err
+	if err := loader.Parse(cfg); err != nil {
+		return cfg, err
+	}
+
+	for _, id := range strings.Split(cfg.RestaurantIDs, ",") {
+		restaurant := &RestaurantConfig{ID: strings.TrimSpace(id)}
+		loader := env.NewEnvLoader(env.WithEnvSource(restaurantEnv(getenv, restaurant.ID)))
+		if err := loader.Parse(restaurant); err != nil {
+			return cfg, fmt.Errorf("restaurant %q: %w", restaurant.ID, err)
+		}
+		cfg.Restaurants = append(cfg.Restaurants, restaurant)
+	}
+	return cfg, nil
+}
+
+// restaurantEnv looks up key overridden for restaurant first and falls back to the shared one.
+func restaurantEnv(getenv func(string) string, id string) func(string) string {
+	prefix := "RESTAURANT_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+	return func(key string) string {
+		if val := getenv(prefix + key); val != "" {
+			return val
+		}
+		return getenv(key)
+	}
+}
+
+func restaurantTables(restaurant *RestaurantConfig) ([]*hdd.Table, error) {
+	if strings.TrimSpace(restaurant.TablesSpec) == "" {
+		tables := hdd.NewSeatPool(d.SeatingAreaTable, restaurant.InstantServeHostDeskSeatCapacity)
+		return append(tables, hdd.NewSeatPool(d.SeatingAreaCounter, restaurant.InstantServeHostDeskCounterSeatCapacity)...), nil
+	}
+	return hdd.ParseTables(restaurant.TablesSpec)
 }
 
 func NewConfig(getenv func(string) string) (*Config, error) {
@@ -54,7 +194,104 @@ func NewConfig(getenv func(string) string) (*Config, error) {
 		return nil, fmt.Errorf("Invalid server configuration, check your environment values: %v", err)
 	}
 
+	switch cfg.SeatManager.CheckInTimeoutPolicy {
+	case "skip", "drop":
+	default:
+		return nil, fmt.Errorf("Invalid server configuration, CHECK_IN_TIMEOUT_POLICY should be either skip or drop: %q", cfg.SeatManager.CheckInTimeoutPolicy)
+	}
+
+	switch cfg.EventBus.Driver {
+	case "pubsub", "stream", "memory":
+	default:
+		return nil, fmt.Errorf("Invalid server configuration, EVENTBUS_DRIVER should be one of pubsub, stream or memory: %q", cfg.EventBus.Driver)
+	}
+	if cfg.EventBus.MaxDeliveries < 1 || cfg.EventBus.RetryBackoff <= 0 || cfg.EventBus.MaxRetryBackoff < cfg.EventBus.RetryBackoff {
+		return nil, fmt.Errorf("Invalid server configuration, EVENTBUS_MAX_DELIVERIES and EVENTBUS_RETRY_BACKOFF should be positive and within EVENTBUS_MAX_RETRY_BACKOFF")
+	}
+	if cfg.EventBus.IdleGroupTimeout < 0 {
+		return nil, fmt.Errorf("Invalid server configuration, EVENTBUS_IDLE_GROUP_TIMEOUT should not be negative")
+	}
+	if cfg.EventBus.StreamWorkers < 1 {
+		return nil, fmt.Errorf("Invalid server configuration, EVENTBUS_STREAM_WORKERS should be positive")
+	}
+
+	switch cfg.Waitlist.Storage {
+	case "redis", "memory":
+	default:
+		return nil, fmt.Errorf("Invalid server configuration, WAITLIST_STORAGE should be either redis or memory: %q", cfg.Waitlist.Storage)
+	}
+
+	switch cfg.HostDesk.Storage {
+	case "redis", "memory":
+	default:
+		return nil, fmt.Errorf("Invalid server configuration, HOST_DESK_STORAGE should be either redis or memory: %q", cfg.HostDesk.Storage)
+	}
+
+	for _, key := range cfg.PreviousCookieKeys() {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("Invalid server configuration, every key of SECRET_COOKIE_PREVIOUS_KEYS should be exactly 32 bytes long")
+		}
+	}
+
+	if len(cfg.StreamToken.Key) < 32 || cfg.StreamToken.TTL <= 0 {
+		return nil, fmt.Errorf("Invalid server configuration, SECRET_STREAM_TOKEN_KEY should be at least 32 bytes long and STREAM_TOKEN_TTL positive")
+	}
+
+	if cfg.Recovery.LinkTTL <= 0 || cfg.Recovery.MaxAttempts < 1 || cfg.Recovery.MaxRestaurantAttempts < 1 || cfg.Recovery.AttemptWindow <= 0 || cfg.Recovery.RestaurantBackoff <= 0 {
+		return nil, fmt.Errorf("Invalid server configuration, RECOVERY_LINK_TTL, RECOVERY_MAX_ATTEMPTS, RECOVERY_MAX_RESTAURANT_ATTEMPTS, RECOVERY_ATTEMPT_WINDOW and RECOVERY_RESTAURANT_BACKOFF should be positive")
+	}
+
+	if cfg.HostDesk.ServiceTimerPollInterval <= 0 || cfg.SeatManager.CheckInPollInterval <= 0 || cfg.Reservation.HoldExpiryPollInterval <= 0 {
+		return nil, fmt.Errorf("Invalid server configuration, SERVICE_TIMER_POLL_INTERVAL, CHECK_IN_POLL_INTERVAL and RESERVATION_HOLD_EXPIRY_POLL_INTERVAL should be positive")
+	}
+
+	if cfg.SSE.HeartbeatInterval <= 0 || cfg.SSE.RetryInterval <= 0 {
+		return nil, fmt.Errorf("Invalid server configuration, SSE_HEARTBEAT_INTERVAL and SSE_RETRY_INTERVAL should be positive")
+	}
+
+	switch cfg.ServiceEstimator.Strategy {
+	case "fixed", "learning":
+	default:
+		return nil, fmt.Errorf("Invalid server configuration, SERVICE_ESTIMATOR should be either fixed or learning: %q", cfg.ServiceEstimator.Strategy)
+	}
+	if cfg.ServiceEstimator.LearningWindow < cfg.ServiceEstimator.LearningMinSamples || cfg.ServiceEstimator.LearningMinSamples < 1 {
+		return nil, fmt.Errorf("Invalid server configuration, LEARNING_SERVICE_ESTIMATOR_MIN_SAMPLES should be positive and within LEARNING_SERVICE_ESTIMATOR_WINDOW: %d", cfg.ServiceEstimator.LearningMinSamples)
+	}
+
+	for _, restaurant := range cfg.Restaurants {
+		if err := d.RestaurantID(restaurant.ID).Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid server configuration, check RESTAURANT_IDS: %v", err)
+		}
+		switch restaurant.PartyProcessingStrategy {
+		case "fair", "instant":
+		default:
+			return nil, fmt.Errorf("Invalid server configuration, PARTY_PROCESSING_STRATEGY of restaurant %q should be either fair or instant: %q", restaurant.ID, restaurant.PartyProcessingStrategy)
+		}
+		switch restaurant.PartySelectionStrategy {
+		case "ordered", "preference", "best-fit", "size-bucket":
+		default:
+			return nil, fmt.Errorf("Invalid server configuration, PARTY_SELECTION_STRATEGY of restaurant %q should be one of ordered, preference, best-fit or size-bucket: %q", restaurant.ID, restaurant.PartySelectionStrategy)
+		}
+		if restaurant.BestFitWindow < 1 {
+			return nil, fmt.Errorf("Invalid server configuration, BEST_FIT_WINDOW of restaurant %q should be positive: %d", restaurant.ID, restaurant.BestFitWindow)
+		}
+		if restaurant.Tables, err = restaurantTables(restaurant); err != nil {
+			return nil, fmt.Errorf("Invalid server configuration, TABLES of restaurant %q: %v", restaurant.ID, err)
+		}
+	}
+
 	cfg.Dev = getenv("APP_ENV") != "production"
 	cfg.Redis.Addr = fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
 	return cfg, nil
 }
+
+// PreviousCookieKeys lists keys of CookiePreviousKeys, none if no key was rotated out yet.
+func (cfg *Config) PreviousCookieKeys() []string {
+	keys := []string{}
+	for _, key := range strings.Split(cfg.CookiePreviousKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -20,8 +20,12 @@ type Party struct {
 	Name   string
 	Size   int
 	Status PartyStatus
+	// Where party would like to be seated, tables if not given.
+	Preference SeatingPreference
 	// Estimated time needed to serve this party once seated.
 	EstimatedServiceTime time.Duration
+	// ReservationID is set for party arriving on time for its reservation, it is seated ahead of walk-ins.
+	ReservationID string
 }
 
 func NewParty(id PartyID, name string, size int) *Party {
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// RestaurantID identifies a venue, every waitlist and host desk belongs to exactly one restaurant.
+type RestaurantID string
+
+// DefaultRestaurantID is used when deployment does not configure its restaurants.
+const DefaultRestaurantID RestaurantID = "default"
+
+var restaurantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Validate checks id is safe to be used in routes and storage keys.
+func (id RestaurantID) Validate() error {
+	if !restaurantIDPattern.MatchString(string(id)) {
+		return fmt.Errorf("invalid restaurant id %q, use lowercase letters, digits and dashes", id)
+	}
+	return nil
+}
+
+// Path prefixes path with the route of restaurant, e.g. /r/<id>/waitlist.
+func (id RestaurantID) Path(path string) string {
+	return fmt.Sprintf("/r/%s%s", id, path)
+}
+
+type restaurantCtxKey struct{}
+
+func WithRestaurantID(ctx context.Context, id RestaurantID) context.Context {
+	return context.WithValue(ctx, restaurantCtxKey{}, id)
+}
+
+// RestaurantIDFromContext returns restaurant of current request, the default restaurant if none.
+func RestaurantIDFromContext(ctx context.Context) RestaurantID {
+	if id, ok := ctx.Value(restaurantCtxKey{}).(RestaurantID); ok {
+		return id
+	}
+	return DefaultRestaurantID
+}
@@ -0,0 +1,70 @@
+package domain
+
+// SeatingArea is a part of the restaurant with its own seats and its own waitlist queue.
+type SeatingArea string
+
+func (a SeatingArea) MarshalBinary() ([]byte, error) {
+	return []byte(string(a)), nil
+}
+
+func (a *SeatingArea) UnmarshalBinary(data []byte) error {
+	*a = SeatingArea(data)
+	return nil
+}
+
+const (
+	SeatingAreaTable   SeatingArea = "table"
+	SeatingAreaCounter SeatingArea = "counter"
+)
+
+// SeatingAreas lists every area, tables go first as they are the default seating.
+var SeatingAreas = []SeatingArea{SeatingAreaTable, SeatingAreaCounter}
+
+// SeatingPreference is where party would like to be seated.
+type SeatingPreference string
+
+func (p SeatingPreference) MarshalBinary() ([]byte, error) {
+	return []byte(string(p)), nil
+}
+
+func (p *SeatingPreference) UnmarshalBinary(data []byte) error {
+	*p = SeatingPreference(data)
+	return nil
+}
+
+const (
+	SeatingPreferenceTable   SeatingPreference = "table"
+	SeatingPreferenceCounter SeatingPreference = "counter"
+	SeatingPreferenceAny     SeatingPreference = "any"
+)
+
+// QueueArea is the area whose queue party waits in.
+// Parties without a strong preference wait for tables, and could still be offered the counter.
+func (p SeatingPreference) QueueArea() SeatingArea {
+	if p == SeatingPreferenceCounter {
+		return SeatingAreaCounter
+	}
+	return SeatingAreaTable
+}
+
+// Areas lists where party accepts to be seated, in the order of preference.
+func (p SeatingPreference) Areas() []SeatingArea {
+	switch p {
+	case SeatingPreferenceCounter:
+		return []SeatingArea{SeatingAreaCounter}
+	case SeatingPreferenceAny:
+		return []SeatingArea{SeatingAreaTable, SeatingAreaCounter}
+	default:
+		return []SeatingArea{SeatingAreaTable}
+	}
+}
+
+// Accepts tells if party is fine with being seated in area.
+func (p SeatingPreference) Accepts(area SeatingArea) bool {
+	for _, a := range p.Areas() {
+		if a == area {
+			return true
+		}
+	}
+	return false
+}
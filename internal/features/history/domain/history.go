@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"time"
+
+	d "queue-bite/internal/domain"
+)
+
+// PartyEventKind is a step of party lifecycle, from joining the waitlist to leaving the restaurant.
+type PartyEventKind string
+
+const (
+	PartyJoined    PartyEventKind = "joined"
+	PartyReady     PartyEventKind = "ready"
+	PartyCheckedIn PartyEventKind = "checked_in"
+	PartyCompleted PartyEventKind = "completed"
+	// PartyLeft is party given up waiting, either by itself or removed by the host.
+	PartyLeft PartyEventKind = "left"
+	// PartyExpired is party missed its check-in window.
+	PartyExpired PartyEventKind = "expired"
+)
+
+// PartyEvent is an entry of the append-only party lifecycle log.
+type PartyEvent struct {
+	RestaurantID d.RestaurantID `json:"restaurant"`
+	PartyID      d.PartyID      `json:"party"`
+	Kind         PartyEventKind `json:"kind"`
+	Size         int            `json:"size"`
+	At           time.Time      `json:"at"`
+}
+
+// DAY_LAYOUT is how days of daily stats are written, in local time of the restaurant.
+var DAY_LAYOUT = "2006-01-02"
+
+// DailyStats aggregates party lifecycle of a single day.
+type DailyStats struct {
+	Day       string
+	Joined    int
+	Seated    int
+	Completed int
+	Left      int
+	Expired   int
+	// AverageWait is from joining to check-in, of parties checked in on the day.
+	AverageWait time.Duration
+	// AverageService is from check-in to completion, of parties completed on the day.
+	AverageService time.Duration
+}
+
+// StartOfDay is midnight in local time of the day at falls on.
+func StartOfDay(at time.Time) time.Time {
+	year, month, day := at.Local().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+// AggregateDaily counts events per local day from the day of from to the day of to, both inclusive.
+// The log could hold the same step of a party more than once, e.g. recorded by several instances,
+// the earliest one counts. Waits and services started before from need their earlier events in events.
+func AggregateDaily(events []*PartyEvent, from, to time.Time) []*DailyStats {
+	steps := map[d.PartyID]map[PartyEventKind]time.Time{}
+	for _, event := range events {
+		party, ok := steps[event.PartyID]
+		if !ok {
+			party = map[PartyEventKind]time.Time{}
+			steps[event.PartyID] = party
+		}
+		if at, ok := party[event.Kind]; !ok || event.At.Before(at) {
+			party[event.Kind] = event.At
+		}
+	}
+
+	stats := []*DailyStats{}
+	byDay := map[string]*DailyStats{}
+	for day := StartOfDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		stat := &DailyStats{Day: day.Format(DAY_LAYOUT)}
+		stats = append(stats, stat)
+		byDay[stat.Day] = stat
+	}
+
+	waits, services := map[string][]time.Duration{}, map[string][]time.Duration{}
+	for _, party := range steps {
+		for kind, at := range party {
+			day := at.Local().Format(DAY_LAYOUT)
+			stat, ok := byDay[day]
+			if !ok {
+				continue
+			}
+
+			switch kind {
+			case PartyJoined:
+				stat.Joined++
+			case PartyCheckedIn:
+				stat.Seated++
+				if joinedAt, ok := party[PartyJoined]; ok {
+					waits[day] = append(waits[day], at.Sub(joinedAt))
+				}
+			case PartyCompleted:
+				stat.Completed++
+				if checkedInAt, ok := party[PartyCheckedIn]; ok {
+					services[day] = append(services[day], at.Sub(checkedInAt))
+				}
+			case PartyLeft:
+				stat.Left++
+			case PartyExpired:
+				stat.Expired++
+			}
+		}
+	}
+
+	for _, stat := range stats {
+		stat.AverageWait = average(waits[stat.Day])
+		stat.AverageService = average(services[stat.Day])
+	}
+	return stats
+}
+
+func average(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, duration := range durations {
+		total += duration
+	}
+	return (total / time.Duration(len(durations))).Round(time.Second)
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/history/domain"
+)
+
+var FILE_HISTORY = "history/file"
+
+// FilePartyHistoryRepository appends events as JSON lines, one file per local day of a restaurant,
+// <dir>/<restaurant>/<day>.jsonl, so old days could be archived or removed file by file.
+// Lines are appended with a single write to a file opened for appending, instances sharing dir
+// do not interleave their lines.
+type FilePartyHistoryRepository struct {
+	logger log.Logger
+	dir    string
+	mu     sync.Mutex
+}
+
+func NewFilePartyHistoryRepository(logger log.Logger, dir string, restaurantID d.RestaurantID) PartyHistoryRepository {
+	return &FilePartyHistoryRepository{
+		logger: logger,
+		dir:    filepath.Join(dir, string(restaurantID)),
+	}
+}
+
+func (r *FilePartyHistoryRepository) AppendPartyEvent(ctx context.Context, event *domain.PartyEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		r.logger.LogErr(FILE_HISTORY, err, "could not create history dir", "dir", r.dir)
+		return err
+	}
+	file, err := os.OpenFile(r.dayFile(event.At), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		r.logger.LogErr(FILE_HISTORY, err, "could not open history file", "event", event)
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		r.logger.LogErr(FILE_HISTORY, err, "could not append party event", "event", event)
+		return err
+	}
+	return nil
+}
+
+func (r *FilePartyHistoryRepository) GetPartyEvents(ctx context.Context, from, to time.Time) ([]*domain.PartyEvent, error) {
+	events := []*domain.PartyEvent{}
+	for day := domain.StartOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		dayEvents, err := r.readDay(day)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range dayEvents {
+			if !event.At.Before(from) && event.At.Before(to) {
+				events = append(events, event)
+			}
+		}
+	}
+	return events, nil
+}
+
+// readDay reads events of day, a line which could not be parsed, e.g. cut off by a crash, is skipped.
+func (r *FilePartyHistoryRepository) readDay(day time.Time) ([]*domain.PartyEvent, error) {
+	file, err := os.Open(r.dayFile(day))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := []*domain.PartyEvent{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := &domain.PartyEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			r.logger.LogErr(FILE_HISTORY, err, "skip unreadable party event", "file", file.Name())
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read history file %s: %w", file.Name(), err)
+	}
+	return events, nil
+}
+
+func (r *FilePartyHistoryRepository) dayFile(at time.Time) string {
+	return filepath.Join(r.dir, at.Local().Format(domain.DAY_LAYOUT)+".jsonl")
+}
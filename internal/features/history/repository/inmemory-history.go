@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/features/history/domain"
+)
+
+type InMemoryPartyHistoryRepository struct {
+	logger log.Logger
+	mu     sync.RWMutex
+	events []domain.PartyEvent
+}
+
+func NewInMemoryPartyHistoryRepository(logger log.Logger) PartyHistoryRepository {
+	return &InMemoryPartyHistoryRepository{logger: logger}
+}
+
+func (r *InMemoryPartyHistoryRepository) AppendPartyEvent(ctx context.Context, event *domain.PartyEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *InMemoryPartyHistoryRepository) GetPartyEvents(ctx context.Context, from, to time.Time) ([]*domain.PartyEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []*domain.PartyEvent{}
+	for _, event := range r.events {
+		if !event.At.Before(from) && event.At.Before(to) {
+			event := event
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"queue-bite/internal/features/history/domain"
+)
+
+// PartyHistoryRepository keeps the party lifecycle log of a restaurant, entries are never changed once appended.
+type PartyHistoryRepository interface {
+	AppendPartyEvent(ctx context.Context, event *domain.PartyEvent) error
+
+	// GetPartyEvents returns events happened from from until before to.
+	GetPartyEvents(ctx context.Context, from, to time.Time) ([]*domain.PartyEvent, error)
+}
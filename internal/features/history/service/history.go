@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/history/domain"
+	"queue-bite/internal/features/history/repository"
+)
+
+var PARTY_HISTORY = "history"
+
+// PartyHistory keeps track of what happened to every party of a restaurant after it is gone from the waitlist and seats.
+type PartyHistory interface {
+	// Record appends a lifecycle step of party to the log.
+	Record(ctx context.Context, partyID d.PartyID, size int, kind domain.PartyEventKind, at time.Time) error
+
+	// GetDailyStats aggregates the log per local day from the day of from to the day of to, both inclusive.
+	GetDailyStats(ctx context.Context, from, to time.Time) ([]*domain.DailyStats, error)
+}
+
+type partyHistory struct {
+	logger       log.Logger
+	restaurantID d.RestaurantID
+	repo         repository.PartyHistoryRepository
+}
+
+func NewPartyHistory(logger log.Logger, restaurantID d.RestaurantID, repo repository.PartyHistoryRepository) PartyHistory {
+	return &partyHistory{
+		logger:       logger,
+		restaurantID: restaurantID,
+		repo:         repo,
+	}
+}
+
+func (h *partyHistory) Record(ctx context.Context, partyID d.PartyID, size int, kind domain.PartyEventKind, at time.Time) error {
+	event := &domain.PartyEvent{
+		RestaurantID: h.restaurantID,
+		PartyID:      partyID,
+		Kind:         kind,
+		Size:         size,
+		At:           at,
+	}
+	if err := h.repo.AppendPartyEvent(ctx, event); err != nil {
+		return err
+	}
+	h.logger.LogDebug(PARTY_HISTORY, "party event recorded", "event", event)
+	return nil
+}
+
+// GetDailyStats reads a day around the range as well, so waits and services crossing midnight are complete.
+func (h *partyHistory) GetDailyStats(ctx context.Context, from, to time.Time) ([]*domain.DailyStats, error) {
+	from, to = domain.StartOfDay(from), domain.StartOfDay(to)
+	events, err := h.repo.GetPartyEvents(ctx, from.AddDate(0, 0, -1), to.AddDate(0, 0, 2))
+	if err != nil {
+		return nil, err
+	}
+	return domain.AggregateDaily(events, from, to), nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/history/domain"
+	"queue-bite/internal/features/history/repository"
+)
+
+func TestPartyHistory(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNoopLogger()
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+
+	impl := map[string]repository.PartyHistoryRepository{
+		"in-memory": repository.NewInMemoryPartyHistoryRepository(logger),
+		"file":      repository.NewFilePartyHistoryRepository(logger, t.TempDir(), d.DefaultRestaurantID),
+	}
+	for name, repo := range impl {
+		t.Run(name, func(t *testing.T) {
+			history := NewPartyHistory(logger, d.DefaultRestaurantID, repo)
+			record := func(partyID d.PartyID, kind domain.PartyEventKind, at time.Time) {
+				require.NoError(t, history.Record(ctx, partyID, 2, kind, at))
+			}
+
+			// joined before midnight, seated on friday
+			record("party-1", domain.PartyJoined, friday.Add(-10*time.Minute))
+			record("party-1", domain.PartyCheckedIn, friday.Add(10*time.Minute))
+			record("party-1", domain.PartyCompleted, friday.Add(70*time.Minute))
+			// recorded twice, by every instance handling the broadcast
+			record("party-1", domain.PartyCompleted, friday.Add(71*time.Minute))
+
+			record("party-2", domain.PartyJoined, friday.Add(12*time.Hour))
+			record("party-2", domain.PartyReady, friday.Add(12*time.Hour+30*time.Minute))
+			record("party-2", domain.PartyCheckedIn, friday.Add(12*time.Hour+40*time.Minute))
+
+			record("party-3", domain.PartyJoined, friday.Add(13*time.Hour))
+			record("party-3", domain.PartyLeft, friday.Add(13*time.Hour+5*time.Minute))
+
+			record("party-4", domain.PartyJoined, friday.Add(14*time.Hour))
+			record("party-4", domain.PartyReady, friday.Add(14*time.Hour+5*time.Minute))
+			record("party-4", domain.PartyExpired, friday.Add(14*time.Hour+10*time.Minute))
+
+			record("party-5", domain.PartyJoined, friday.Add(25*time.Hour))
+
+			stats, err := history.GetDailyStats(ctx, friday.Add(12*time.Hour), friday.Add(36*time.Hour))
+			require.NoError(t, err)
+			require.Len(t, stats, 2)
+
+			assert.Equal(t, &domain.DailyStats{
+				Day:            "2026-10-16",
+				Joined:         3,
+				Seated:         2,
+				Completed:      1,
+				Left:           1,
+				Expired:        1,
+				AverageWait:    30 * time.Minute,
+				AverageService: time.Hour,
+			}, stats[0])
+			assert.Equal(t, &domain.DailyStats{Day: "2026-10-17", Joined: 1}, stats[1])
+		})
+	}
+}
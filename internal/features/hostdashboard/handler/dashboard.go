@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/hostdashboard/handler/view"
+	hd "queue-bite/internal/features/hostdesk/service"
+	rs "queue-bite/internal/features/reservation/service"
+	wld "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
+)
+
+var HOST_DASHBOARD = "hostdashboard"
+
+// UPCOMING_RESERVATIONS_WITHIN is how far ahead the dashboard lists reservations.
+var UPCOMING_RESERVATIONS_WITHIN = 24 * time.Hour
+
+func (h *hostDashboardHandler) HandleDashboardDisplay(
+	logger log.Logger,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		props, err := loadDashboardProps(r.Context(), waitlist, hostdesk, reservations)
+		if err != nil {
+			logger.LogErr(HOST_DASHBOARD, err, "failed to load host dashboard")
+			http.Error(w, "Failed to load host dashboard", http.StatusInternalServerError)
+			return
+		}
+
+		templ.Handler(view.DashboardPage(props)).ServeHTTP(w, r)
+	}
+}
+
+func (h *hostDashboardHandler) HandlePartiesDisplay(
+	logger log.Logger,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
+
+func renderParties(
+	logger log.Logger,
+	w http.ResponseWriter,
+	r *http.Request,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+	errorMessage string,
+) {
+	props, err := loadDashboardProps(r.Context(), waitlist, hostdesk, reservations)
+	if err != nil {
+		logger.LogErr(HOST_DASHBOARD, err, "failed to load parties for host dashboard")
+		http.Error(w, "Failed to load parties", http.StatusInternalServerError)
+		return
+	}
+
+	props.ErrorMessage = errorMessage
+	templ.Handler(view.DashboardParties(props)).ServeHTTP(w, r)
+}
+
+func loadDashboardProps(ctx context.Context, waitlist ws.Waitlist, hostdesk hd.HostDesk, reservations rs.ReservationBook) (*view.DashboardProps, error) {
+	areaSeats := []*view.AreaSeats{}
+	queuedParties := []*wld.QueuedParty{}
+	for _, area := range d.SeatingAreas {
+		totalSeats, err := hostdesk.GetTotalCapacity(ctx, area)
+		if err != nil {
+			return nil, err
+		}
+
+		occupied, err := hostdesk.GetOccupiedSeats(ctx, area)
+		if err != nil {
+			return nil, err
+		}
+
+		preserved, err := hostdesk.GetPreservedSeats(ctx, area)
+		if err != nil {
+			return nil, err
+		}
+
+		available, _, err := hostdesk.GetCurrentCapacity(ctx, area)
+		if err != nil {
+			return nil, err
+		}
+		areaSeats = append(areaSeats, view.NewAreaSeats(area, totalSeats, occupied, preserved, available))
+
+		partyStream, err := waitlist.GetQueuedParties(ctx, area)
+		if err != nil {
+			return nil, err
+		}
+
+		for party := range partyStream {
+			queuedParties = append(queuedParties, party)
+		}
+	}
+
+	seatedParties, err := hostdesk.GetPartyServiceStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming, err := reservations.GetUpcoming(ctx, time.Now().Add(UPCOMING_RESERVATIONS_WITHIN))
+	if err != nil {
+		return nil, err
+	}
+
+	return view.NewDashboardProps(queuedParties, seatedParties, areaSeats, upcoming), nil
+}
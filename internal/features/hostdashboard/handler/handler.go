@@ -0,0 +1,7 @@
+package handler
+
+type hostDashboardHandler struct{}
+
+func NewHostDashboardHandler() *hostDashboardHandler {
+	return &hostDashboardHandler{}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	hsd "queue-bite/internal/features/history/domain"
+	hs "queue-bite/internal/features/history/service"
+	"queue-bite/pkg/utils"
+)
+
+var HOST_DASHBOARD_HISTORY = "hostdashboard/history"
+
+// DAILY_STATS_DEFAULT_DAYS is how many days up to today daily stats cover when no range is asked for.
+var DAILY_STATS_DEFAULT_DAYS = 7
+
+// DAILY_STATS_MAX_DAYS bounds a single query, every day in range is read from the log.
+var DAILY_STATS_MAX_DAYS = 92
+
+type dailyStatsResponse struct {
+	Day                   string `json:"day"`
+	Joined                int    `json:"joined"`
+	Seated                int    `json:"seated"`
+	Completed             int    `json:"completed"`
+	Left                  int    `json:"left"`
+	Expired               int    `json:"expired"`
+	AverageWaitSeconds    int64  `json:"average_wait_seconds"`
+	AverageServiceSeconds int64  `json:"average_service_seconds"`
+}
+
+// HandleDailyStats answers party aggregates per day as JSON, days are asked for by ?from=YYYY-MM-DD&to=YYYY-MM-DD
+// in local time of the restaurant, both inclusive.
+func (h *hostDashboardHandler) HandleDailyStats(logger log.Logger, history hs.PartyHistory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		to := time.Now()
+		if day := r.URL.Query().Get("to"); day != "" {
+			parsed, err := time.ParseInLocation(hsd.DAY_LAYOUT, day, time.Local)
+			if err != nil {
+				http.Error(w, "Invalid to day, expect YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			to = parsed
+		}
+		from := to.AddDate(0, 0, 1-DAILY_STATS_DEFAULT_DAYS)
+		if day := r.URL.Query().Get("from"); day != "" {
+			parsed, err := time.ParseInLocation(hsd.DAY_LAYOUT, day, time.Local)
+			if err != nil {
+				http.Error(w, "Invalid from day, expect YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			from = parsed
+		}
+		if from.After(to) || to.Sub(from) > time.Duration(DAILY_STATS_MAX_DAYS)*24*time.Hour {
+			http.Error(w, "Invalid day range", http.StatusBadRequest)
+			return
+		}
+
+		stats, err := history.GetDailyStats(r.Context(), from, to)
+		if err != nil {
+			logger.LogErr(HOST_DASHBOARD_HISTORY, err, "failed to get daily stats", "from", from, "to", to)
+			http.Error(w, "Failed to get daily stats", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]dailyStatsResponse, 0, len(stats))
+		for _, stat := range stats {
+			response = append(response, dailyStatsResponse{
+				Day:                   stat.Day,
+				Joined:                stat.Joined,
+				Seated:                stat.Seated,
+				Completed:             stat.Completed,
+				Left:                  stat.Left,
+				Expired:               stat.Expired,
+				AverageWaitSeconds:    int64(stat.AverageWait.Seconds()),
+				AverageServiceSeconds: int64(stat.AverageService.Seconds()),
+			})
+		}
+		if err := utils.Encode(w, r, http.StatusOK, response); err != nil {
+			logger.LogErr(HOST_DASHBOARD_HISTORY, err, "failed to encode daily stats")
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	hd "queue-bite/internal/features/hostdesk/service"
+	rsd "queue-bite/internal/features/reservation/domain"
+	rs "queue-bite/internal/features/reservation/service"
+	sm "queue-bite/internal/features/seatmanager/service"
+	wld "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
+)
+
+var HOST_DASHBOARD_ACTION = "hostdashboard/action"
+
+func (h *hostDashboardHandler) HandleRemoveParty(
+	logger log.Logger,
+	seatManager sm.SeatManager,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		partyID := d.PartyID(chi.URLParam(r, "partyID"))
+		if err := seatManager.RemoveParty(r.Context(), partyID); err != nil {
+			logger.LogErr(HOST_DASHBOARD_ACTION, err, "host failed to remove party", "party id", partyID)
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, errorMessageOf(err, "Failed to remove party"))
+			return
+		}
+
+		logger.LogDebug(HOST_DASHBOARD_ACTION, "party removed by host", "party id", partyID)
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
+
+func (h *hostDashboardHandler) HandleReadyParty(
+	logger log.Logger,
+	seatManager sm.SeatManager,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		partyID := d.PartyID(chi.URLParam(r, "partyID"))
+		if err := seatManager.ReadyParty(r.Context(), partyID); err != nil {
+			logger.LogErr(HOST_DASHBOARD_ACTION, err, "host failed to ready party", "party id", partyID)
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, errorMessageOf(err, "Failed to call party"))
+			return
+		}
+
+		logger.LogDebug(HOST_DASHBOARD_ACTION, "party called by host", "party id", partyID)
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
+
+func (h *hostDashboardHandler) HandleCompleteParty(
+	logger log.Logger,
+	seatManager sm.SeatManager,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		partyID := d.PartyID(chi.URLParam(r, "partyID"))
+		if err := seatManager.PartyServiceComplete(r.Context(), partyID); err != nil {
+			logger.LogErr(HOST_DASHBOARD_ACTION, err, "host failed to complete party service", "party id", partyID)
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, errorMessageOf(err, "Failed to complete service"))
+			return
+		}
+
+		logger.LogDebug(HOST_DASHBOARD_ACTION, "party service completed by host", "party id", partyID)
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
+
+func (h *hostDashboardHandler) HandleCheckInParty(
+	logger log.Logger,
+	seatManager sm.SeatManager,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		partyID := d.PartyID(chi.URLParam(r, "partyID"))
+		if err := seatManager.PartyCheckIn(r.Context(), partyID); err != nil {
+			logger.LogErr(HOST_DASHBOARD_ACTION, err, "host failed to check in party", "party id", partyID)
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, errorMessageOf(err, "Failed to check in party"))
+			return
+		}
+
+		logger.LogDebug(HOST_DASHBOARD_ACTION, "party checked in by host", "party id", partyID)
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
+
+func errorMessageOf(err error, fallback string) string {
+	switch err {
+	case wld.ErrPartyNotFound, hdd.ErrPartyNotFound:
+		return "Party is no longer in line or seated"
+	case wld.ErrInvalidPartyStatusTransition:
+		return "Party has already been called"
+	case hdd.ErrInsufficientCapacity:
+		return "Not enough seats available for this party"
+	case hdd.ErrPartyNotSeated:
+		return "Party has not checked in yet"
+	case rsd.ErrReservationNotFound:
+		return "Reservation is not found"
+	case rsd.ErrReservationNotBooked:
+		return "Reservation has already arrived or was given up"
+	case rsd.ErrSlotInPast:
+		return "Reservation time has already passed"
+	case rsd.ErrPartyTooLarge:
+		return "No tables could seat a party of this size"
+	case rsd.ErrFullyBooked:
+		return "Tables are fully booked around this time"
+	}
+	return fallback
+}
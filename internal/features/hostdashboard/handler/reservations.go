@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/form/v4"
+	"github.com/go-playground/validator/v10"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hd "queue-bite/internal/features/hostdesk/service"
+	rsd "queue-bite/internal/features/reservation/domain"
+	rs "queue-bite/internal/features/reservation/service"
+	sm "queue-bite/internal/features/seatmanager/service"
+	ws "queue-bite/internal/features/waitlist/service"
+)
+
+var HOST_DASHBOARD_RESERVATION = "hostdashboard/reservation"
+
+// RESERVATION_SLOT_LAYOUT is how datetime-local inputs send the slot, in local time of the restaurant.
+var RESERVATION_SLOT_LAYOUT = "2006-01-02T15:04"
+
+func (h *hostDashboardHandler) HandleBookReservation(
+	logger log.Logger,
+	validate *validator.Validate,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	formDecoder := form.NewDecoder()
+
+	type BookReservationRequest struct {
+		PartyName string        `validate:"required"`
+		PartySize int           `validate:"required,min=1"`
+		Area      d.SeatingArea `validate:"required,oneof=table counter"`
+		SlotAt    string        `validate:"required"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var payload BookReservationRequest
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid reservation", http.StatusBadRequest)
+			return
+		}
+		if err := formDecoder.Decode(&payload, r.Form); err != nil {
+			http.Error(w, "Invalid reservation", http.StatusBadRequest)
+			return
+		}
+		if err := validate.Struct(&payload); err != nil {
+			logger.LogErr(HOST_DASHBOARD_RESERVATION, err, "book reservation validation failed")
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, "Name, party size, seating and time are needed to book")
+			return
+		}
+
+		slotAt, err := time.ParseInLocation(RESERVATION_SLOT_LAYOUT, payload.SlotAt, time.Local)
+		if err != nil {
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, "Reservation time is not valid")
+			return
+		}
+
+		reservation, err := reservations.Book(r.Context(), payload.PartyName, payload.PartySize, payload.Area, slotAt)
+		if err != nil {
+			logger.LogErr(HOST_DASHBOARD_RESERVATION, err, "host failed to book reservation", "payload", payload)
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, errorMessageOf(err, "Failed to book reservation"))
+			return
+		}
+
+		logger.LogDebug(HOST_DASHBOARD_RESERVATION, "reservation booked by host", "reservation", reservation)
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
+
+func (h *hostDashboardHandler) HandleReservationArrival(
+	logger log.Logger,
+	seatManager sm.SeatManager,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := rsd.ReservationID(chi.URLParam(r, "reservationID"))
+		party, err := seatManager.ProcessReservationArrival(r.Context(), id)
+		if err != nil {
+			logger.LogErr(HOST_DASHBOARD_RESERVATION, err, "host failed to process reservation arrival", "reservation id", id)
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, errorMessageOf(err, "Failed to queue arrived reservation"))
+			return
+		}
+
+		logger.LogDebug(HOST_DASHBOARD_RESERVATION, "reservation arrived", "reservation id", id, "party", party)
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
+
+func (h *hostDashboardHandler) HandleCancelReservation(
+	logger log.Logger,
+	seatManager sm.SeatManager,
+	waitlist ws.Waitlist,
+	hostdesk hd.HostDesk,
+	reservations rs.ReservationBook,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := rsd.ReservationID(chi.URLParam(r, "reservationID"))
+		if err := seatManager.CancelReservation(r.Context(), id); err != nil {
+			logger.LogErr(HOST_DASHBOARD_RESERVATION, err, "host failed to cancel reservation", "reservation id", id)
+			renderParties(logger, w, r, waitlist, hostdesk, reservations, errorMessageOf(err, "Failed to cancel reservation"))
+			return
+		}
+
+		logger.LogDebug(HOST_DASHBOARD_RESERVATION, "reservation cancelled by host", "reservation id", id)
+		renderParties(logger, w, r, waitlist, hostdesk, reservations, "")
+	}
+}
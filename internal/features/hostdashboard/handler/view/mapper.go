@@ -0,0 +1,32 @@
+package view
+
+import (
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	rsd "queue-bite/internal/features/reservation/domain"
+	wld "queue-bite/internal/features/waitlist/domain"
+)
+
+func NewDashboardProps(
+	queuedParties []*wld.QueuedParty,
+	seatedParties []*hdd.PartyServiceState,
+	areaSeats []*AreaSeats,
+	reservations []*rsd.Reservation,
+) *DashboardProps {
+	return &DashboardProps{
+		AreaSeats:     areaSeats,
+		QueuedParties: queuedParties,
+		SeatedParties: seatedParties,
+		Reservations:  reservations,
+	}
+}
+
+func NewAreaSeats(area d.SeatingArea, totalSeats int, occupiedSeats int, preservedSeats int, availableSeats int) *AreaSeats {
+	return &AreaSeats{
+		Area:           area,
+		TotalSeats:     totalSeats,
+		OccupiedSeats:  occupiedSeats,
+		PreservedSeats: preservedSeats,
+		AvailableSeats: availableSeats,
+	}
+}
@@ -12,4 +12,5 @@ var (
 	ErrPartyNoPreservedSeats = errors.New("party has no preserved seats")
 	ErrPartyAlreadyReady     = errors.New("party is already ready")
 	ErrPartyAlreadySeated    = errors.New("party is already seated")
+	ErrPartyNotSeated        = errors.New("party is not seated")
 )
@@ -1,11 +1,16 @@
 package domain
 
 import (
+	"time"
+
 	d "queue-bite/internal/domain"
 	"queue-bite/internal/platform/eventbus"
 )
 
-type SeatsPreservedEvent struct{ PartyID d.PartyID }
+type SeatsPreservedEvent struct {
+	RestaurantID d.RestaurantID
+	PartyID      d.PartyID
+}
 
 func (e SeatsPreservedEvent) Topic() string { return TopicPartyPreserved }
 
@@ -13,7 +18,16 @@ func (e SeatsPreservedEvent) NewEvent() eventbus.Event {
 	return &SeatsPreservedEvent{}
 }
 
-type PartyServiceCompeletedEvent struct{ PartyID d.PartyID }
+type PartyServiceCompeletedEvent struct {
+	RestaurantID d.RestaurantID
+	PartyID      d.PartyID
+	PartySize    int
+	CheckedInAt  time.Time
+	CompletedAt  time.Time
+	// EndedByTimer is set when service timer ran out before staff completed the party,
+	// so the party stayed at least as long as it was estimated.
+	EndedByTimer bool
+}
 
 func (e PartyServiceCompeletedEvent) Topic() string { return TopicPartyServiceCompleted }
 
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// SeatingForecast simulates tables of an area freeing up and being taken by parties in line.
+// Seated parties give back their tables once their estimated service ends, parties in line are seated
+// one after another in queue order, each at the first tables fitting it and never before the party ahead.
+type SeatingForecast struct {
+	start    time.Time
+	at       time.Time
+	free     []*Table
+	releases []tableRelease
+}
+
+type tableRelease struct {
+	at     time.Time
+	tables []*Table
+}
+
+// NewSeatingForecast starts simulation at start with free tables of vacancy.
+func NewSeatingForecast(start time.Time, vacancy *SeatingVacancy) *SeatingForecast {
+	return &SeatingForecast{
+		start: start,
+		at:    start,
+		free:  append([]*Table{}, vacancy.Tables...),
+	}
+}
+
+// Start is when simulation starts, the time parties already holding tables are seated at.
+func (f *SeatingForecast) Start() time.Time {
+	return f.start
+}
+
+// Release gives tables back at time at, tables of parties overdue are given back on start.
+func (f *SeatingForecast) Release(tables []*Table, at time.Time) {
+	if len(tables) == 0 {
+		return
+	}
+	if at.Before(f.start) {
+		at = f.start
+	}
+
+	i := sort.Search(len(f.releases), func(i int) bool { return f.releases[i].at.After(at) })
+	f.releases = append(f.releases, tableRelease{})
+	copy(f.releases[i+1:], f.releases[i:])
+	f.releases[i] = tableRelease{at: at, tables: tables}
+}
+
+// Seat returns the earliest time party of size could be seated after parties seated before it,
+// the tables it takes are given back once duration passed.
+// Returns false if tables fitting party never free up, nothing is taken then.
+func (f *SeatingForecast) Seat(size int, duration time.Duration) (time.Time, bool) {
+	at, free, releases := f.at, f.free, f.releases
+	for {
+		for len(releases) > 0 && !releases[0].at.After(at) {
+			free = append(free, releases[0].tables...)
+			releases = releases[1:]
+		}
+
+		if tables := AssignTables(free, size); tables != nil {
+			f.at, f.free, f.releases = at, withoutTables(free, tables), releases
+			f.Release(tables, at.Add(duration))
+			return at, true
+		}
+		if len(releases) == 0 {
+			return time.Time{}, false
+		}
+		at = releases[0].at
+	}
+}
+
+// withoutTables returns tables not taken, tables itself is left as is.
+func withoutTables(tables []*Table, taken []*Table) []*Table {
+	isTaken := make(map[TableID]bool, len(taken))
+	for _, table := range taken {
+		isTaken[table.ID] = true
+	}
+	rest := []*Table{}
+	for _, table := range tables {
+		if !isTaken[table.ID] {
+			rest = append(rest, table)
+		}
+	}
+	return rest
+}
@@ -23,28 +23,46 @@ const (
 )
 
 type PartyServiceState struct {
-	ID          domain.PartyID `redis:"ID"`
-	Status      SeatStatus     `redis:"Status"`
-	SeatsCount  int            `redis:"SeatsCount"`
-	PreservedAt time.Time      `redis:"PreservedAt"`
-	CheckedInAt time.Time      `redis:"CheckedInAt"`
+	ID          domain.PartyID     `redis:"ID"`
+	Status      SeatStatus         `redis:"Status"`
+	SeatsCount  int                `redis:"SeatsCount"`
+	PartySize   int                `redis:"PartySize"`
+	Area        domain.SeatingArea `redis:"Area"`
+	Tables      TableIDs           `redis:"Tables"`
+	PreservedAt time.Time          `redis:"PreservedAt"`
+	CheckedInAt time.Time          `redis:"CheckedInAt"`
 }
 
-func NewPartyServiceFromPreserve(partyID domain.PartyID, seats int) *PartyServiceState {
+// NewPartyServiceFromPreserve holds tables for party, it takes every seat of the tables even if party does not fill them.
+func NewPartyServiceFromPreserve(partyID domain.PartyID, partySize int, tables []*Table, area domain.SeatingArea) *PartyServiceState {
 	return &PartyServiceState{
 		ID:          partyID,
 		Status:      SeatPreserved,
-		SeatsCount:  seats,
+		SeatsCount:  TotalCovers(tables),
+		PartySize:   partySize,
+		Area:        area,
+		Tables:      IDsOf(tables),
 		PreservedAt: time.Now().UTC(),
 	}
 }
 
-func NewPartyServiceImmediately(partyID domain.PartyID, seats int) *PartyServiceState {
+func NewPartyServiceImmediately(partyID domain.PartyID, partySize int, tables []*Table, area domain.SeatingArea) *PartyServiceState {
 	return &PartyServiceState{
 		ID:          partyID,
 		Status:      SeatPreserved,
-		SeatsCount:  seats,
+		SeatsCount:  TotalCovers(tables),
+		PartySize:   partySize,
+		Area:        area,
+		Tables:      IDsOf(tables),
 		PreservedAt: time.Now().UTC(),
 		CheckedInAt: time.Now().UTC(),
 	}
 }
+
+// SeatingArea returns where party is seated, tables for states stored before areas were introduced.
+func (s *PartyServiceState) SeatingArea() domain.SeatingArea {
+	if s.Area == "" {
+		return domain.SeatingAreaTable
+	}
+	return s.Area
+}
@@ -0,0 +1,309 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	d "queue-bite/internal/domain"
+)
+
+type TableID string
+
+func (id TableID) MarshalBinary() ([]byte, error) {
+	return []byte(string(id)), nil
+}
+
+func (id *TableID) UnmarshalBinary(data []byte) error {
+	*id = TableID(data)
+	return nil
+}
+
+// TableIDs are tables held by a party, stored comma separated.
+type TableIDs []TableID
+
+func (ids TableIDs) MarshalBinary() ([]byte, error) {
+	return []byte(ids.String()), nil
+}
+
+func (ids *TableIDs) ScanRedis(s string) error {
+	*ids = TableIDs{}
+	for _, id := range strings.Split(s, ",") {
+		if id != "" {
+			*ids = append(*ids, TableID(id))
+		}
+	}
+	return nil
+}
+
+func (ids TableIDs) String() string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = string(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Table is a piece of seating inventory, a party takes a whole table even if it does not fill it.
+type Table struct {
+	ID        TableID
+	MinCovers int
+	MaxCovers int
+	Area      d.SeatingArea
+	// CombinableWith lists tables which could be pushed together with this one for a larger party.
+	CombinableWith []TableID
+}
+
+// NewSeatPool models area as single seats which could be freely combined, e.g. a counter,
+// so any party fits as long as there are enough free seats.
+func NewSeatPool(area d.SeatingArea, seats int) []*Table {
+	tables := make([]*Table, 0, seats)
+	for i := 1; i <= seats; i++ {
+		tables = append(tables, &Table{ID: TableID(fmt.Sprintf("%s-%02d", area, i)), MinCovers: 1, MaxCovers: 1, Area: area})
+	}
+	for _, table := range tables {
+		for _, other := range tables {
+			if other != table {
+				table.CombinableWith = append(table.CombinableWith, other.ID)
+			}
+		}
+	}
+	return tables
+}
+
+// ParseTables reads table inventory from comma separated ID:MIN-MAX:AREA[:COMBINABLE|COMBINABLE...],
+// e.g. "T1:2-4:table:T2,T2:2-4:table:T1,B1:1-1:counter".
+func ParseTables(spec string) ([]*Table, error) {
+	tables := []*Table{}
+	seen := map[TableID]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, ":")
+		if len(fields) < 3 || len(fields) > 4 {
+			return nil, fmt.Errorf("invalid table %q, expect ID:MIN-MAX:AREA[:COMBINABLE|...]", entry)
+		}
+
+		covers := strings.SplitN(fields[1], "-", 2)
+		if len(covers) != 2 {
+			return nil, fmt.Errorf("invalid covers of table %q, expect MIN-MAX", entry)
+		}
+		min, err := strconv.Atoi(covers[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid min covers of table %q: %w", entry, err)
+		}
+		max, err := strconv.Atoi(covers[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid max covers of table %q: %w", entry, err)
+		}
+		if min < 1 || max < min {
+			return nil, fmt.Errorf("invalid covers of table %q, expect 1 <= MIN <= MAX", entry)
+		}
+
+		area := d.SeatingArea(fields[2])
+		if area != d.SeatingAreaTable && area != d.SeatingAreaCounter {
+			return nil, fmt.Errorf("invalid area of table %q, expect table or counter", entry)
+		}
+
+		table := &Table{ID: TableID(fields[0]), MinCovers: min, MaxCovers: max, Area: area}
+		if seen[table.ID] {
+			return nil, fmt.Errorf("duplicated table %q", table.ID)
+		}
+		seen[table.ID] = true
+		if len(fields) == 4 {
+			for _, id := range strings.Split(fields[3], "|") {
+				if id != "" {
+					table.CombinableWith = append(table.CombinableWith, TableID(id))
+				}
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// AssignTables picks tables for party of size among free tables, nil if party could not be seated.
+// A single table covering the party is preferred, the smallest one wins.
+// Otherwise tables combinable with each other are pushed together, greedily taking the largest neighbour
+// until party is covered, and the combination wasting fewest seats wins.
+func AssignTables(free []*Table, size int) []*Table {
+	if size <= 0 || TotalCovers(free) < size {
+		return nil
+	}
+
+	var best []*Table
+	for _, table := range free {
+		if table.MinCovers <= size && size <= table.MaxCovers &&
+			(best == nil || table.MaxCovers < best[0].MaxCovers) {
+			best = []*Table{table}
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	adjacent := adjacency(free)
+	bestCovers := 0
+	for i := range free {
+		group, covers := growGroup(free, adjacent, i, size)
+		if covers < size || TotalMinCovers(group) > size {
+			continue
+		}
+		if best == nil || covers < bestCovers || (covers == bestCovers && len(group) < len(best)) {
+			best, bestCovers = group, covers
+		}
+	}
+	return best
+}
+
+// adjacency indexes which of free tables could be pushed together, combinable is symmetric.
+func adjacency(free []*Table) [][]int {
+	index := make(map[TableID]int, len(free))
+	for i, table := range free {
+		index[table.ID] = i
+	}
+
+	seen := make([]map[int]bool, len(free))
+	adjacent := make([][]int, len(free))
+	link := func(i, j int) {
+		if seen[i] == nil {
+			seen[i] = map[int]bool{}
+		}
+		if i != j && !seen[i][j] {
+			seen[i][j] = true
+			adjacent[i] = append(adjacent[i], j)
+		}
+	}
+	for i, table := range free {
+		for _, id := range table.CombinableWith {
+			if j, exists := index[id]; exists {
+				link(i, j)
+				link(j, i)
+			}
+		}
+	}
+	return adjacent
+}
+
+func growGroup(free []*Table, adjacent [][]int, start int, size int) ([]*Table, int) {
+	inGroup := map[int]bool{start: true}
+	frontier := map[int]bool{}
+	for _, j := range adjacent[start] {
+		frontier[j] = true
+	}
+	group := []*Table{free[start]}
+	covers := free[start].MaxCovers
+	for covers < size && len(frontier) > 0 {
+		next := -1
+		for j := range frontier {
+			if next == -1 || free[j].MaxCovers > free[next].MaxCovers ||
+				(free[j].MaxCovers == free[next].MaxCovers && j < next) {
+				next = j
+			}
+		}
+		delete(frontier, next)
+		inGroup[next] = true
+		group = append(group, free[next])
+		covers += free[next].MaxCovers
+		for _, j := range adjacent[next] {
+			if !inGroup[j] {
+				frontier[j] = true
+			}
+		}
+	}
+	return group, covers
+}
+
+func TotalCovers(tables []*Table) int {
+	covers := 0
+	for _, table := range tables {
+		covers += table.MaxCovers
+	}
+	return covers
+}
+
+func TotalMinCovers(tables []*Table) int {
+	covers := 0
+	for _, table := range tables {
+		covers += table.MinCovers
+	}
+	return covers
+}
+
+func IDsOf(tables []*Table) TableIDs {
+	ids := make(TableIDs, len(tables))
+	for i, table := range tables {
+		ids[i] = table.ID
+	}
+	return ids
+}
+
+// TablesIn returns tables of area in order of their ID.
+func TablesIn(tables []*Table, area d.SeatingArea) []*Table {
+	inArea := []*Table{}
+	for _, table := range tables {
+		if table.Area == area {
+			inArea = append(inArea, table)
+		}
+	}
+	sort.SliceStable(inArea, func(i, j int) bool { return inArea[i].ID < inArea[j].ID })
+	return inArea
+}
+
+// SeatingVacancy is free tables of an area, taken at Version of the area.
+type SeatingVacancy struct {
+	Area    d.SeatingArea
+	Tables  []*Table
+	Version d.Version
+}
+
+func NewSeatingVacancy(area d.SeatingArea, tables []*Table, held []TableID, version d.Version) *SeatingVacancy {
+	isHeld := make(map[TableID]bool, len(held))
+	for _, id := range held {
+		isHeld[id] = true
+	}
+
+	free := []*Table{}
+	for _, table := range TablesIn(tables, area) {
+		if !isHeld[table.ID] {
+			free = append(free, table)
+		}
+	}
+	return &SeatingVacancy{Area: area, Tables: free, Version: version}
+}
+
+// Seats counts free seats, not every party up to this size could be seated though.
+func (v *SeatingVacancy) Seats() int {
+	return TotalCovers(v.Tables)
+}
+
+// CanSeat answers whether party of size could be seated right now.
+func (v *SeatingVacancy) CanSeat(size int) bool {
+	return AssignTables(v.Tables, size) != nil
+}
+
+// LargestParty is size of the largest party could be seated right now.
+func (v *SeatingVacancy) LargestParty() int {
+	for size := v.Seats(); size > 0; size-- {
+		if v.CanSeat(size) {
+			return size
+		}
+	}
+	return 0
+}
+
+// Hold sets aside tables fitting party of size so they are no longer free,
+// false if no free tables fit and nothing could be held.
+func (v *SeatingVacancy) Hold(size int) bool {
+	held := AssignTables(v.Tables, size)
+	if held == nil {
+		return false
+	}
+
+	v.Tables = withoutTables(v.Tables, held)
+	return true
+}
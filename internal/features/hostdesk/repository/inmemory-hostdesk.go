@@ -23,37 +23,80 @@ type hostdeskStats struct {
 type InMemoryHostDeskRepository struct {
 	logger log.Logger
 	state  map[d.PartyID]*domain.PartyServiceState
-	stats  atomic.Value
+	// stats of each seating area, versioned on their own
+	stats  map[d.SeatingArea]*atomic.Value
+	tables []*domain.Table
+	held   map[domain.TableID]d.PartyID
 }
 
 func NewInMemoryHostDeskRepository(logger log.Logger) HostDeskRepository {
 	repo := &InMemoryHostDeskRepository{
 		logger: logger,
 		state:  make(map[d.PartyID]*domain.PartyServiceState),
+		stats:  make(map[d.SeatingArea]*atomic.Value),
+		tables: []*domain.Table{},
+		held:   make(map[domain.TableID]d.PartyID),
+	}
+	for _, area := range d.SeatingAreas {
+		stats := &atomic.Value{}
+		stats.Store(hostdeskStats{
+			Occupied:  0,
+			Preserved: 0,
+			Version:   0,
+		})
+		repo.stats[area] = stats
 	}
-	repo.stats.Store(hostdeskStats{
-		Occupied:  0,
-		Preserved: 0,
-		Version:   0,
-	})
 	return repo
 }
 
-func (r *InMemoryHostDeskRepository) GetOccupiedSeats(ctx context.Context) (int, error) {
-	state := r.stats.Load().(hostdeskStats)
+func (r *InMemoryHostDeskRepository) GetOccupiedSeats(ctx context.Context, area d.SeatingArea) (int, error) {
+	state := r.areaStats(area).Load().(hostdeskStats)
 	return state.Occupied, nil
 }
 
-func (r *InMemoryHostDeskRepository) GetPreservedSeats(ctx context.Context) (int, error) {
-	state := r.stats.Load().(hostdeskStats)
+func (r *InMemoryHostDeskRepository) GetPreservedSeats(ctx context.Context, area d.SeatingArea) (int, error) {
+	state := r.areaStats(area).Load().(hostdeskStats)
 	return state.Preserved, nil
 }
 
-func (r *InMemoryHostDeskRepository) GetTotalSeatsInUse(ctx context.Context) (int, d.Version, error) {
-	state := r.stats.Load().(hostdeskStats)
+func (r *InMemoryHostDeskRepository) GetTotalSeatsInUse(ctx context.Context, area d.SeatingArea) (int, d.Version, error) {
+	state := r.areaStats(area).Load().(hostdeskStats)
 	return state.Occupied + state.Preserved, d.Version(state.Version), nil
 }
 
+func (r *InMemoryHostDeskRepository) SaveTables(ctx context.Context, tables []*domain.Table) error {
+	r.tables = tables
+	return nil
+}
+
+func (r *InMemoryHostDeskRepository) GetTables(ctx context.Context) ([]*domain.Table, error) {
+	return r.tables, nil
+}
+
+func (r *InMemoryHostDeskRepository) GetHeldTables(ctx context.Context, area d.SeatingArea) ([]domain.TableID, d.Version, error) {
+	held := []domain.TableID{}
+	for _, table := range domain.TablesIn(r.tables, area) {
+		if _, exists := r.held[table.ID]; exists {
+			held = append(held, table.ID)
+		}
+	}
+	stats := r.areaStats(area).Load().(hostdeskStats)
+	return held, stats.Version, nil
+}
+
+func (r *InMemoryHostDeskRepository) releaseTables(state *domain.PartyServiceState) {
+	for _, id := range state.Tables {
+		delete(r.held, id)
+	}
+}
+
+func (r *InMemoryHostDeskRepository) areaStats(area d.SeatingArea) *atomic.Value {
+	if stats, exists := r.stats[area]; exists {
+		return stats
+	}
+	return r.stats[d.SeatingAreaTable]
+}
+
 func (r *InMemoryHostDeskRepository) ReleasePreservedSeats(ctx context.Context, partyID d.PartyID) error {
 	state, exists := r.state[partyID]
 	if !exists {
@@ -63,14 +106,16 @@ func (r *InMemoryHostDeskRepository) ReleasePreservedSeats(ctx context.Context,
 		return domain.ErrPartyNoPreservedSeats
 	}
 
-	stats := r.stats.Load().(hostdeskStats)
+	areaStats := r.areaStats(state.SeatingArea())
+	stats := areaStats.Load().(hostdeskStats)
 	newStats := hostdeskStats{
 		Occupied:  stats.Occupied,
-		Preserved: stats.Preserved + state.SeatsCount,
+		Preserved: stats.Preserved - state.SeatsCount,
 		Version:   stats.Version + 1,
 	}
-	r.stats.Store(newStats)
+	areaStats.Store(newStats)
 
+	r.releaseTables(state)
 	delete(r.state, partyID)
 	r.logger.LogDebug(INMEMORY_HOSTDESK, "release preserved seats", "party id", partyID, "stats", newStats)
 	return nil
@@ -85,13 +130,14 @@ func (r *InMemoryHostDeskRepository) TransferToOccupied(ctx context.Context, par
 		return domain.ErrPartyNoPreservedSeats
 	}
 
-	stats := r.stats.Load().(hostdeskStats)
+	areaStats := r.areaStats(state.SeatingArea())
+	stats := areaStats.Load().(hostdeskStats)
 	nextStats := hostdeskStats{
 		Occupied:  stats.Occupied + state.SeatsCount,
 		Preserved: stats.Preserved - state.SeatsCount,
 		Version:   stats.Version + 1,
 	}
-	r.stats.Store(nextStats)
+	areaStats.Store(nextStats)
 	state.Status = domain.SeatOccupied
 	state.CheckedInAt = time.Now()
 
@@ -107,8 +153,16 @@ func (r *InMemoryHostDeskRepository) GetPartyServiceState(ctx context.Context, p
 	return state, nil
 }
 
+func (r *InMemoryHostDeskRepository) GetPartyServiceStates(ctx context.Context) ([]*domain.PartyServiceState, error) {
+	states := make([]*domain.PartyServiceState, 0, len(r.state))
+	for _, state := range r.state {
+		states = append(states, state)
+	}
+	return states, nil
+}
+
 func (r *InMemoryHostDeskRepository) CreatePartyServiceState(ctx context.Context, state *domain.PartyServiceState) error {
-	stats := r.stats.Load().(hostdeskStats)
+	stats := r.areaStats(state.SeatingArea()).Load().(hostdeskStats)
 	return r.OptimisticCreatePartyServiceState(ctx, state, stats.Version)
 }
 
@@ -117,12 +171,21 @@ func (r *InMemoryHostDeskRepository) OptimisticCreatePartyServiceState(ctx conte
 		return domain.ErrPartyAlreadyExists
 	}
 
-	stats := r.stats.Load().(hostdeskStats)
+	areaStats := r.areaStats(state.SeatingArea())
+	stats := areaStats.Load().(hostdeskStats)
 	if stats.Version != version {
 		return d.ErrVersionMismatch
 	}
+	for _, id := range state.Tables {
+		if _, held := r.held[id]; held {
+			return d.ErrVersionMismatch
+		}
+	}
 
 	r.state[state.ID] = state
+	for _, id := range state.Tables {
+		r.held[id] = state.ID
+	}
 	nextStats := hostdeskStats{
 		Occupied:  stats.Occupied,
 		Preserved: stats.Preserved,
@@ -134,7 +197,7 @@ func (r *InMemoryHostDeskRepository) OptimisticCreatePartyServiceState(ctx conte
 	case domain.SeatPreserved:
 		nextStats.Preserved += state.SeatsCount
 	}
-	r.stats.Store(nextStats)
+	areaStats.Store(nextStats)
 
 	r.logger.LogDebug(INMEMORY_HOSTDESK, "start service for party", "party id", state.ID, "stats", nextStats)
 	return nil
@@ -155,8 +218,9 @@ func (r *InMemoryHostDeskRepository) UpdatePartyServiceState(ctx context.Context
 	}
 
 	if nextState.SeatsCount != 0 && nextState.SeatsCount != oldSeats {
-		stats := r.stats.Load().(hostdeskStats)
-		r.stats.Store(hostdeskStats{
+		areaStats := r.areaStats(currentState.SeatingArea())
+		stats := areaStats.Load().(hostdeskStats)
+		areaStats.Store(hostdeskStats{
 			Occupied:  stats.Occupied - oldSeats + nextState.SeatsCount,
 			Preserved: stats.Preserved,
 			Version:   stats.Version + 1,
@@ -172,12 +236,14 @@ func (r *InMemoryHostDeskRepository) EndPartyServiceState(ctx context.Context, p
 		return domain.ErrPartyNotFound
 	}
 
-	stats := r.stats.Load().(hostdeskStats)
-	r.stats.Store(hostdeskStats{
+	areaStats := r.areaStats(state.SeatingArea())
+	stats := areaStats.Load().(hostdeskStats)
+	areaStats.Store(hostdeskStats{
 		Occupied:  stats.Occupied - state.SeatsCount,
 		Preserved: stats.Preserved,
 		Version:   stats.Version + 1,
 	})
+	r.releaseTables(state)
 	delete(r.state, partyID)
 	return nil
 }
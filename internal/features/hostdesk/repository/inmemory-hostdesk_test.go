@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/hostdesk/domain"
+)
+
+func TestInMemoryReleasePreservedSeats(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryHostDeskRepository(log.NewNoopLogger())
+	tables := []*domain.Table{{ID: "T1", MinCovers: 2, MaxCovers: 4, Area: d.SeatingAreaTable}}
+	require.NoError(t, repo.CreatePartyServiceState(ctx, domain.NewPartyServiceFromPreserve("party-1", 3, tables, d.SeatingAreaTable)))
+
+	preserved, err := repo.GetPreservedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 4, preserved)
+
+	require.NoError(t, repo.ReleasePreservedSeats(ctx, "party-1"))
+
+	preserved, err = repo.GetPreservedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 0, preserved, "released seats are given back, not preserved twice")
+
+	state, err := repo.GetPartyServiceState(ctx, "party-1")
+	require.NoError(t, err)
+	assert.Nil(t, state, "party holds no seats once released")
+
+	held, _, err := repo.GetHeldTables(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Empty(t, held)
+	assert.Equal(t, domain.ErrPartyNotFound, repo.ReleasePreservedSeats(ctx, "party-1"))
+}
+
+func TestInMemoryTransferToOccupied(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryHostDeskRepository(log.NewNoopLogger())
+	tables := []*domain.Table{{ID: "T1", MinCovers: 2, MaxCovers: 4, Area: d.SeatingAreaTable}}
+	require.NoError(t, repo.CreatePartyServiceState(ctx, domain.NewPartyServiceFromPreserve("party-1", 3, tables, d.SeatingAreaTable)))
+
+	require.NoError(t, repo.TransferToOccupied(ctx, "party-1"))
+	assert.Equal(t, domain.ErrPartyNoPreservedSeats, repo.TransferToOccupied(ctx, "party-1"))
+	assert.Equal(t, domain.ErrPartyNoPreservedSeats, repo.ReleasePreservedSeats(ctx, "party-1"))
+
+	occupied, err := repo.GetOccupiedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 4, occupied, "seats are moved once")
+	preserved, err := repo.GetPreservedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 0, preserved)
+
+	assert.Equal(t, domain.ErrPartyNotFound, repo.TransferToOccupied(ctx, "party-2"))
+}
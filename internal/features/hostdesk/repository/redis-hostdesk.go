@@ -2,8 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"strconv"
+	"sort"
 	"time"
 
 	"github.com/jinzhu/copier"
@@ -17,16 +18,34 @@ import (
 var REDIS_HOSTDESK = "hostdesk/redis"
 var SKIP_VERSION_CHECK = -1
 
-type hostdeskRedisKeys struct{}
+// hostdeskRedisKeys namespaces every host desk key by restaurant.
+type hostdeskRedisKeys struct {
+	restaurantID d.RestaurantID
+}
 
-// hd:state:<party_id>
+// hd:<restaurant>:state:<party_id>
 func (k *hostdeskRedisKeys) getPartyStateKey(partyID d.PartyID) string {
-	return fmt.Sprintf("hd:state:%s", partyID)
+	return fmt.Sprintf("hd:%s:state:%s", k.restaurantID, partyID)
+}
+
+// hd:<restaurant>:state:*
+func (k *hostdeskRedisKeys) getPartyStatePattern() string {
+	return fmt.Sprintf("hd:%s:state:*", k.restaurantID)
 }
 
-// hd:stats
-func (k *hostdeskRedisKeys) getStatsKey() string {
-	return "hd:stats"
+// hd:<restaurant>:stats:<area>
+func (k *hostdeskRedisKeys) getStatsKey(area d.SeatingArea) string {
+	return fmt.Sprintf("hd:%s:stats:%s", k.restaurantID, area)
+}
+
+// hd:<restaurant>:tables
+func (k *hostdeskRedisKeys) getTablesKey() string {
+	return fmt.Sprintf("hd:%s:tables", k.restaurantID)
+}
+
+// hd:<restaurant>:held:<area>
+func (k *hostdeskRedisKeys) getHeldTablesKey(area d.SeatingArea) string {
+	return fmt.Sprintf("hd:%s:held:%s", k.restaurantID, area)
 }
 
 type hostdeskStatsHash struct {
@@ -41,40 +60,42 @@ type RedisHostDeskRepository struct {
 	keys   *hostdeskRedisKeys
 }
 
-func NewRedisHostDeskRepository(logger log.Logger, client *redis.Client) HostDeskRepository {
+func NewRedisHostDeskRepository(logger log.Logger, client *redis.Client, restaurantID d.RestaurantID) HostDeskRepository {
 	repo := &RedisHostDeskRepository{
 		logger: logger,
 		client: client,
-		keys:   &hostdeskRedisKeys{},
+		keys:   &hostdeskRedisKeys{restaurantID: restaurantID},
 	}
 
 	ctx := context.Background()
-	exists, _ := client.HExists(ctx, repo.keys.getStatsKey(), "Version").Result()
-	if !exists {
-		client.HMSet(ctx, repo.keys.getStatsKey(), &hostdeskStatsHash{Occupied: 0, Preserved: 0, Version: 0})
+	for _, area := range d.SeatingAreas {
+		exists, _ := client.HExists(ctx, repo.keys.getStatsKey(area), "Version").Result()
+		if !exists {
+			client.HMSet(ctx, repo.keys.getStatsKey(area), &hostdeskStatsHash{Occupied: 0, Preserved: 0, Version: 0})
+		}
 	}
 
 	return repo
 }
 
-func (r *RedisHostDeskRepository) GetOccupiedSeats(ctx context.Context) (int, error) {
-	occupied, err := r.client.HGet(ctx, r.keys.getStatsKey(), "Occupied").Int()
+func (r *RedisHostDeskRepository) GetOccupiedSeats(ctx context.Context, area d.SeatingArea) (int, error) {
+	occupied, err := r.client.HGet(ctx, r.keys.getStatsKey(area), "Occupied").Int()
 	if err == redis.Nil {
 		return 0, nil
 	}
 	return occupied, err
 }
 
-func (r *RedisHostDeskRepository) GetPreservedSeats(ctx context.Context) (int, error) {
-	preserved, err := r.client.HGet(ctx, r.keys.getStatsKey(), "Preserved").Int()
+func (r *RedisHostDeskRepository) GetPreservedSeats(ctx context.Context, area d.SeatingArea) (int, error) {
+	preserved, err := r.client.HGet(ctx, r.keys.getStatsKey(area), "Preserved").Int()
 	if err == redis.Nil {
 		return 0, nil
 	}
 	return preserved, err
 }
 
-func (r *RedisHostDeskRepository) GetTotalSeatsInUse(ctx context.Context) (int, d.Version, error) {
-	res := r.client.HGetAll(ctx, r.keys.getStatsKey())
+func (r *RedisHostDeskRepository) GetTotalSeatsInUse(ctx context.Context, area d.SeatingArea) (int, d.Version, error) {
+	res := r.client.HGetAll(ctx, r.keys.getStatsKey(area))
 	if res.Err() != nil {
 		return 0, 0, res.Err()
 	}
@@ -86,80 +107,143 @@ func (r *RedisHostDeskRepository) GetTotalSeatsInUse(ctx context.Context) (int,
 	return stats.Occupied + stats.Preserved, d.Version(stats.Version), nil
 }
 
+func (r *RedisHostDeskRepository) SaveTables(ctx context.Context, tables []*domain.Table) error {
+	fields := make([]interface{}, 0, len(tables)*2)
+	for _, table := range tables {
+		data, err := json.Marshal(table)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, string(table.ID), data)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, r.keys.getTablesKey())
+	if len(fields) > 0 {
+		pipe.HSet(ctx, r.keys.getTablesKey(), fields...)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisHostDeskRepository) GetTables(ctx context.Context) ([]*domain.Table, error) {
+	res, err := r.client.HGetAll(ctx, r.keys.getTablesKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]*domain.Table, 0, len(res))
+	for _, data := range res {
+		table := &domain.Table{}
+		if err := json.Unmarshal([]byte(data), table); err != nil {
+			r.logger.LogErr(REDIS_HOSTDESK, err, "could not parse table", "table", data)
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].ID < tables[j].ID })
+	return tables, nil
+}
+
+func (r *RedisHostDeskRepository) GetHeldTables(ctx context.Context, area d.SeatingArea) ([]domain.TableID, d.Version, error) {
+	pipe := r.client.TxPipeline()
+	members := pipe.SMembers(ctx, r.keys.getHeldTablesKey(area))
+	version := pipe.HGet(ctx, r.keys.getStatsKey(area), "Version")
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+
+	held := make([]domain.TableID, 0, len(members.Val()))
+	for _, id := range members.Val() {
+		held = append(held, domain.TableID(id))
+	}
+	v, err := version.Int()
+	if err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+	return held, d.Version(v), nil
+}
+
+// releasePreservedSeatsScript returns 0 when the party holds no preserved seats, so that state read and counters change atomically.
 const releasePreservedSeatsScript = `
     local stats_key = KEYS[1]
-    local seat_cnt = ARGV[1]
-    redis.call('HINCRBY', stats_key, "Preserved", seat_cnt)
+    local party_state_key = KEYS[2]
+    local held_key = KEYS[3]
+    local preserved_status = ARGV[1]
+    local state = redis.call('HMGET', party_state_key, "Status", "SeatsCount", "Tables")
+    if state[1] ~= preserved_status then
+        return 0
+    end
+    local seat_cnt = tonumber(state[2])
+    redis.call('HINCRBY', stats_key, "Preserved", -seat_cnt)
     redis.call('HINCRBY', stats_key, "Version", 1)
-    return "ok"
+    if state[3] then
+        for table_id in string.gmatch(state[3], '[^,]+') do
+            redis.call('SREM', held_key, table_id)
+        end
+    end
+    redis.call('DEL', party_state_key)
+    return seat_cnt
 `
 
 func (r *RedisHostDeskRepository) ReleasePreservedSeats(ctx context.Context, partyID d.PartyID) error {
 	partyStateKey := r.keys.getPartyStateKey(partyID)
-	if exists := r.client.Exists(ctx, partyStateKey).Val(); exists == 0 {
-		return domain.ErrPartyNotFound
-	}
-
-	results, err := r.client.HMGet(ctx, partyStateKey, "Status", "SeatsCount").Result()
-	if err != nil {
+	area, err := r.client.HGet(ctx, partyStateKey, "Area").Result()
+	if err != nil && err != redis.Nil {
 		return err
 	}
 
-	if results[0].(string) != string(domain.SeatPreserved) {
-		return domain.ErrPartyNoPreservedSeats
+	releaseKeys := []string{
+		r.keys.getStatsKey(seatingAreaOf(area)),
+		partyStateKey,
+		r.keys.getHeldTablesKey(seatingAreaOf(area)),
 	}
-
-	seats := results[1].(int64)
-	script := redis.NewScript(releasePreservedSeatsScript)
-	_, err = script.Run(ctx, r.client, []string{r.keys.getStatsKey()}, int(seats)).Result()
+	seats, err := redis.NewScript(releasePreservedSeatsScript).Run(ctx, r.client, releaseKeys, string(domain.SeatPreserved)).Int()
 	if err != nil {
 		return err
 	}
+	if seats == 0 {
+		return r.noPreservedSeatsErr(ctx, partyStateKey)
+	}
 
 	r.logger.LogDebug(REDIS_HOSTDESK, "release preserved seats", "party id", partyID, "seat count", seats)
 	return nil
 }
 
+// transferToOccupiedScript returns 0 when the party holds no preserved seats, so that state read and counters change atomically.
 const transferToOccupiedScript = `
     local stats_key = KEYS[1]
     local party_state_key = KEYS[2]
-    local seat_cnt = ARGV[1]
+    local preserved_status = ARGV[1]
     local party_next_status = ARGV[2]
     local checked_in_at = ARGV[3]
+    local state = redis.call('HMGET', party_state_key, "Status", "SeatsCount")
+    if state[1] ~= preserved_status then
+        return 0
+    end
+    local seat_cnt = tonumber(state[2])
     redis.call('HINCRBY', stats_key, 'Occupied', seat_cnt)
     redis.call('HINCRBY', stats_key, 'Preserved', -seat_cnt)
     redis.call('HINCRBY', stats_key, 'Version', 1)
     redis.call('HMSET', party_state_key, "Status", party_next_status, "CheckedInAt", checked_in_at)
-    return nil
+    return seat_cnt
 `
 
 func (r *RedisHostDeskRepository) TransferToOccupied(ctx context.Context, partyID d.PartyID) error {
 	partyStateKey := r.keys.getPartyStateKey(partyID)
-	if exists := r.client.Exists(ctx, partyStateKey).Val(); exists == 0 {
-		return domain.ErrPartyNotFound
+	area, err := r.client.HGet(ctx, partyStateKey, "Area").Result()
+	if err != nil && err != redis.Nil {
+		return err
 	}
 
-	results, err := r.client.HMGet(ctx, partyStateKey, "Status", "SeatsCount").Result()
+	transferKeys := []string{r.keys.getStatsKey(seatingAreaOf(area)), partyStateKey}
+	transferVals := []interface{}{string(domain.SeatPreserved), string(domain.SeatOccupied), time.Now().UTC()}
+	seats, err := redis.NewScript(transferToOccupiedScript).Run(ctx, r.client, transferKeys, transferVals...).Int()
 	if err != nil {
 		return err
 	}
-
-	if results[0].(string) != string(domain.SeatPreserved) {
-		return domain.ErrPartyNoPreservedSeats
-	}
-
-	seats, ok := results[1].(int64)
-	if !ok {
-		seats, _ = strconv.ParseInt(results[1].(string), 10, 64)
-	}
-	script := redis.NewScript(transferToOccupiedScript)
-	transferKeys := []string{r.keys.getStatsKey(), r.keys.getPartyStateKey(partyID)}
-	checkedInAt := time.Now().UTC()
-	transferVals := []interface{}{seats, domain.SeatOccupied, checkedInAt}
-	_, err = script.Run(ctx, r.client, transferKeys, transferVals...).Result()
-
-	if err != nil && err != redis.Nil {
-		return err
+	if seats == 0 {
+		return r.noPreservedSeatsErr(ctx, partyStateKey)
 	}
 
 	r.logger.LogDebug(REDIS_HOSTDESK, "transfer to occupied",
@@ -170,6 +254,18 @@ func (r *RedisHostDeskRepository) TransferToOccupied(ctx context.Context, partyI
 	return nil
 }
 
+// noPreservedSeatsErr tells a party that is gone apart from one no longer holding preserved seats.
+func (r *RedisHostDeskRepository) noPreservedSeatsErr(ctx context.Context, partyStateKey string) error {
+	exists, err := r.client.Exists(ctx, partyStateKey).Result()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return domain.ErrPartyNotFound
+	}
+	return domain.ErrPartyNoPreservedSeats
+}
+
 func (r *RedisHostDeskRepository) GetPartyServiceState(ctx context.Context, partyID d.PartyID) (*domain.PartyServiceState, error) {
 	res := r.client.HGetAll(ctx, r.keys.getPartyStateKey(partyID))
 	r.logger.LogDebug(REDIS_HOSTDESK, "get party service state", "party", res.Val(), "key", r.keys.getPartyStateKey(partyID))
@@ -190,6 +286,32 @@ func (r *RedisHostDeskRepository) GetPartyServiceState(ctx context.Context, part
 	return state, nil
 }
 
+func (r *RedisHostDeskRepository) GetPartyServiceStates(ctx context.Context) ([]*domain.PartyServiceState, error) {
+	states := []*domain.PartyServiceState{}
+	iter := r.client.Scan(ctx, 0, r.keys.getPartyStatePattern(), 0).Iterator()
+	for iter.Next(ctx) {
+		res := r.client.HGetAll(ctx, iter.Val())
+		if res.Err() != nil {
+			return nil, res.Err()
+		}
+		if len(res.Val()) == 0 {
+			continue
+		}
+
+		state := &domain.PartyServiceState{}
+		if err := res.Scan(state); err != nil {
+			r.logger.LogErr(REDIS_HOSTDESK, err, "could not parse party service state", "key", iter.Val())
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return states, nil
+}
+
 func (r *RedisHostDeskRepository) CreatePartyServiceState(ctx context.Context, state *domain.PartyServiceState) error {
 	return r.OptimisticCreatePartyServiceState(ctx, state, d.Version(SKIP_VERSION_CHECK))
 }
@@ -197,12 +319,16 @@ func (r *RedisHostDeskRepository) CreatePartyServiceState(ctx context.Context, s
 const createPartyScript = `
     local stats_key = KEYS[1]
     local party_state_key = KEYS[2]
+    local held_key = KEYS[3]
     local version = ARGV[1]             -- -1 -> skip version check
     local seat_in_used_type = ARGV[2]
     local party_id = ARGV[3]            -- ID           domain.PartyID
     local seat_status = ARGV[4]         -- Status       SeatStatus
     local seat_cnt = ARGV[5]            -- SeatsCount   int
     local time = ARGV[6]                -- PreservedAt/CheckedInAt  time.Time
+    local area = ARGV[7]                -- Area         SeatingArea
+    local tables = ARGV[8]              -- Tables       TableIDs
+    local party_size = ARGV[9]          -- PartySize    int
 
     if tonumber(version) ~= -1 then
         local current_version = redis.call("HGET", stats_key, "Version") or 0
@@ -211,6 +337,16 @@ const createPartyScript = `
         end
     end
 
+    -- tables held meanwhile mean caller assigned them from a stale vacancy
+    for table_id in string.gmatch(tables, '[^,]+') do
+        if redis.call('SISMEMBER', held_key, table_id) == 1 then
+            return redis.error_reply("ErrVersionMismatch")
+        end
+    end
+    for table_id in string.gmatch(tables, '[^,]+') do
+        redis.call('SADD', held_key, table_id)
+    end
+
     redis.call('HINCRBY', stats_key, seat_in_used_type, seat_cnt)
     redis.call('HINCRBY', stats_key, 'Version', 1)
     
@@ -218,7 +354,7 @@ const createPartyScript = `
     if seat_in_used_type == "Preserved" then
         time_field = "PreservedAt"
     end
-    redis.call('HMSET', party_state_key, "ID", party_id, "Status", seat_status, "SeatsCount", seat_cnt, time_field, time)
+    redis.call('HMSET', party_state_key, "ID", party_id, "Status", seat_status, "SeatsCount", seat_cnt, time_field, time, "Area", area, "Tables", tables, "PartySize", party_size)
     return nil
 `
 
@@ -239,7 +375,11 @@ func (r *RedisHostDeskRepository) OptimisticCreatePartyServiceState(ctx context.
 	}
 
 	script := redis.NewScript(createPartyScript)
-	createKeys := []string{r.keys.getStatsKey(), r.keys.getPartyStateKey(state.ID)}
+	createKeys := []string{
+		r.keys.getStatsKey(state.SeatingArea()),
+		r.keys.getPartyStateKey(state.ID),
+		r.keys.getHeldTablesKey(state.SeatingArea()),
+	}
 	createVals := []interface{}{
 		int(version),
 		seatInUsedType,
@@ -247,6 +387,9 @@ func (r *RedisHostDeskRepository) OptimisticCreatePartyServiceState(ctx context.
 		string(state.Status),
 		state.SeatsCount,
 		time.Now().UTC(),
+		string(state.SeatingArea()),
+		state.Tables.String(),
+		state.PartySize,
 	}
 	_, err := script.Run(ctx, r.client, createKeys, createVals...).Result()
 	if err != nil && err != redis.Nil {
@@ -260,6 +403,7 @@ func (r *RedisHostDeskRepository) OptimisticCreatePartyServiceState(ctx context.
 		"party id", state.ID,
 		"status", state.Status,
 		"seats", state.SeatsCount,
+		"tables", state.Tables,
 	)
 	return nil
 }
@@ -283,17 +427,32 @@ func (r *RedisHostDeskRepository) UpdatePartyServiceState(ctx context.Context, p
 const endOfPartyServiceScript = `
     local stats_key = KEYS[1]
     local party_state_key = KEYS[2]
+    local held_key = KEYS[3]
     local seats = redis.call('HGET', party_state_key, "SeatsCount")
     if not seats then
         return 0
     end
     redis.call('HINCRBY', stats_key, "Occupied", -tonumber(seats))
     redis.call('HINCRBY', stats_key, "Version", 1)
+    local tables = redis.call('HGET', party_state_key, "Tables")
+    if tables then
+        for table_id in string.gmatch(tables, '[^,]+') do
+            redis.call('SREM', held_key, table_id)
+        end
+    end
     return redis.call('DEL', party_state_key)
 `
 
 func (r *RedisHostDeskRepository) EndPartyServiceState(ctx context.Context, partyID d.PartyID) error {
-	endOfServiceKeys := []string{r.keys.getStatsKey(), r.keys.getPartyStateKey(partyID)}
+	area, err := r.client.HGet(ctx, r.keys.getPartyStateKey(partyID), "Area").Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	endOfServiceKeys := []string{
+		r.keys.getStatsKey(seatingAreaOf(area)),
+		r.keys.getPartyStateKey(partyID),
+		r.keys.getHeldTablesKey(seatingAreaOf(area)),
+	}
 	success, err := r.client.Eval(ctx, endOfPartyServiceScript, endOfServiceKeys).Result()
 
 	if err != nil {
@@ -307,3 +466,11 @@ func (r *RedisHostDeskRepository) EndPartyServiceState(ctx context.Context, part
 
 	return nil
 }
+
+// seatingAreaOf reads the area field of party state, states stored before areas were introduced sit at tables.
+func seatingAreaOf(val interface{}) d.SeatingArea {
+	if area, ok := val.(string); ok && area != "" {
+		return d.SeatingArea(area)
+	}
+	return d.SeatingAreaTable
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/hostdesk/domain"
+)
+
+func TestRedisReleasePreservedSeats(t *testing.T) {
+	endpoint, cleanup := setupRedisContainer(t)
+	defer cleanup()
+
+	client := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer client.Close()
+
+	ctx := context.Background()
+	repo := NewRedisHostDeskRepository(log.NewNoopLogger(), client, d.DefaultRestaurantID)
+	tables := []*domain.Table{{ID: "T1", MinCovers: 2, MaxCovers: 4, Area: d.SeatingAreaTable}}
+	require.NoError(t, repo.CreatePartyServiceState(ctx, domain.NewPartyServiceFromPreserve("party-1", 3, tables, d.SeatingAreaTable)))
+
+	preserved, err := repo.GetPreservedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 4, preserved)
+
+	require.NoError(t, repo.ReleasePreservedSeats(ctx, "party-1"))
+
+	preserved, err = repo.GetPreservedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 0, preserved, "released seats are given back, not preserved twice")
+
+	state, err := repo.GetPartyServiceState(ctx, "party-1")
+	require.NoError(t, err)
+	assert.Nil(t, state, "party holds no seats once released")
+
+	held, _, err := repo.GetHeldTables(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Empty(t, held)
+	assert.Equal(t, domain.ErrPartyNotFound, repo.ReleasePreservedSeats(ctx, "party-1"))
+}
+
+func TestRedisTransferToOccupied(t *testing.T) {
+	endpoint, cleanup := setupRedisContainer(t)
+	defer cleanup()
+
+	client := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer client.Close()
+
+	ctx := context.Background()
+	repo := NewRedisHostDeskRepository(log.NewNoopLogger(), client, d.DefaultRestaurantID)
+	tables := []*domain.Table{{ID: "T1", MinCovers: 2, MaxCovers: 4, Area: d.SeatingAreaTable}}
+	require.NoError(t, repo.CreatePartyServiceState(ctx, domain.NewPartyServiceFromPreserve("party-1", 3, tables, d.SeatingAreaTable)))
+
+	require.NoError(t, repo.TransferToOccupied(ctx, "party-1"))
+	assert.Equal(t, domain.ErrPartyNoPreservedSeats, repo.TransferToOccupied(ctx, "party-1"))
+	assert.Equal(t, domain.ErrPartyNoPreservedSeats, repo.ReleasePreservedSeats(ctx, "party-1"))
+
+	occupied, err := repo.GetOccupiedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 4, occupied, "seats are moved once")
+	preserved, err := repo.GetPreservedSeats(ctx, d.SeatingAreaTable)
+	require.NoError(t, err)
+	assert.Equal(t, 0, preserved)
+
+	assert.Equal(t, domain.ErrPartyNotFound, repo.TransferToOccupied(ctx, "party-2"))
+}
+
+func setupRedisContainer(t *testing.T) (string, func()) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7-alpine",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("Ready to accept connections"),
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+
+	endpoint, err := container.Endpoint(ctx, "")
+	require.NoError(t, err)
+
+	cleanup := func() {
+		require.NoError(t, container.Terminate(ctx))
+	}
+	return endpoint, cleanup
+}
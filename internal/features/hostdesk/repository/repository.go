@@ -11,14 +11,25 @@ import (
 // It tracks occupied seats and party service states to help the host manage
 // restaurant capacity efficiently.
 type HostDeskRepository interface {
-	GetOccupiedSeats(ctx context.Context) (int, error)
+	GetOccupiedSeats(ctx context.Context, area d.SeatingArea) (int, error)
 
-	GetPreservedSeats(ctx context.Context) (int, error)
+	GetPreservedSeats(ctx context.Context, area d.SeatingArea) (int, error)
 
-	// GetTotalSeatsInUse returns combined occupied and preserved seats with version.
-	// Version enables optimistic locking for capacity changes.
-	GetTotalSeatsInUse(ctx context.Context) (int, d.Version, error)
+	// GetTotalSeatsInUse returns combined occupied and preserved seats of area with its version.
+	// Version enables optimistic locking for capacity changes, each area is versioned on its own.
+	GetTotalSeatsInUse(ctx context.Context, area d.SeatingArea) (int, d.Version, error)
 
+	// SaveTables replaces table inventory of the restaurant.
+	SaveTables(ctx context.Context, tables []*domain.Table) error
+
+	GetTables(ctx context.Context) ([]*domain.Table, error)
+
+	// GetHeldTables returns tables of area held by parties, either preserved or occupied, with version of the area.
+	GetHeldTables(ctx context.Context, area d.SeatingArea) ([]domain.TableID, d.Version, error)
+
+	// ReleasePreservedSeats gives back seats preserved for party, decrementing preserved seats of its area
+	// and freeing its tables, then drops its service state.
+	// Returns ErrPartyNotFound if party holds no seats, ErrPartyNoPreservedSeats if its seats are occupied.
 	ReleasePreservedSeats(ctx context.Context, partyID d.PartyID) error
 
 	// TransferToOccupied moves party from preserved to occupied state.
@@ -27,11 +38,16 @@ type HostDeskRepository interface {
 
 	GetPartyServiceState(ctx context.Context, partyID d.PartyID) (*domain.PartyServiceState, error)
 
+	// GetPartyServiceStates lists service states of every party holding seats,
+	// either preserved or occupied.
+	GetPartyServiceStates(ctx context.Context) ([]*domain.PartyServiceState, error)
+
 	// CreatePartyServiceState initializes new service state for party.
 	CreatePartyServiceState(ctx context.Context, state *domain.PartyServiceState) error
 
 	// OptimisticCreatePartyServiceState creates service state if version matches.
 	// Used to handle concurrent seating operations safely.
+	// Returns ErrVersionMismatch if any table of the state is already held, as tables were picked from a stale vacancy.
 	OptimisticCreatePartyServiceState(ctx context.Context, state *domain.PartyServiceState, version d.Version) error
 
 	UpdatePartyServiceState(ctx context.Context, partyID d.PartyID, state *domain.PartyServiceState) error
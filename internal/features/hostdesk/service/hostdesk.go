@@ -4,23 +4,50 @@ import (
 	"context"
 
 	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/hostdesk/domain"
 	w "queue-bite/internal/features/waitlist/domain"
 )
 
 type HostDesk interface {
-	GetTotalCapacity(ctx context.Context) (int, error)
+	// GetTotalCapacity returns seats of every table in area.
+	GetTotalCapacity(ctx context.Context, area d.SeatingArea) (int, error)
 
-	// GetCurrentCapacity returns available seats and current version.
+	// GetMaxPartySize returns size of the largest party tables of area could ever seat together.
+	GetMaxPartySize(ctx context.Context, area d.SeatingArea) (int, error)
+
+	// GetVacancy returns free tables of area with its current version,
+	// which answers whether a party of given size could be seated right now.
+	GetVacancy(ctx context.Context, area d.SeatingArea) (*domain.SeatingVacancy, error)
+
+	// GetCurrentCapacity returns available seats of area, not counting tables held for parties yet to arrive,
+	// and its current version.
 	// Version used for optimistic locking in seat operations.
-	GetCurrentCapacity(ctx context.Context) (int, d.Version, error)
+	GetCurrentCapacity(ctx context.Context, area d.SeatingArea) (int, d.Version, error)
+
+	// ForecastSeating simulates tables of area freeing up from now on as parties holding them finish their service,
+	// nil if host desk could not tell when services end.
+	ForecastSeating(ctx context.Context, area d.SeatingArea) (*domain.SeatingForecast, error)
+
+	GetOccupiedSeats(ctx context.Context, area d.SeatingArea) (int, error)
 
-	NotifyPartyReady(ctx context.Context, party *w.QueuedParty) error
+	GetPreservedSeats(ctx context.Context, area d.SeatingArea) (int, error)
 
-	// PreserveSeats attempts to reserve seats for party.
-	// Uses version for optimistic locking to handle concurrent requests.
-	// Returns (true, nil) if seats successfully preserved.
-	PreserveSeats(ctx context.Context, partyID d.PartyID, seats int, version d.Version) (bool, error)
+	// GetPartyServiceStates lists parties currently holding preserved or occupied seats.
+	GetPartyServiceStates(ctx context.Context) ([]*domain.PartyServiceState, error)
 
+	// GetPartyServiceState returns seats state of party, or nil if party holds no seats.
+	GetPartyServiceState(ctx context.Context, partyID d.PartyID) (*domain.PartyServiceState, error)
+
+	// NotifyPartyReady preserves seats in area for party and lets it know its seats are ready.
+	NotifyPartyReady(ctx context.Context, party *w.QueuedParty, area d.SeatingArea) error
+
+	// PreserveSeats attempts to reserve tables in area fitting party of seats.
+	// Uses version of the area for optimistic locking to handle concurrent requests.
+	// Returns (true, nil) if tables successfully preserved, ErrInsufficientCapacity if no free tables fit.
+	PreserveSeats(ctx context.Context, partyID d.PartyID, seats int, area d.SeatingArea, version d.Version) (bool, error)
+
+	// ReleasePreservedSeats frees tables preserved for party so they can be offered to others.
+	// Returns (false, nil) if party holds no preserved seats, e.g. they were released already or party checked in.
 	ReleasePreservedSeats(ctx context.Context, partyID d.PartyID) (bool, error)
 
 	// ServeImmediately seats party without going through queue.
@@ -32,8 +59,12 @@ type HostDesk interface {
 	CheckIn(ctx context.Context, party *w.QueuedParty) error
 
 	// ServiceComplete ends party's service and frees seats.
-	// Triggers capacity updates and cleanup.
+	// Cancels pending service timer, triggers capacity updates and cleanup.
+	// Returns ErrPartyNotSeated if party only holds preserved seats.
 	ServiceComplete(ctx context.Context, party *w.QueuedParty) error
 
 	HasPartyOccupiedSeat(ctx context.Context, partyID d.PartyID) bool
+
+	// WatchServiceTimer completes service of parties whose service timer is up, until ctx is done.
+	WatchServiceTimer(ctx context.Context) error
 }
@@ -12,7 +12,8 @@ package service
 import (
         context "context"
         domain "queue-bite/internal/domain"
-        domain0 "queue-bite/internal/features/waitlist/domain"
+        domain0 "queue-bite/internal/features/hostdesk/domain"
+        domain1 "queue-bite/internal/features/waitlist/domain"
         reflect "reflect"
 
         gomock "go.uber.org/mock/gomock"
@@ -43,7 +44,7 @@ func (m *MockHostDesk) EXPECT() *MockHostDeskMockRecorder {
 }
 
 // CheckIn mocks base method.
-func (m *MockHostDesk) CheckIn(ctx context.Context, party *domain0.QueuedParty) error {
+func (m *MockHostDesk) CheckIn(ctx context.Context, party *domain1.QueuedParty) error {
         m.ctrl.T.Helper()
         ret := m.ctrl.Call(m, "CheckIn", ctx, party)
         ret0, _ := ret[0].(error)
@@ -56,10 +57,25 @@ func (mr *MockHostDeskMockRecorder) CheckIn(ctx, party any) *gomock.Call {
         return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckIn", reflect.TypeOf((*MockHostDesk)(nil).CheckIn), ctx, party)
 }
 
+// ForecastSeating mocks base method.
+func (m *MockHostDesk) ForecastSeating(ctx context.Context, area domain.SeatingArea) (*domain0.SeatingForecast, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "ForecastSeating", ctx, area)
+        ret0, _ := ret[0].(*domain0.SeatingForecast)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// ForecastSeating indicates an expected call of ForecastSeating.
+func (mr *MockHostDeskMockRecorder) ForecastSeating(ctx, area any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForecastSeating", reflect.TypeOf((*MockHostDesk)(nil).ForecastSeating), ctx, area)
+}
+
 // GetCurrentCapacity mocks base method.
-func (m *MockHostDesk) GetCurrentCapacity(ctx context.Context) (int, domain.Version, error) {
+func (m *MockHostDesk) GetCurrentCapacity(ctx context.Context, area domain.SeatingArea) (int, domain.Version, error) {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "GetCurrentCapacity", ctx)
+        ret := m.ctrl.Call(m, "GetCurrentCapacity", ctx, area)
         ret0, _ := ret[0].(int)
         ret1, _ := ret[1].(domain.Version)
         ret2, _ := ret[2].(error)
@@ -67,24 +83,114 @@ func (m *MockHostDesk) GetCurrentCapacity(ctx context.Context) (int, domain.Vers
 }
 
 // GetCurrentCapacity indicates an expected call of GetCurrentCapacity.
-func (mr *MockHostDeskMockRecorder) GetCurrentCapacity(ctx any) *gomock.Call {
+func (mr *MockHostDeskMockRecorder) GetCurrentCapacity(ctx, area any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentCapacity", reflect.TypeOf((*MockHostDesk)(nil).GetCurrentCapacity), ctx, area)
+}
+
+// GetMaxPartySize mocks base method.
+func (m *MockHostDesk) GetMaxPartySize(ctx context.Context, area domain.SeatingArea) (int, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "GetMaxPartySize", ctx, area)
+        ret0, _ := ret[0].(int)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// GetMaxPartySize indicates an expected call of GetMaxPartySize.
+func (mr *MockHostDeskMockRecorder) GetMaxPartySize(ctx, area any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMaxPartySize", reflect.TypeOf((*MockHostDesk)(nil).GetMaxPartySize), ctx, area)
+}
+
+// GetOccupiedSeats mocks base method.
+func (m *MockHostDesk) GetOccupiedSeats(ctx context.Context, area domain.SeatingArea) (int, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "GetOccupiedSeats", ctx, area)
+        ret0, _ := ret[0].(int)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// GetOccupiedSeats indicates an expected call of GetOccupiedSeats.
+func (mr *MockHostDeskMockRecorder) GetOccupiedSeats(ctx, area any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOccupiedSeats", reflect.TypeOf((*MockHostDesk)(nil).GetOccupiedSeats), ctx, area)
+}
+
+// GetPartyServiceState mocks base method.
+func (m *MockHostDesk) GetPartyServiceState(ctx context.Context, partyID domain.PartyID) (*domain0.PartyServiceState, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "GetPartyServiceState", ctx, partyID)
+        ret0, _ := ret[0].(*domain0.PartyServiceState)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// GetPartyServiceState indicates an expected call of GetPartyServiceState.
+func (mr *MockHostDeskMockRecorder) GetPartyServiceState(ctx, partyID any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPartyServiceState", reflect.TypeOf((*MockHostDesk)(nil).GetPartyServiceState), ctx, partyID)
+}
+
+// GetPartyServiceStates mocks base method.
+func (m *MockHostDesk) GetPartyServiceStates(ctx context.Context) ([]*domain0.PartyServiceState, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "GetPartyServiceStates", ctx)
+        ret0, _ := ret[0].([]*domain0.PartyServiceState)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// GetPartyServiceStates indicates an expected call of GetPartyServiceStates.
+func (mr *MockHostDeskMockRecorder) GetPartyServiceStates(ctx any) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentCapacity", reflect.TypeOf((*MockHostDesk)(nil).GetCurrentCapacity), ctx)
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPartyServiceStates", reflect.TypeOf((*MockHostDesk)(nil).GetPartyServiceStates), ctx)
+}
+
+// GetPreservedSeats mocks base method.
+func (m *MockHostDesk) GetPreservedSeats(ctx context.Context, area domain.SeatingArea) (int, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "GetPreservedSeats", ctx, area)
+        ret0, _ := ret[0].(int)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// GetPreservedSeats indicates an expected call of GetPreservedSeats.
+func (mr *MockHostDeskMockRecorder) GetPreservedSeats(ctx, area any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreservedSeats", reflect.TypeOf((*MockHostDesk)(nil).GetPreservedSeats), ctx, area)
 }
 
 // GetTotalCapacity mocks base method.
-func (m *MockHostDesk) GetTotalCapacity(ctx context.Context) (int, error) {
+func (m *MockHostDesk) GetTotalCapacity(ctx context.Context, area domain.SeatingArea) (int, error) {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "GetTotalCapacity", ctx)
+        ret := m.ctrl.Call(m, "GetTotalCapacity", ctx, area)
         ret0, _ := ret[0].(int)
         ret1, _ := ret[1].(error)
         return ret0, ret1
 }
 
 // GetTotalCapacity indicates an expected call of GetTotalCapacity.
-func (mr *MockHostDeskMockRecorder) GetTotalCapacity(ctx any) *gomock.Call {
+func (mr *MockHostDeskMockRecorder) GetTotalCapacity(ctx, area any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalCapacity", reflect.TypeOf((*MockHostDesk)(nil).GetTotalCapacity), ctx, area)
+}
+
+// GetVacancy mocks base method.
+func (m *MockHostDesk) GetVacancy(ctx context.Context, area domain.SeatingArea) (*domain0.SeatingVacancy, error) {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "GetVacancy", ctx, area)
+        ret0, _ := ret[0].(*domain0.SeatingVacancy)
+        ret1, _ := ret[1].(error)
+        return ret0, ret1
+}
+
+// GetVacancy indicates an expected call of GetVacancy.
+func (mr *MockHostDeskMockRecorder) GetVacancy(ctx, area any) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalCapacity", reflect.TypeOf((*MockHostDesk)(nil).GetTotalCapacity), ctx)
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVacancy", reflect.TypeOf((*MockHostDesk)(nil).GetVacancy), ctx, area)
 }
 
 // HasPartyOccupiedSeat mocks base method.
@@ -102,32 +208,32 @@ func (mr *MockHostDeskMockRecorder) HasPartyOccupiedSeat(ctx, partyID any) *gomo
 }
 
 // NotifyPartyReady mocks base method.
-func (m *MockHostDesk) NotifyPartyReady(ctx context.Context, party *domain0.QueuedParty) error {
+func (m *MockHostDesk) NotifyPartyReady(ctx context.Context, party *domain1.QueuedParty, area domain.SeatingArea) error {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "NotifyPartyReady", ctx, party)
+        ret := m.ctrl.Call(m, "NotifyPartyReady", ctx, party, area)
         ret0, _ := ret[0].(error)
         return ret0
 }
 
 // NotifyPartyReady indicates an expected call of NotifyPartyReady.
-func (mr *MockHostDeskMockRecorder) NotifyPartyReady(ctx, party any) *gomock.Call {
+func (mr *MockHostDeskMockRecorder) NotifyPartyReady(ctx, party, area any) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyPartyReady", reflect.TypeOf((*MockHostDesk)(nil).NotifyPartyReady), ctx, party)
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyPartyReady", reflect.TypeOf((*MockHostDesk)(nil).NotifyPartyReady), ctx, party, area)
 }
 
 // PreserveSeats mocks base method.
-func (m *MockHostDesk) PreserveSeats(ctx context.Context, partyID domain.PartyID, seats int, version domain.Version) (bool, error) {
+func (m *MockHostDesk) PreserveSeats(ctx context.Context, partyID domain.PartyID, seats int, area domain.SeatingArea, version domain.Version) (bool, error) {
         m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "PreserveSeats", ctx, partyID, seats, version)
+        ret := m.ctrl.Call(m, "PreserveSeats", ctx, partyID, seats, area, version)
         ret0, _ := ret[0].(bool)
         ret1, _ := ret[1].(error)
         return ret0, ret1
 }
 
 // PreserveSeats indicates an expected call of PreserveSeats.
-func (mr *MockHostDeskMockRecorder) PreserveSeats(ctx, partyID, seats, version any) *gomock.Call {
+func (mr *MockHostDeskMockRecorder) PreserveSeats(ctx, partyID, seats, area, version any) *gomock.Call {
         mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreserveSeats", reflect.TypeOf((*MockHostDesk)(nil).PreserveSeats), ctx, partyID, seats, version)
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreserveSeats", reflect.TypeOf((*MockHostDesk)(nil).PreserveSeats), ctx, partyID, seats, area, version)
 }
 
 // ReleasePreservedSeats mocks base method.
@@ -160,7 +266,7 @@ func (mr *MockHostDeskMockRecorder) ServeImmediately(ctx, party any) *gomock.Cal
 }
 
 // ServiceComplete mocks base method.
-func (m *MockHostDesk) ServiceComplete(ctx context.Context, party *domain0.QueuedParty) error {
+func (m *MockHostDesk) ServiceComplete(ctx context.Context, party *domain1.QueuedParty) error {
         m.ctrl.T.Helper()
         ret := m.ctrl.Call(m, "ServiceComplete", ctx, party)
         ret0, _ := ret[0].(error)
@@ -173,3 +279,16 @@ func (mr *MockHostDeskMockRecorder) ServiceComplete(ctx, party any) *gomock.Call
         return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServiceComplete", reflect.TypeOf((*MockHostDesk)(nil).ServiceComplete), ctx, party)
 }
 
+// WatchServiceTimer mocks base method.
+func (m *MockHostDesk) WatchServiceTimer(ctx context.Context) error {
+        m.ctrl.T.Helper()
+        ret := m.ctrl.Call(m, "WatchServiceTimer", ctx)
+        ret0, _ := ret[0].(error)
+        return ret0
+}
+
+// WatchServiceTimer indicates an expected call of WatchServiceTimer.
+func (mr *MockHostDeskMockRecorder) WatchServiceTimer(ctx any) *gomock.Call {
+        mr.mock.ctrl.T.Helper()
+        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchServiceTimer", reflect.TypeOf((*MockHostDesk)(nil).WatchServiceTimer), ctx)
+}
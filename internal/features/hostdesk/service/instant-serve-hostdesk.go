@@ -3,71 +3,225 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	log "queue-bite/internal/config/logger"
 	d "queue-bite/internal/domain"
 	"queue-bite/internal/features/hostdesk/domain"
 	"queue-bite/internal/features/hostdesk/repository"
+	st "queue-bite/internal/features/servicetime/service"
 	wld "queue-bite/internal/features/waitlist/domain"
 	"queue-bite/internal/platform/eventbus"
 )
 
 var INSTANT_SERVE = "hostdesk/instant-serve"
 var SKIP_VERSION_CHECK = d.Version(-1)
+var SERVICE_TIMER_CLAIM_BATCH = 10
 
 type InstantServeHostDesk struct {
 	logger       log.Logger
+	restaurantID d.RestaurantID
 	repo         repository.HostDeskRepository
 	eventbus     eventbus.EventBus
 	servicetimer ServiceTimer
-	totalSeats   int
+	tables       []*domain.Table
+	holds        CapacityHolds
+	estimator    st.ServiceTimeEstimator
 }
 
+// CapacityHolds tells which parties have tables spoken for ahead of their arrival, such as reservations.
+type CapacityHolds interface {
+	// HeldPartySizes returns sizes of parties whose tables in area are held at time at.
+	HeldPartySizes(ctx context.Context, area d.SeatingArea, at time.Time) ([]int, error)
+}
+
+type HostDeskOption func(*InstantServeHostDesk)
+
+// WithCapacityHolds keeps tables fitting held parties out of vacancy, so they are neither offered to the waitlist
+// nor to walk-ins. Held parties are seated at the largest first, a party no free tables fit holds nothing.
+func WithCapacityHolds(holds CapacityHolds) HostDeskOption {
+	return func(h *InstantServeHostDesk) {
+		h.holds = holds
+	}
+}
+
+// WithServiceTimeEstimator lets host desk forecast seating, parties holding tables are expected
+// to give them back once their estimated service time passed.
+func WithServiceTimeEstimator(estimator st.ServiceTimeEstimator) HostDeskOption {
+	return func(h *InstantServeHostDesk) {
+		h.estimator = estimator
+	}
+}
+
+// NewInstantServeHostDesk seats parties at tables, the inventory is saved to repo.
 func NewInstantServeHostDesk(
 	logger log.Logger,
-	totalSeats int,
+	restaurantID d.RestaurantID,
+	tables []*domain.Table,
 	repo repository.HostDeskRepository,
 	eventbus eventbus.EventBus,
 	servicetimer ServiceTimer,
+	opts ...HostDeskOption,
 ) HostDesk {
-	return &InstantServeHostDesk{
+	h := &InstantServeHostDesk{
 		logger:       logger,
-		totalSeats:   totalSeats,
+		restaurantID: restaurantID,
+		tables:       tables,
 		repo:         repo,
 		eventbus:     eventbus,
 		servicetimer: servicetimer,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if err := repo.SaveTables(context.Background(), tables); err != nil {
+		logger.LogErr(INSTANT_SERVE, err, "could not save tables")
+	}
+	return h
 }
 
-func (h *InstantServeHostDesk) GetTotalCapacity(ctx context.Context) (int, error) {
-	return h.totalSeats, nil
+// WatchServiceTimer completes service of parties whose service timer is up, until ctx is done.
+func (h *InstantServeHostDesk) WatchServiceTimer(ctx context.Context) error {
+	if h.servicetimer == nil {
+		return nil
+	}
+	return h.servicetimer.Watch(ctx, h.completeServiceOnTimer)
+}
+
+func (h *InstantServeHostDesk) GetTotalCapacity(ctx context.Context, area d.SeatingArea) (int, error) {
+	return domain.TotalCovers(domain.TablesIn(h.tables, area)), nil
+}
+
+func (h *InstantServeHostDesk) GetMaxPartySize(ctx context.Context, area d.SeatingArea) (int, error) {
+	return domain.NewSeatingVacancy(area, h.tables, nil, 0).LargestParty(), nil
+}
+
+func (h *InstantServeHostDesk) GetVacancy(ctx context.Context, area d.SeatingArea) (*domain.SeatingVacancy, error) {
+	tables, err := h.repo.GetTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	held, version, err := h.repo.GetHeldTables(ctx, area)
+	if err != nil {
+		return nil, err
+	}
+	vacancy := domain.NewSeatingVacancy(area, tables, held, version)
+	if err := h.holdCapacity(ctx, vacancy); err != nil {
+		return nil, err
+	}
+	return vacancy, nil
 }
 
-func (h *InstantServeHostDesk) GetCurrentCapacity(ctx context.Context) (int, d.Version, error) {
-	totalUsed, version, err := h.repo.GetTotalSeatsInUse(ctx)
+// holdCapacity takes tables of parties held right now out of vacancy.
+func (h *InstantServeHostDesk) holdCapacity(ctx context.Context, vacancy *domain.SeatingVacancy) error {
+	if h.holds == nil {
+		return nil
+	}
+
+	sizes, err := h.holds.HeldPartySizes(ctx, vacancy.Area, time.Now())
 	if err != nil {
-		return h.totalSeats, version, err
+		return err
 	}
 
-	capacity := h.totalSeats - totalUsed
-	h.logger.LogDebug(INSTANT_SERVE, "current capacity", "capacity", capacity, "total used", totalUsed)
-	return capacity, version, nil
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	for _, size := range sizes {
+		if !vacancy.Hold(size) {
+			h.logger.LogDebug(INSTANT_SERVE, "no free tables to hold for party", "area", vacancy.Area, "size", size)
+		}
+	}
+	return nil
 }
 
-func (h *InstantServeHostDesk) NotifyPartyReady(ctx context.Context, party *wld.QueuedParty) error {
-	preserved, err := h.PreserveSeats(ctx, party.ID, party.Size, SKIP_VERSION_CHECK)
+// ForecastSeating starts from free tables of area, parties holding tables give them back after their estimated
+// service time counted from check-in, parties yet to check in are expected to check in right away.
+func (h *InstantServeHostDesk) ForecastSeating(ctx context.Context, area d.SeatingArea) (*domain.SeatingForecast, error) {
+	if h.estimator == nil {
+		return nil, nil
+	}
+
+	vacancy, err := h.GetVacancy(ctx, area)
+	if err != nil {
+		return nil, err
+	}
+	states, err := h.repo.GetPartyServiceStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[domain.TableID]*domain.Table, len(h.tables))
+	for _, table := range h.tables {
+		tables[table.ID] = table
+	}
+
+	now := time.Now()
+	forecast := domain.NewSeatingForecast(now, vacancy)
+	for _, state := range states {
+		if state.SeatingArea() != area {
+			continue
+		}
+
+		estimate, err := h.estimator.EstimateServiceTime(ctx, d.NewParty(state.ID, "", max(state.PartySize, 1)))
+		if err != nil {
+			return nil, err
+		}
+		checkedInAt := now
+		if state.Status == domain.SeatOccupied && !state.CheckedInAt.IsZero() {
+			checkedInAt = state.CheckedInAt
+		}
+
+		held := []*domain.Table{}
+		for _, id := range state.Tables {
+			if table, ok := tables[id]; ok {
+				held = append(held, table)
+			}
+		}
+		forecast.Release(held, checkedInAt.Add(estimate.Duration))
+	}
+	return forecast, nil
+}
+
+func (h *InstantServeHostDesk) GetCurrentCapacity(ctx context.Context, area d.SeatingArea) (int, d.Version, error) {
+	vacancy, err := h.GetVacancy(ctx, area)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	capacity := vacancy.Seats()
+	h.logger.LogDebug(INSTANT_SERVE, "current capacity", "area", area, "capacity", capacity)
+	return capacity, vacancy.Version, nil
+}
+
+func (h *InstantServeHostDesk) GetOccupiedSeats(ctx context.Context, area d.SeatingArea) (int, error) {
+	return h.repo.GetOccupiedSeats(ctx, area)
+}
+
+func (h *InstantServeHostDesk) GetPreservedSeats(ctx context.Context, area d.SeatingArea) (int, error) {
+	return h.repo.GetPreservedSeats(ctx, area)
+}
+
+func (h *InstantServeHostDesk) GetPartyServiceStates(ctx context.Context) ([]*domain.PartyServiceState, error) {
+	return h.repo.GetPartyServiceStates(ctx)
+}
+
+func (h *InstantServeHostDesk) GetPartyServiceState(ctx context.Context, partyID d.PartyID) (*domain.PartyServiceState, error) {
+	return h.repo.GetPartyServiceState(ctx, partyID)
+}
+
+func (h *InstantServeHostDesk) NotifyPartyReady(ctx context.Context, party *wld.QueuedParty, area d.SeatingArea) error {
+	preserved, err := h.PreserveSeats(ctx, party.ID, party.Size, area, SKIP_VERSION_CHECK)
 	if err != nil {
 		return err
 	}
 
 	if preserved {
-		h.eventbus.Publish(ctx, &domain.SeatsPreservedEvent{PartyID: party.ID})
+		h.eventbus.Publish(ctx, &domain.SeatsPreservedEvent{RestaurantID: h.restaurantID, PartyID: party.ID})
 		h.logger.LogDebug(INSTANT_SERVE, "seats preserved, notify party ready", "party id", party.ID)
 	}
 	return nil
 }
 
-func (h *InstantServeHostDesk) PreserveSeats(ctx context.Context, partyID d.PartyID, seats int, version d.Version) (bool, error) {
+func (h *InstantServeHostDesk) PreserveSeats(ctx context.Context, partyID d.PartyID, seats int, area d.SeatingArea, version d.Version) (bool, error) {
 	curr, err := h.repo.GetPartyServiceState(ctx, partyID)
 	if err != nil {
 		return false, err
@@ -78,21 +232,26 @@ func (h *InstantServeHostDesk) PreserveSeats(ctx context.Context, partyID d.Part
 		return false, domain.ErrPartyAlreadyExists
 	}
 
-	cap, v, err := h.GetCurrentCapacity(ctx)
-	if version != SKIP_VERSION_CHECK && v != version {
+	vacancy, err := h.GetVacancy(ctx, area)
+	if err != nil {
+		return false, err
+	}
+	if version != SKIP_VERSION_CHECK && vacancy.Version != version {
 		return false, d.ErrVersionMismatch
 	}
 
-	if cap < seats {
+	tables := domain.AssignTables(vacancy.Tables, seats)
+	if tables == nil {
 		return false, domain.ErrInsufficientCapacity
 	}
 
-	state := domain.NewPartyServiceFromPreserve(partyID, seats)
+	state := domain.NewPartyServiceFromPreserve(partyID, seats, tables, area)
 	err = h.repo.OptimisticCreatePartyServiceState(ctx, state, version)
 
 	if err != nil {
 		return false, err
 	}
+	h.logger.LogDebug(INSTANT_SERVE, "tables preserved", "party id", partyID, "tables", state.Tables)
 	return true, nil
 }
 
@@ -102,15 +261,25 @@ func (h *InstantServeHostDesk) ReleasePreservedSeats(ctx context.Context, partyI
 		return true, nil
 	}
 	switch err {
-	case domain.ErrPartyNotFound:
-	case domain.ErrPartyNoPreservedSeats:
+	case domain.ErrPartyNotFound, domain.ErrPartyNoPreservedSeats:
 		return false, nil
 	}
 	return false, fmt.Errorf("failed to release preserved seats for party: %v", partyID)
 }
 
 func (h *InstantServeHostDesk) ServeImmediately(ctx context.Context, party *d.Party) error {
-	state := domain.NewPartyServiceImmediately(party.ID, party.Size)
+	area := party.Preference.QueueArea()
+	vacancy, err := h.GetVacancy(ctx, area)
+	if err != nil {
+		return err
+	}
+
+	tables := domain.AssignTables(vacancy.Tables, party.Size)
+	if tables == nil {
+		return domain.ErrInsufficientCapacity
+	}
+
+	state := domain.NewPartyServiceImmediately(party.ID, party.Size, tables, area)
 	return h.repo.CreatePartyServiceState(ctx, state)
 }
 
@@ -127,19 +296,48 @@ func (h *InstantServeHostDesk) CheckIn(ctx context.Context, party *wld.QueuedPar
 	}
 
 	if h.servicetimer != nil {
-		h.servicetimer.StartTracking(ctx, party, func(ctx context.Context, partyID d.PartyID) error {
-			return h.ServiceComplete(ctx, party)
-		})
+		if err := h.servicetimer.StartTracking(ctx, party); err != nil {
+			h.logger.LogErr(INSTANT_SERVE, err, "could not start service timer", "party", party)
+		}
 	}
 	return nil
 }
 
 func (h *InstantServeHostDesk) ServiceComplete(ctx context.Context, party *wld.QueuedParty) error {
+	return h.completeService(ctx, party, false)
+}
+
+func (h *InstantServeHostDesk) completeService(ctx context.Context, party *wld.QueuedParty, byTimer bool) error {
+	state, err := h.repo.GetPartyServiceState(ctx, party.ID)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return domain.ErrPartyNotFound
+	}
+	if state.Status != domain.SeatOccupied {
+		return domain.ErrPartyNotSeated
+	}
+
+	if h.servicetimer != nil {
+		if err := h.servicetimer.StopTracking(ctx, party.ID); err != nil {
+			h.logger.LogErr(INSTANT_SERVE, err, "could not stop service timer", "party id", party.ID)
+		}
+	}
+
 	if err := h.repo.EndPartyServiceState(ctx, party.ID); err != nil {
 		return err
 	}
 	h.logger.LogDebug(INSTANT_SERVE, "service completed", "party", party)
-	if err := h.eventbus.Publish(ctx, domain.PartyServiceCompeletedEvent{PartyID: party.ID}); err != nil {
+	completed := domain.PartyServiceCompeletedEvent{
+		RestaurantID: h.restaurantID,
+		PartyID:      party.ID,
+		PartySize:    state.PartySize,
+		CheckedInAt:  state.CheckedInAt,
+		CompletedAt:  time.Now().UTC(),
+		EndedByTimer: byTimer,
+	}
+	if err := h.eventbus.Publish(ctx, completed); err != nil {
 		h.logger.LogErr(INSTANT_SERVE, err, "could not publish service completed event")
 		return err
 	}
@@ -147,6 +345,18 @@ func (h *InstantServeHostDesk) ServiceComplete(ctx context.Context, party *wld.Q
 	return nil
 }
 
+// completeServiceOnTimer ends service of party once its timer is up.
+// Party which was completed by staff meanwhile is already gone and ignored.
+func (h *InstantServeHostDesk) completeServiceOnTimer(ctx context.Context, partyID d.PartyID) error {
+	err := h.completeService(ctx, &wld.QueuedParty{Party: &d.Party{ID: partyID}}, true)
+	switch err {
+	case domain.ErrPartyNotFound, domain.ErrPartyNotSeated:
+		h.logger.LogDebug(INSTANT_SERVE, "service timer is up for party not seated", "party id", partyID)
+		return nil
+	}
+	return err
+}
+
 func (h *InstantServeHostDesk) HasPartyOccupiedSeat(ctx context.Context, partyID d.PartyID) bool {
 	state, err := h.repo.GetPartyServiceState(ctx, partyID)
 	h.logger.LogDebug(INSTANT_SERVE, "get party service state", "party", state)
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +16,9 @@ import (
 	d "queue-bite/internal/domain"
 	"queue-bite/internal/features/hostdesk/domain"
 	"queue-bite/internal/features/hostdesk/repository"
+	st "queue-bite/internal/features/servicetime/service"
 	w "queue-bite/internal/features/waitlist/domain"
+	dlr "queue-bite/internal/platform/deadline/redis"
 	"queue-bite/internal/platform/eventbus"
 	ebr "queue-bite/internal/platform/eventbus/redis"
 )
@@ -26,23 +30,23 @@ func TestPreserveSeats(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	inmemoryRepo := repository.NewInMemoryHostDeskRepository(logger)
-	redisRepo := repository.NewRedisHostDeskRepository(logger, redisClient)
+	redisRepo := repository.NewRedisHostDeskRepository(logger, redisClient, d.DefaultRestaurantID)
 	registry := eventbus.NewEventRegistry()
 	eventbus := ebr.NewRedisEventBus(logger, redisClient, registry)
 	totalSeats := 12
 	impl := []repository.HostDeskRepository{inmemoryRepo, redisRepo}
 	svc := []HostDesk{}
 	for _, repo := range impl {
-		svc = append(svc, NewInstantServeHostDesk(logger, totalSeats, repo, eventbus, nil))
+		svc = append(svc, NewInstantServeHostDesk(logger, d.DefaultRestaurantID, domain.NewSeatPool(d.SeatingAreaTable, totalSeats), repo, eventbus, nil))
 	}
 
 	t.Run("successful reservation", func(t *testing.T) {
 		for _, service := range svc {
-			ok, err := service.PreserveSeats(context.Background(), "party-1", 10, 0)
+			ok, err := service.PreserveSeats(context.Background(), "party-1", 10, d.SeatingAreaTable, 0)
 			require.NoError(t, err)
 			assert.True(t, ok)
 
-			available, version, err := service.GetCurrentCapacity(context.Background())
+			available, version, err := service.GetCurrentCapacity(context.Background(), d.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 2, available)
 			assert.Equal(t, 1, int(version))
@@ -51,7 +55,7 @@ func TestPreserveSeats(t *testing.T) {
 
 	t.Run("party already exists", func(t *testing.T) {
 		for _, service := range svc {
-			ok, err := service.PreserveSeats(context.Background(), "party-1", 2, 1)
+			ok, err := service.PreserveSeats(context.Background(), "party-1", 2, d.SeatingAreaTable, 1)
 			assert.ErrorIs(t, err, domain.ErrPartyAlreadyExists)
 			assert.False(t, ok)
 		}
@@ -59,7 +63,7 @@ func TestPreserveSeats(t *testing.T) {
 
 	t.Run("insufficient seats", func(t *testing.T) {
 		for _, service := range svc {
-			ok, err := service.PreserveSeats(context.Background(), "party-2", 4, 1)
+			ok, err := service.PreserveSeats(context.Background(), "party-2", 4, d.SeatingAreaTable, 1)
 			assert.ErrorIs(t, err, domain.ErrInsufficientCapacity)
 			assert.False(t, ok)
 		}
@@ -67,13 +71,94 @@ func TestPreserveSeats(t *testing.T) {
 
 	t.Run("version dismatch", func(t *testing.T) {
 		for _, service := range svc {
-			ok, err := service.PreserveSeats(context.Background(), "party-2", 2, 0)
+			ok, err := service.PreserveSeats(context.Background(), "party-2", 2, d.SeatingAreaTable, 0)
 			assert.ErrorIs(t, err, d.ErrVersionMismatch)
 			assert.False(t, ok)
 		}
 	})
 }
 
+func TestTableAssignment(t *testing.T) {
+	logger := log.NewNoopLogger()
+	redisClient, cleanup := setupRedisContainer(t)
+	t.Cleanup(cleanup)
+
+	inmemoryRepo := repository.NewInMemoryHostDeskRepository(logger)
+	redisRepo := repository.NewRedisHostDeskRepository(logger, redisClient, d.DefaultRestaurantID)
+	registry := eventbus.NewEventRegistry()
+	eventbus := ebr.NewRedisEventBus(logger, redisClient, registry)
+	tables, err := domain.ParseTables("A1:1-2:table,A2:1-2:table,B1:1-2:table:B2,B2:1-2:table,C1:3-4:table")
+	require.NoError(t, err)
+	impl := []repository.HostDeskRepository{inmemoryRepo, redisRepo}
+	svc := []HostDesk{}
+	for _, repo := range impl {
+		svc = append(svc, NewInstantServeHostDesk(logger, d.DefaultRestaurantID, tables, repo, eventbus, nil))
+	}
+
+	t.Run("single table fits party", func(t *testing.T) {
+		for _, service := range svc {
+			ok, err := service.PreserveSeats(context.Background(), "party-1", 4, d.SeatingAreaTable, 0)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			state, err := service.GetPartyServiceState(context.Background(), "party-1")
+			require.NoError(t, err)
+			assert.Equal(t, domain.TableIDs{"C1"}, state.Tables)
+		}
+	})
+
+	t.Run("combinable tables are pushed together", func(t *testing.T) {
+		for _, service := range svc {
+			ok, err := service.PreserveSeats(context.Background(), "party-2", 3, d.SeatingAreaTable, 1)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			state, err := service.GetPartyServiceState(context.Background(), "party-2")
+			require.NoError(t, err)
+			assert.ElementsMatch(t, domain.TableIDs{"B1", "B2"}, state.Tables)
+			assert.Equal(t, 4, state.SeatsCount)
+		}
+	})
+
+	t.Run("party is not split across separate tables", func(t *testing.T) {
+		for _, service := range svc {
+			vacancy, err := service.GetVacancy(context.Background(), d.SeatingAreaTable)
+			require.NoError(t, err)
+			assert.Equal(t, 4, vacancy.Seats())
+			assert.False(t, vacancy.CanSeat(4))
+			assert.True(t, vacancy.CanSeat(2))
+
+			ok, err := service.PreserveSeats(context.Background(), "party-3", 4, d.SeatingAreaTable, vacancy.Version)
+			assert.ErrorIs(t, err, domain.ErrInsufficientCapacity)
+			assert.False(t, ok)
+		}
+	})
+
+	t.Run("released tables are free again", func(t *testing.T) {
+		for _, service := range svc {
+			ok, err := service.ReleasePreservedSeats(context.Background(), "party-1")
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			vacancy, err := service.GetVacancy(context.Background(), d.SeatingAreaTable)
+			require.NoError(t, err)
+			assert.True(t, vacancy.CanSeat(4))
+
+			ok, err = service.ReleasePreservedSeats(context.Background(), "party-1")
+			require.NoError(t, err)
+			assert.False(t, ok, "seats are given back once")
+		}
+	})
+
+	t.Run("largest party", func(t *testing.T) {
+		for _, service := range svc {
+			size, err := service.GetMaxPartySize(context.Background(), d.SeatingAreaTable)
+			require.NoError(t, err)
+			assert.Equal(t, 4, size)
+		}
+	})
+}
+
 func TestTransferToOccupied(t *testing.T) {
 	// logger := log.NewZerologLogger(os.Stdout, true)
 	logger := log.NewNoopLogger()
@@ -81,19 +166,19 @@ func TestTransferToOccupied(t *testing.T) {
 	t.Cleanup(cleanup)
 
 	inmemoryRepo := repository.NewInMemoryHostDeskRepository(logger)
-	redisRepo := repository.NewRedisHostDeskRepository(logger, redisClient)
+	redisRepo := repository.NewRedisHostDeskRepository(logger, redisClient, d.DefaultRestaurantID)
 	registry := eventbus.NewEventRegistry()
 	eventbus := ebr.NewRedisEventBus(logger, redisClient, registry)
 	totalSeats := 12
 	impl := []repository.HostDeskRepository{inmemoryRepo, redisRepo}
 	svc := []HostDesk{}
 	for _, repo := range impl {
-		svc = append(svc, NewInstantServeHostDesk(logger, totalSeats, repo, eventbus, nil))
+		svc = append(svc, NewInstantServeHostDesk(logger, d.DefaultRestaurantID, domain.NewSeatPool(d.SeatingAreaTable, totalSeats), repo, eventbus, nil))
 	}
 
 	t.Run("preserved seats to occupied seats", func(t *testing.T) {
 		for _, service := range svc {
-			ok, err := service.PreserveSeats(context.Background(), "party-1", 2, 0)
+			ok, err := service.PreserveSeats(context.Background(), "party-1", 2, d.SeatingAreaTable, 0)
 			assert.NoError(t, err)
 			assert.True(t, ok)
 
@@ -105,8 +190,136 @@ func TestTransferToOccupied(t *testing.T) {
 
 			exists = service.HasPartyOccupiedSeat(context.Background(), "party-1")
 			assert.True(t, exists)
+
+			// releasing preserved seats leaves seats of a checked in party occupied
+			ok, err = service.ReleasePreservedSeats(context.Background(), "party-1")
+			require.NoError(t, err)
+			assert.False(t, ok)
+			assert.True(t, service.HasPartyOccupiedSeat(context.Background(), "party-1"))
+		}
+	})
+}
+
+func TestServiceComplete(t *testing.T) {
+	logger := log.NewNoopLogger()
+	redisClient, cleanup := setupRedisContainer(t)
+	t.Cleanup(cleanup)
+
+	inmemoryRepo := repository.NewInMemoryHostDeskRepository(logger)
+	redisRepo := repository.NewRedisHostDeskRepository(logger, redisClient, d.DefaultRestaurantID)
+	registry := eventbus.NewEventRegistry()
+	eventbus := ebr.NewRedisEventBus(logger, redisClient, registry)
+	totalSeats := 12
+	impl := []repository.HostDeskRepository{inmemoryRepo, redisRepo}
+	timers := []*linearServiceTimer{}
+	svc := []HostDesk{}
+	for _, repo := range impl {
+		timer := NewLinearServiceTimer(logger, st.NewFixedRateEstimator(30*time.Minute)).(*linearServiceTimer)
+		timers = append(timers, timer)
+		service := NewInstantServeHostDesk(logger, d.DefaultRestaurantID, domain.NewSeatPool(d.SeatingAreaTable, totalSeats), repo, eventbus, timer)
+		require.NoError(t, service.WatchServiceTimer(context.Background()))
+		svc = append(svc, service)
+	}
+
+	t.Run("preserved party could not complete service", func(t *testing.T) {
+		for _, service := range svc {
+			ok, err := service.PreserveSeats(context.Background(), "party-1", 2, d.SeatingAreaTable, SKIP_VERSION_CHECK)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			err = service.ServiceComplete(context.Background(), &w.QueuedParty{Party: &d.Party{ID: "party-1"}})
+			assert.ErrorIs(t, err, domain.ErrPartyNotSeated)
 		}
 	})
+
+	t.Run("complete service before timer fires", func(t *testing.T) {
+		for i, service := range svc {
+			party := &w.QueuedParty{Party: &d.Party{ID: "party-1", Size: 2, Status: d.PartyStatusReady}}
+			err := service.CheckIn(context.Background(), party)
+			require.NoError(t, err)
+			assert.Contains(t, timers[i].timers, party.ID)
+
+			err = service.ServiceComplete(context.Background(), party)
+			require.NoError(t, err)
+			assert.NotContains(t, timers[i].timers, party.ID)
+			assert.False(t, service.HasPartyOccupiedSeat(context.Background(), party.ID))
+
+			capacity, _, err := service.GetCurrentCapacity(context.Background(), d.SeatingAreaTable)
+			require.NoError(t, err)
+			assert.Equal(t, totalSeats, capacity)
+		}
+	})
+
+	t.Run("unknown party", func(t *testing.T) {
+		for _, service := range svc {
+			err := service.ServiceComplete(context.Background(), &w.QueuedParty{Party: &d.Party{ID: "party-unknown"}})
+			assert.ErrorIs(t, err, domain.ErrPartyNotFound)
+		}
+	})
+}
+
+func TestDurableServiceTimer(t *testing.T) {
+	logger := log.NewNoopLogger()
+	redisClient, cleanup := setupRedisContainer(t)
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	registry := eventbus.NewEventRegistry()
+	registry.Register(domain.TopicPartyServiceCompleted, &domain.PartyServiceCompeletedEvent{})
+	bus := ebr.NewRedisEventBus(logger, redisClient, registry)
+	repo := repository.NewRedisHostDeskRepository(logger, redisClient, d.DefaultRestaurantID)
+	totalSeats := 12
+
+	var completed atomic.Int32
+	bus.Subscribe(domain.TopicPartyServiceCompleted, func(ctx context.Context, event eventbus.Event) error {
+		completed.Add(1)
+		return nil
+	})
+
+	// every instance owns its timer while sharing redis, as replicas do
+	newInstance := func() (HostDesk, ServiceTimer) {
+		timer := NewDurableServiceTimer(logger, dlr.NewRedisDeadlineQueue(logger, redisClient, "service"), st.NewFixedRateEstimator(50*time.Millisecond), 10*time.Millisecond)
+		service := NewInstantServeHostDesk(logger, d.DefaultRestaurantID, domain.NewSeatPool(d.SeatingAreaTable, totalSeats), repo, bus, timer)
+		require.NoError(t, service.WatchServiceTimer(ctx))
+		return service, timer
+	}
+	checkIn := func(t *testing.T, service HostDesk, partyID d.PartyID) {
+		ok, err := service.PreserveSeats(ctx, partyID, 2, d.SeatingAreaTable, SKIP_VERSION_CHECK)
+		require.NoError(t, err)
+		require.True(t, ok)
+		err = service.CheckIn(ctx, &w.QueuedParty{Party: &d.Party{ID: partyID, Size: 2, Status: d.PartyStatusReady}})
+		require.NoError(t, err)
+	}
+	fullCapacity := func() bool {
+		inUse, _, err := repo.GetTotalSeatsInUse(ctx, d.SeatingAreaTable)
+		return err == nil && inUse == 0
+	}
+
+	t.Run("service completes once across instances", func(t *testing.T) {
+		service, timer := newInstance()
+		_, otherTimer := newInstance()
+		defer timer.Unwatch(ctx)
+		defer otherTimer.Unwatch(ctx)
+
+		checkIn(t, service, "party-1")
+		assert.Eventually(t, fullCapacity, time.Second, 10*time.Millisecond)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, int32(1), completed.Load())
+	})
+
+	t.Run("pending timer is recovered after restart", func(t *testing.T) {
+		service, timer := newInstance()
+		checkIn(t, service, "party-2")
+		require.NoError(t, timer.Unwatch(ctx))
+
+		time.Sleep(100 * time.Millisecond)
+		assert.True(t, service.HasPartyOccupiedSeat(ctx, "party-2"))
+
+		_, restarted := newInstance()
+		defer restarted.Unwatch(ctx)
+		assert.Eventually(t, fullCapacity, time.Second, 10*time.Millisecond)
+	})
 }
 
 func setupRedisContainer(t *testing.T) (*redis.Client, func()) {
@@ -135,3 +348,65 @@ func setupRedisContainer(t *testing.T) (*redis.Client, func()) {
 	client := redis.NewClient(&redis.Options{Addr: endpoint})
 	return client, cleanup
 }
+
+func TestServiceTimerDuration(t *testing.T) {
+	ctx := context.Background()
+	estimator := st.NewFixedRateEstimator(time.Minute)
+
+	t.Run("estimate stamped on joining waitlist", func(t *testing.T) {
+		party := &w.QueuedParty{Party: &d.Party{ID: "party-1", Size: 2, EstimatedServiceTime: 7 * time.Minute}}
+		duration, err := serviceDuration(ctx, estimator, party)
+		require.NoError(t, err)
+		assert.Equal(t, 7*time.Minute, duration)
+	})
+
+	t.Run("party seated without queueing", func(t *testing.T) {
+		party := &w.QueuedParty{Party: &d.Party{ID: "party-1", Size: 2}}
+		duration, err := serviceDuration(ctx, estimator, party)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, duration)
+	})
+}
+
+func TestForecastSeating(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNoopLogger()
+	tables, err := domain.ParseTables("A1:1-2:table,A2:1-2:table,C1:3-4:table,B1:1-1:counter")
+	require.NoError(t, err)
+	repo := repository.NewInMemoryHostDeskRepository(logger)
+	estimator := st.NewFixedRateEstimator(10 * time.Minute)
+
+	t.Run("without estimator", func(t *testing.T) {
+		hostdesk := NewInstantServeHostDesk(logger, d.DefaultRestaurantID, tables, repo, nil, nil)
+		forecast, err := hostdesk.ForecastSeating(ctx, d.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Nil(t, forecast)
+	})
+
+	t.Run("parties in line take tables as seated parties give them back", func(t *testing.T) {
+		hostdesk := NewInstantServeHostDesk(logger, d.DefaultRestaurantID, tables, repo, nil, nil, WithServiceTimeEstimator(estimator))
+		seated := domain.NewPartyServiceImmediately("party-1", 2, tables[:1], d.SeatingAreaTable)
+		seated.Status = domain.SeatOccupied
+		seated.CheckedInAt = time.Now().Add(-10 * time.Minute)
+		require.NoError(t, repo.CreatePartyServiceState(ctx, seated))
+		require.NoError(t, repo.CreatePartyServiceState(ctx, domain.NewPartyServiceFromPreserve("party-2", 3, tables[2:3], d.SeatingAreaTable)))
+
+		forecast, err := hostdesk.ForecastSeating(ctx, d.SeatingAreaTable)
+		require.NoError(t, err)
+		require.NotNil(t, forecast)
+		start := forecast.Start()
+		seat := func(size int) time.Duration {
+			at, ok := forecast.Seat(size, time.Duration(size)*10*time.Minute)
+			require.True(t, ok)
+			return at.Sub(start).Round(time.Minute)
+		}
+
+		assert.Equal(t, time.Duration(0), seat(2), "free table fits right away")
+		assert.Equal(t, 10*time.Minute, seat(2), "seated party is through in 10 minutes")
+		assert.Equal(t, 30*time.Minute, seat(4), "preserved party checks in right away")
+		assert.Equal(t, 30*time.Minute, seat(1), "not seated ahead of parties before it")
+
+		_, ok := forecast.Seat(5, time.Hour)
+		assert.False(t, ok, "no tables ever fit")
+	})
+}
@@ -7,39 +7,93 @@ import (
 
 	log "queue-bite/internal/config/logger"
 	"queue-bite/internal/domain"
+	st "queue-bite/internal/features/servicetime/service"
 	wld "queue-bite/internal/features/waitlist/domain"
+	"queue-bite/internal/platform/deadline"
 )
 
 type ServiceCompletionCallback func(ctx context.Context, partyID domain.PartyID) error
 
 type ServiceTimer interface {
-	StartTracking(ctx context.Context, partyID *wld.QueuedParty, onComplete ServiceCompletionCallback) error
+	// Watch registers callback fired when service time of any tracked party is up, until ctx is done.
+	// Timer keeps a single callback, so it also applies to parties tracked before a restart.
+	Watch(ctx context.Context, onComplete ServiceCompletionCallback) error
+
+	// Unwatch stops firing callback, pending timers are kept if the timer is durable.
+	Unwatch(ctx context.Context) error
+
+	// StartTracking times service of party for as long as it was estimated to stay, see serviceDuration.
+	StartTracking(ctx context.Context, party *wld.QueuedParty) error
+
+	// StopTracking cancels pending timer of party, e.g. service ended before the timer fires.
+	StopTracking(ctx context.Context, partyID domain.PartyID) error
+}
+
+// serviceDuration is how long party is held its seats, the estimate stamped when it joined the waitlist,
+// so seats are held as long as diners were told to wait. Parties seated without queueing are estimated now.
+func serviceDuration(ctx context.Context, estimator st.ServiceTimeEstimator, party *wld.QueuedParty) (time.Duration, error) {
+	if party.EstimatedServiceTime > 0 {
+		return party.EstimatedServiceTime, nil
+	}
+	estimate, err := estimator.EstimateServiceTime(ctx, party.Party)
+	if err != nil {
+		return 0, err
+	}
+	return estimate.Duration, nil
 }
 
 type linearServiceTimer struct {
-	logger           log.Logger
-	timers           map[domain.PartyID]*time.Timer
-	durationPerGuest time.Duration
-	mu               sync.Mutex
+	logger     log.Logger
+	timers     map[domain.PartyID]*time.Timer
+	estimator  st.ServiceTimeEstimator
+	onComplete ServiceCompletionCallback
+	mu         sync.Mutex
 }
 
-func NewLinearServiceTimer(logger log.Logger, durationPerGuest time.Duration) ServiceTimer {
+func NewLinearServiceTimer(logger log.Logger, estimator st.ServiceTimeEstimator) ServiceTimer {
 	return &linearServiceTimer{
-		logger:           logger,
-		timers:           make(map[domain.PartyID]*time.Timer),
-		durationPerGuest: durationPerGuest,
-		mu:               sync.Mutex{},
+		logger:    logger,
+		timers:    make(map[domain.PartyID]*time.Timer),
+		estimator: estimator,
+		mu:        sync.Mutex{},
+	}
+}
+
+func (t *linearServiceTimer) Watch(ctx context.Context, onComplete ServiceCompletionCallback) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onComplete = onComplete
+	return nil
+}
+
+func (t *linearServiceTimer) Unwatch(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for partyID, timer := range t.timers {
+		timer.Stop()
+		delete(t.timers, partyID)
 	}
+	t.onComplete = nil
+	return nil
 }
 
-func (t *linearServiceTimer) StartTracking(ctx context.Context, party *wld.QueuedParty, onComplete ServiceCompletionCallback) error {
+func (t *linearServiceTimer) StartTracking(ctx context.Context, party *wld.QueuedParty) error {
+	period, err := serviceDuration(ctx, t.estimator, party)
+	if err != nil {
+		return err
+	}
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	period := t.durationPerGuest * time.Duration(party.Size)
+	onComplete := t.onComplete
 	t.timers[party.ID] = time.AfterFunc(period, func() {
-		if err := onComplete(context.Background(), party.ID); err != nil {
-			t.logger.LogErr("servicetimer/linear", err, "failed on timer completed", "duration", period, "party", party)
+		if onComplete != nil {
+			if err := onComplete(context.Background(), party.ID); err != nil {
+				t.logger.LogErr("servicetimer/linear", err, "failed on timer completed", "duration", period, "party", party)
+			}
 		}
 		t.mu.Lock()
 		delete(t.timers, party.ID)
@@ -50,3 +104,87 @@ func (t *linearServiceTimer) StartTracking(ctx context.Context, party *wld.Queue
 
 	return nil
 }
+
+func (t *linearServiceTimer) StopTracking(ctx context.Context, partyID domain.PartyID) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timer, exists := t.timers[partyID]
+	if !exists {
+		return nil
+	}
+
+	timer.Stop()
+	delete(t.timers, partyID)
+	t.logger.LogDebug("servicetimer/linear", "stop service timer", "party id", partyID)
+	return nil
+}
+
+// durableServiceTimer keeps end of service deadlines in a shared deadline queue instead of process memory.
+// Deadlines survive restarts and each expired one is leased to a single instance at a time,
+// then dropped once service completed, or retried if completing it failed.
+type durableServiceTimer struct {
+	logger       log.Logger
+	deadlines    deadline.Queue
+	estimator    st.ServiceTimeEstimator
+	pollInterval time.Duration
+	poller       *deadline.Poller
+	mu           sync.Mutex
+}
+
+func NewDurableServiceTimer(logger log.Logger, deadlines deadline.Queue, estimator st.ServiceTimeEstimator, pollInterval time.Duration) ServiceTimer {
+	return &durableServiceTimer{
+		logger:       logger,
+		deadlines:    deadlines,
+		estimator:    estimator,
+		pollInterval: pollInterval,
+	}
+}
+
+// Watch starts polling for expired deadlines until ctx is done, the ones expired while no instance was running
+// are claimed on the first poll.
+func (t *durableServiceTimer) Watch(ctx context.Context, onComplete ServiceCompletionCallback) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.poller != nil {
+		t.poller.Stop()
+	}
+	t.poller = deadline.NewPoller(t.logger, t.deadlines, t.pollInterval, SERVICE_TIMER_CLAIM_BATCH, func(ctx context.Context, id string) error {
+		t.logger.LogDebug("servicetimer/durable", "end of service timer", "party id", id)
+		return onComplete(ctx, domain.PartyID(id))
+	})
+	t.poller.Start(ctx)
+	return nil
+}
+
+func (t *durableServiceTimer) Unwatch(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.poller != nil {
+		t.poller.Stop()
+		t.poller = nil
+	}
+	return nil
+}
+
+func (t *durableServiceTimer) StartTracking(ctx context.Context, party *wld.QueuedParty) error {
+	period, err := serviceDuration(ctx, t.estimator, party)
+	if err != nil {
+		return err
+	}
+	if err := t.deadlines.Schedule(ctx, string(party.ID), time.Now().Add(period)); err != nil {
+		return err
+	}
+	t.logger.LogDebug("servicetimer/durable", "start service timer for party checkin", "duration", period, "party", party)
+	return nil
+}
+
+func (t *durableServiceTimer) StopTracking(ctx context.Context, partyID domain.PartyID) error {
+	if err := t.deadlines.Cancel(ctx, string(partyID)); err != nil {
+		return err
+	}
+	t.logger.LogDebug("servicetimer/durable", "stop service timer", "party id", partyID)
+	return nil
+}
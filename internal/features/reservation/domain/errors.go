@@ -0,0 +1,11 @@
+package domain
+
+import "errors"
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotBooked = errors.New("reservation is no longer booked")
+	ErrSlotInPast           = errors.New("reservation slot is in the past")
+	ErrPartyTooLarge        = errors.New("no tables could seat party of this size")
+	ErrFullyBooked          = errors.New("tables are fully booked around this slot")
+)
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"time"
+
+	d "queue-bite/internal/domain"
+)
+
+type ReservationID string
+
+func (id ReservationID) MarshalBinary() ([]byte, error) {
+	return []byte(string(id)), nil
+}
+
+func (id *ReservationID) UnmarshalBinary(data []byte) error {
+	*id = ReservationID(data)
+	return nil
+}
+
+type ReservationStatus string
+
+func (s ReservationStatus) MarshalBinary() ([]byte, error) {
+	return []byte(string(s)), nil
+}
+
+func (s *ReservationStatus) UnmarshalBinary(data []byte) error {
+	*s = ReservationStatus(data)
+	return nil
+}
+
+const (
+	ReservationBooked    ReservationStatus = "booked"
+	ReservationArrived   ReservationStatus = "arrived"
+	ReservationCancelled ReservationStatus = "cancelled"
+	// ReservationNoShow is a booking whose party did not arrive before its hold expired.
+	ReservationNoShow ReservationStatus = "no-show"
+)
+
+// Reservation books tables of an area for a party at a future time slot.
+type Reservation struct {
+	ID     ReservationID     `redis:"ID"`
+	Name   string            `redis:"Name"`
+	Size   int               `redis:"Size"`
+	Area   d.SeatingArea     `redis:"Area"`
+	SlotAt time.Time         `redis:"SlotAt"`
+	Status ReservationStatus `redis:"Status"`
+	// PartyID is the party queued once the reservation arrived.
+	PartyID   d.PartyID `redis:"PartyID"`
+	ArrivedAt time.Time `redis:"ArrivedAt"`
+}
+
+func NewReservation(id ReservationID, name string, size int, area d.SeatingArea, slotAt time.Time) *Reservation {
+	return &Reservation{
+		ID:     id,
+		Name:   name,
+		Size:   size,
+		Area:   area,
+		SlotAt: slotAt.UTC(),
+		Status: ReservationBooked,
+	}
+}
+
+// HoldWindow is how long before its slot a booking starts holding tables,
+// and how long past the slot the tables are still held for a late party.
+type HoldWindow struct {
+	Before time.Duration
+	Grace  time.Duration
+}
+
+// Holds tells if tables of reservation are spoken for at time at.
+func (w HoldWindow) Holds(r *Reservation, at time.Time) bool {
+	return r.Status == ReservationBooked && !at.Before(r.SlotAt.Add(-w.Before)) && !at.After(w.ExpiresAt(r))
+}
+
+// ExpiresAt is when tables held for reservation are given back if its party has not arrived.
+func (w HoldWindow) ExpiresAt(r *Reservation) time.Time {
+	return r.SlotAt.Add(w.Grace)
+}
+
+// OnTime tells if party arriving at time at is still within the grace window of its slot,
+// later parties lose their priority and are queued as walk-ins.
+func (w HoldWindow) OnTime(r *Reservation, at time.Time) bool {
+	return !at.After(w.ExpiresAt(r))
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/features/reservation/domain"
+)
+
+type InMemoryReservationRepository struct {
+	logger       log.Logger
+	mu           sync.RWMutex
+	reservations map[domain.ReservationID]domain.Reservation
+}
+
+func NewInMemoryReservationRepository(logger log.Logger) ReservationRepository {
+	return &InMemoryReservationRepository{
+		logger:       logger,
+		reservations: make(map[domain.ReservationID]domain.Reservation),
+	}
+}
+
+func (r *InMemoryReservationRepository) SaveReservation(ctx context.Context, reservation *domain.Reservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reservations[reservation.ID] = *reservation
+	return nil
+}
+
+func (r *InMemoryReservationRepository) GetReservation(ctx context.Context, id domain.ReservationID) (*domain.Reservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reservation, exists := r.reservations[id]
+	if !exists {
+		return nil, nil
+	}
+	return &reservation, nil
+}
+
+func (r *InMemoryReservationRepository) GetReservationsBetween(ctx context.Context, from, to time.Time) ([]*domain.Reservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reservations := []*domain.Reservation{}
+	for _, reservation := range r.reservations {
+		if reservation.SlotAt.Before(from) || reservation.SlotAt.After(to) {
+			continue
+		}
+		reservations = append(reservations, &reservation)
+	}
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return reservations[i].SlotAt.Before(reservations[j].SlotAt)
+	})
+	return reservations, nil
+}
+
+func (r *InMemoryReservationRepository) UpdateBookedReservation(ctx context.Context, reservation *domain.Reservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.reservations[reservation.ID]
+	if !exists {
+		return domain.ErrReservationNotFound
+	}
+	if stored.Status != domain.ReservationBooked {
+		return domain.ErrReservationNotBooked
+	}
+
+	stored.Status = reservation.Status
+	stored.PartyID = reservation.PartyID
+	stored.ArrivedAt = reservation.ArrivedAt
+	r.reservations[reservation.ID] = stored
+	return nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/reservation/domain"
+)
+
+var REDIS_RESERVATION = "reservation/redis"
+
+// RESERVATION_RETENTION is how long a reservation is kept past its slot.
+var RESERVATION_RETENTION = 24 * time.Hour
+
+// reservationRedisKeys namespaces every reservation key by restaurant.
+type reservationRedisKeys struct {
+	restaurantID d.RestaurantID
+}
+
+// rsv:<restaurant>:booking:<id>
+func (k *reservationRedisKeys) getReservationKey(id domain.ReservationID) string {
+	return fmt.Sprintf("rsv:%s:booking:%s", k.restaurantID, id)
+}
+
+// rsv:<restaurant>:slots
+func (k *reservationRedisKeys) getSlotsKey() string {
+	return fmt.Sprintf("rsv:%s:slots", k.restaurantID)
+}
+
+// updateBookedScript settles a reservation only while it is still booked.
+// KEYS[1]: reservation hash
+// ARGV: field value pairs to write
+const updateBookedScript = `
+local status = redis.call('HGET', KEYS[1], 'Status')
+if not status then
+    return redis.error_reply("ErrReservationNotFound")
+end
+if status ~= 'booked' then
+    return redis.error_reply("ErrReservationNotBooked")
+end
+redis.call('HSET', KEYS[1], unpack(ARGV))
+return 1
+`
+
+type RedisReservationRepository struct {
+	logger log.Logger
+	client *redis.Client
+	keys   *reservationRedisKeys
+
+	updateBookedScript *redis.Script
+}
+
+func NewRedisReservationRepository(logger log.Logger, client *redis.Client, restaurantID d.RestaurantID) ReservationRepository {
+	return &RedisReservationRepository{
+		logger: logger,
+		client: client,
+		keys:   &reservationRedisKeys{restaurantID: restaurantID},
+
+		updateBookedScript: redis.NewScript(updateBookedScript),
+	}
+}
+
+// SaveReservation stores reservation until RESERVATION_RETENTION past its slot,
+// slots of reservations expired meanwhile are pruned along.
+func (r *RedisReservationRepository) SaveReservation(ctx context.Context, reservation *domain.Reservation) error {
+	key := r.keys.getReservationKey(reservation.ID)
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, reservation)
+	pipe.ExpireAt(ctx, key, reservation.SlotAt.Add(RESERVATION_RETENTION))
+	pipe.ZAdd(ctx, r.keys.getSlotsKey(), redis.Z{Score: float64(reservation.SlotAt.Unix()), Member: string(reservation.ID)})
+	pipe.ZRemRangeByScore(ctx, r.keys.getSlotsKey(), "-inf", strconv.FormatInt(time.Now().Add(-RESERVATION_RETENTION).Unix(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.LogErr(REDIS_RESERVATION, err, "could not save reservation", "reservation", reservation)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisReservationRepository) GetReservation(ctx context.Context, id domain.ReservationID) (*domain.Reservation, error) {
+	res := r.client.HGetAll(ctx, r.keys.getReservationKey(id))
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	if len(res.Val()) == 0 {
+		return nil, nil
+	}
+
+	reservation := &domain.Reservation{}
+	if err := res.Scan(reservation); err != nil {
+		r.logger.LogErr(REDIS_RESERVATION, err, "could not parse reservation", "id", id)
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func (r *RedisReservationRepository) GetReservationsBetween(ctx context.Context, from, to time.Time) ([]*domain.Reservation, error) {
+	ids, err := r.client.ZRangeByScore(ctx, r.keys.getSlotsKey(), &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.Unix(), 10),
+		Max: strconv.FormatInt(to.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, r.keys.getReservationKey(domain.ReservationID(id)))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reservations := make([]*domain.Reservation, 0, len(ids))
+	for i, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			continue
+		}
+		reservation := &domain.Reservation{}
+		if err := cmd.Scan(reservation); err != nil {
+			r.logger.LogErr(REDIS_RESERVATION, err, "could not parse reservation", "id", ids[i])
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, nil
+}
+
+func (r *RedisReservationRepository) UpdateBookedReservation(ctx context.Context, reservation *domain.Reservation) error {
+	err := r.updateBookedScript.Run(ctx, r.client, []string{r.keys.getReservationKey(reservation.ID)},
+		"Status", string(reservation.Status),
+		"PartyID", string(reservation.PartyID),
+		"ArrivedAt", reservation.ArrivedAt.Format(time.RFC3339Nano),
+	).Err()
+	if err == nil {
+		return nil
+	}
+
+	switch err.Error() {
+	case "ERR ErrReservationNotFound":
+		return domain.ErrReservationNotFound
+	case "ERR ErrReservationNotBooked":
+		return domain.ErrReservationNotBooked
+	}
+	r.logger.LogErr(REDIS_RESERVATION, err, "could not update reservation", "reservation", reservation)
+	return err
+}
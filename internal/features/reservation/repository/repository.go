@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"queue-bite/internal/features/reservation/domain"
+)
+
+// ReservationRepository keeps bookings of a restaurant ordered by their slot.
+type ReservationRepository interface {
+	SaveReservation(ctx context.Context, reservation *domain.Reservation) error
+
+	// GetReservation returns nil, nil if reservation is not found.
+	GetReservation(ctx context.Context, id domain.ReservationID) (*domain.Reservation, error)
+
+	// GetReservationsBetween lists reservations whose slot is within [from, to], in order of their slot.
+	GetReservationsBetween(ctx context.Context, from, to time.Time) ([]*domain.Reservation, error)
+
+	// UpdateBookedReservation writes status and arrival of reservation only while the stored one is still booked,
+	// so a reservation could only be settled once. Returns ErrReservationNotBooked otherwise.
+	UpdateBookedReservation(ctx context.Context, reservation *domain.Reservation) error
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	"queue-bite/internal/features/reservation/domain"
+	"queue-bite/internal/features/reservation/repository"
+	"queue-bite/internal/platform/deadline"
+	"queue-bite/pkg/utils"
+)
+
+var RESERVATION = "reservation"
+var HOLD_EXPIRY_CLAIM_BATCH = 10
+var HOLD_EXPIRY_RETRY_BACKOFF = time.Second
+var HOLD_EXPIRY_MAX_RETRY_BACKOFF = time.Minute
+
+// HoldExpiredCallback is called once tables held for a reservation are given back as its party did not show up.
+type HoldExpiredCallback func(ctx context.Context, reservation *domain.Reservation) error
+
+type ReservationBook interface {
+	// Book takes a reservation of party of size for area at slotAt.
+	// Returns ErrPartyTooLarge if no tables of area could seat party,
+	// ErrFullyBooked if tables could not seat it along the reservations around the slot.
+	Book(ctx context.Context, name string, size int, area d.SeatingArea, slotAt time.Time) (*domain.Reservation, error)
+
+	// Cancel gives up a booked reservation along with tables held for it.
+	Cancel(ctx context.Context, id domain.ReservationID) error
+
+	// GetReservation returns nil, nil if reservation is not found.
+	GetReservation(ctx context.Context, id domain.ReservationID) (*domain.Reservation, error)
+
+	// GetUpcoming lists reservations from one turn ago until until, in order of their slot.
+	GetUpcoming(ctx context.Context, until time.Time) ([]*domain.Reservation, error)
+
+	// Arrive settles reservation as arrived by party, which drops its held tables.
+	// Tells whether party arrived within the grace window and keeps its priority over walk-ins.
+	Arrive(ctx context.Context, id domain.ReservationID, partyID d.PartyID) (*domain.Reservation, bool, error)
+
+	// HeldPartySizes returns sizes of booked parties whose tables in area are held at time at.
+	HeldPartySizes(ctx context.Context, area d.SeatingArea, at time.Time) ([]int, error)
+
+	// Watch starts giving back tables of reservations whose party did not show up within the grace window.
+	Watch(ctx context.Context, onExpired HoldExpiredCallback) error
+	Unwatch(ctx context.Context) error
+}
+
+// reservationBook holds tables of a booking from shortly before its slot until its grace window is over.
+// Bookings closer than a turn to each other compete for the same tables.
+// Hold expiry deadlines live in the shared deadline queue, so each no-show is settled by a single instance.
+type reservationBook struct {
+	logger       log.Logger
+	tables       []*hdd.Table
+	repo         repository.ReservationRepository
+	window       domain.HoldWindow
+	turnTime     time.Duration
+	deadlines    deadline.Queue
+	pollInterval time.Duration
+	poller       *deadline.Poller
+	mu           sync.Mutex
+}
+
+// NewReservationBook books tables of the inventory, hold expiry is not tracked if deadlines is nil.
+func NewReservationBook(
+	logger log.Logger,
+	tables []*hdd.Table,
+	repo repository.ReservationRepository,
+	window domain.HoldWindow,
+	turnTime time.Duration,
+	deadlines deadline.Queue,
+	pollInterval time.Duration,
+) ReservationBook {
+	return &reservationBook{
+		logger:       logger,
+		tables:       tables,
+		repo:         repo,
+		window:       window,
+		turnTime:     turnTime,
+		deadlines:    deadlines,
+		pollInterval: pollInterval,
+	}
+}
+
+func (b *reservationBook) Book(ctx context.Context, name string, size int, area d.SeatingArea, slotAt time.Time) (*domain.Reservation, error) {
+	if slotAt.Before(time.Now()) {
+		return nil, domain.ErrSlotInPast
+	}
+
+	inventory := hdd.NewSeatingVacancy(area, b.tables, nil, 0)
+	if !inventory.CanSeat(size) {
+		return nil, domain.ErrPartyTooLarge
+	}
+
+	around, err := b.repo.GetReservationsBetween(ctx, slotAt.Add(-b.turnTime), slotAt.Add(b.turnTime))
+	if err != nil {
+		return nil, err
+	}
+	sizes := []int{size}
+	for _, other := range around {
+		if other.Area != area || !b.competes(other, slotAt) {
+			continue
+		}
+		sizes = append(sizes, other.Size)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	for _, size := range sizes {
+		if !inventory.Hold(size) {
+			return nil, domain.ErrFullyBooked
+		}
+	}
+
+	reservation := domain.NewReservation(domain.ReservationID(utils.GenerateID()), name, size, area, slotAt)
+	if err := b.repo.SaveReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+	if b.deadlines != nil {
+		if err := b.deadlines.Schedule(ctx, string(reservation.ID), b.window.ExpiresAt(reservation)); err != nil {
+			b.logger.LogErr(RESERVATION, err, "could not schedule hold expiry", "reservation", reservation)
+		}
+	}
+
+	b.logger.LogDebug(RESERVATION, "reservation booked", "reservation", reservation)
+	return reservation, nil
+}
+
+// competes tells if other reservation still needs its tables within a turn of slotAt.
+func (b *reservationBook) competes(other *domain.Reservation, slotAt time.Time) bool {
+	if other.Status != domain.ReservationBooked && other.Status != domain.ReservationArrived {
+		return false
+	}
+	gap := other.SlotAt.Sub(slotAt)
+	return gap > -b.turnTime && gap < b.turnTime
+}
+
+func (b *reservationBook) Cancel(ctx context.Context, id domain.ReservationID) error {
+	reservation, err := b.repo.GetReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation == nil {
+		return domain.ErrReservationNotFound
+	}
+
+	reservation.Status = domain.ReservationCancelled
+	if err := b.repo.UpdateBookedReservation(ctx, reservation); err != nil {
+		return err
+	}
+	b.cancelHoldExpiry(ctx, id)
+	b.logger.LogDebug(RESERVATION, "reservation cancelled", "reservation", reservation)
+	return nil
+}
+
+func (b *reservationBook) GetReservation(ctx context.Context, id domain.ReservationID) (*domain.Reservation, error) {
+	return b.repo.GetReservation(ctx, id)
+}
+
+func (b *reservationBook) GetUpcoming(ctx context.Context, until time.Time) ([]*domain.Reservation, error) {
+	return b.repo.GetReservationsBetween(ctx, time.Now().Add(-b.turnTime), until)
+}
+
+func (b *reservationBook) Arrive(ctx context.Context, id domain.ReservationID, partyID d.PartyID) (*domain.Reservation, bool, error) {
+	reservation, err := b.repo.GetReservation(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if reservation == nil {
+		return nil, false, domain.ErrReservationNotFound
+	}
+
+	now := time.Now()
+	reservation.Status = domain.ReservationArrived
+	reservation.PartyID = partyID
+	reservation.ArrivedAt = now.UTC()
+	if err := b.repo.UpdateBookedReservation(ctx, reservation); err != nil {
+		return nil, false, err
+	}
+	b.cancelHoldExpiry(ctx, id)
+
+	onTime := b.window.OnTime(reservation, now)
+	b.logger.LogDebug(RESERVATION, "reservation arrived", "reservation", reservation, "on time", onTime)
+	return reservation, onTime, nil
+}
+
+func (b *reservationBook) HeldPartySizes(ctx context.Context, area d.SeatingArea, at time.Time) ([]int, error) {
+	reservations, err := b.repo.GetReservationsBetween(ctx, at.Add(-b.window.Grace), at.Add(b.window.Before))
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := []int{}
+	for _, reservation := range reservations {
+		if reservation.Area == area && b.window.Holds(reservation, at) {
+			sizes = append(sizes, reservation.Size)
+		}
+	}
+	return sizes, nil
+}
+
+func (b *reservationBook) Watch(ctx context.Context, onExpired HoldExpiredCallback) error {
+	if b.deadlines == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.poller != nil {
+		b.poller.Stop()
+	}
+	b.poller = deadline.NewPoller(b.logger, b.deadlines, b.pollInterval, HOLD_EXPIRY_CLAIM_BATCH, func(ctx context.Context, id string) error {
+		return b.expireHold(ctx, domain.ReservationID(id), onExpired)
+	}, deadline.WithRetryBackoff(HOLD_EXPIRY_RETRY_BACKOFF, HOLD_EXPIRY_MAX_RETRY_BACKOFF))
+	b.poller.Start(ctx)
+	return nil
+}
+
+func (b *reservationBook) Unwatch(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.poller != nil {
+		b.poller.Stop()
+		b.poller = nil
+	}
+	return nil
+}
+
+// expireHold settles a reservation still booked once its grace window is over as no-show.
+// Reservation which arrived or was cancelled meanwhile is left as is. Expiry is retried until onExpired succeeds,
+// so a reservation already settled as no-show is handed to onExpired again.
+func (b *reservationBook) expireHold(ctx context.Context, id domain.ReservationID, onExpired HoldExpiredCallback) error {
+	reservation, err := b.repo.GetReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation != nil && reservation.Status == domain.ReservationNoShow {
+		return onExpired(ctx, reservation)
+	}
+	if reservation == nil || reservation.Status != domain.ReservationBooked {
+		return nil
+	}
+
+	reservation.Status = domain.ReservationNoShow
+	if err := b.repo.UpdateBookedReservation(ctx, reservation); err != nil {
+		if err == domain.ErrReservationNotBooked {
+			return nil
+		}
+		return err
+	}
+
+	b.logger.LogDebug(RESERVATION, "reservation did not show up, hold expired", "reservation", reservation)
+	return onExpired(ctx, reservation)
+}
+
+func (b *reservationBook) cancelHoldExpiry(ctx context.Context, id domain.ReservationID) {
+	if b.deadlines == nil {
+		return
+	}
+
+	if err := b.deadlines.Cancel(ctx, string(id)); err != nil {
+		b.logger.LogErr(RESERVATION, err, "could not cancel hold expiry", "reservation id", id)
+	}
+}
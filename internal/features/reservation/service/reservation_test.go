@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	hdr "queue-bite/internal/features/hostdesk/repository"
+	hd "queue-bite/internal/features/hostdesk/service"
+	"queue-bite/internal/features/reservation/domain"
+	"queue-bite/internal/features/reservation/repository"
+	dlm "queue-bite/internal/platform/deadline/inmemory"
+)
+
+func TestReservationBook(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNoopLogger()
+	tables, err := hdd.ParseTables("A1:1-2:table,A2:1-2:table,C1:3-4:table")
+	require.NoError(t, err)
+	window := domain.HoldWindow{Before: 15 * time.Minute, Grace: 15 * time.Minute}
+
+	setup := func() (ReservationBook, repository.ReservationRepository) {
+		repo := repository.NewInMemoryReservationRepository(logger)
+		return NewReservationBook(logger, tables, repo, window, 2*time.Hour, nil, time.Second), repo
+	}
+
+	t.Run("party larger than any tables could seat", func(t *testing.T) {
+		book, _ := setup()
+		_, err := book.Book(ctx, "name", 5, d.SeatingAreaTable, time.Now().Add(time.Hour))
+		assert.ErrorIs(t, err, domain.ErrPartyTooLarge)
+
+		_, err = book.Book(ctx, "name", 1, d.SeatingAreaCounter, time.Now().Add(time.Hour))
+		assert.ErrorIs(t, err, domain.ErrPartyTooLarge)
+	})
+
+	t.Run("slot in the past", func(t *testing.T) {
+		book, _ := setup()
+		_, err := book.Book(ctx, "name", 2, d.SeatingAreaTable, time.Now().Add(-time.Minute))
+		assert.ErrorIs(t, err, domain.ErrSlotInPast)
+	})
+
+	t.Run("bookings within a turn compete for tables", func(t *testing.T) {
+		book, _ := setup()
+		slot := time.Now().Add(3 * time.Hour)
+		_, err := book.Book(ctx, "first", 4, d.SeatingAreaTable, slot)
+		require.NoError(t, err)
+		_, err = book.Book(ctx, "second", 2, d.SeatingAreaTable, slot.Add(30*time.Minute))
+		require.NoError(t, err)
+		_, err = book.Book(ctx, "third", 2, d.SeatingAreaTable, slot.Add(-30*time.Minute))
+		require.NoError(t, err)
+
+		_, err = book.Book(ctx, "fourth", 2, d.SeatingAreaTable, slot)
+		assert.ErrorIs(t, err, domain.ErrFullyBooked)
+
+		_, err = book.Book(ctx, "next turn", 4, d.SeatingAreaTable, slot.Add(2*time.Hour))
+		assert.NoError(t, err)
+	})
+
+	t.Run("tables are held around the slot only while booked", func(t *testing.T) {
+		book, _ := setup()
+		slot := time.Now().Add(10 * time.Minute)
+		reservation, err := book.Book(ctx, "name", 3, d.SeatingAreaTable, slot)
+		require.NoError(t, err)
+
+		sizes, err := book.HeldPartySizes(ctx, d.SeatingAreaTable, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, []int{3}, sizes)
+
+		sizes, err = book.HeldPartySizes(ctx, d.SeatingAreaTable, slot.Add(-20*time.Minute))
+		require.NoError(t, err)
+		assert.Empty(t, sizes, "hold starts shortly before the slot")
+
+		sizes, err = book.HeldPartySizes(ctx, d.SeatingAreaCounter, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, sizes)
+
+		require.NoError(t, book.Cancel(ctx, reservation.ID))
+		sizes, err = book.HeldPartySizes(ctx, d.SeatingAreaTable, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, sizes)
+
+		assert.ErrorIs(t, book.Cancel(ctx, reservation.ID), domain.ErrReservationNotBooked)
+	})
+
+	t.Run("held tables are kept out of vacancy", func(t *testing.T) {
+		book, _ := setup()
+		hostdesk := hd.NewInstantServeHostDesk(logger, d.DefaultRestaurantID, tables,
+			hdr.NewInMemoryHostDeskRepository(logger), nil, nil, hd.WithCapacityHolds(book))
+		_, err := book.Book(ctx, "name", 3, d.SeatingAreaTable, time.Now().Add(10*time.Minute))
+		require.NoError(t, err)
+
+		vacancy, err := hostdesk.GetVacancy(ctx, d.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.False(t, vacancy.CanSeat(3), "walk-in could not take held table")
+		assert.True(t, vacancy.CanSeat(2))
+
+		capacity, _, err := hostdesk.GetCurrentCapacity(ctx, d.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 4, capacity)
+
+		total, err := hostdesk.GetTotalCapacity(ctx, d.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 8, total)
+	})
+
+	t.Run("arrival within grace window keeps priority", func(t *testing.T) {
+		book, repo := setup()
+		onTime := domain.NewReservation("on-time", "name", 2, d.SeatingAreaTable, time.Now().Add(-10*time.Minute))
+		late := domain.NewReservation("late", "name", 2, d.SeatingAreaTable, time.Now().Add(-20*time.Minute))
+		require.NoError(t, repo.SaveReservation(ctx, onTime))
+		require.NoError(t, repo.SaveReservation(ctx, late))
+
+		reservation, priority, err := book.Arrive(ctx, "on-time", "party-1")
+		require.NoError(t, err)
+		assert.True(t, priority)
+		assert.Equal(t, domain.ReservationArrived, reservation.Status)
+		assert.Equal(t, d.PartyID("party-1"), reservation.PartyID)
+
+		_, priority, err = book.Arrive(ctx, "late", "party-2")
+		require.NoError(t, err)
+		assert.False(t, priority)
+
+		_, _, err = book.Arrive(ctx, "on-time", "party-3")
+		assert.ErrorIs(t, err, domain.ErrReservationNotBooked)
+
+		_, _, err = book.Arrive(ctx, "unknown", "party-4")
+		assert.ErrorIs(t, err, domain.ErrReservationNotFound)
+	})
+}
+
+// flakyReservationRepository fails the first update of a reservation, as a store briefly unavailable would.
+type flakyReservationRepository struct {
+	repository.ReservationRepository
+	failed atomic.Bool
+}
+
+func (r *flakyReservationRepository) UpdateBookedReservation(ctx context.Context, reservation *domain.Reservation) error {
+	if r.failed.CompareAndSwap(false, true) {
+		return errors.New("store unavailable")
+	}
+	return r.ReservationRepository.UpdateBookedReservation(ctx, reservation)
+}
+
+func TestHoldExpiryRetry(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNoopLogger()
+	tables, err := hdd.ParseTables("A1:1-2:table,C1:3-4:table")
+	require.NoError(t, err)
+	window := domain.HoldWindow{Before: 15 * time.Minute, Grace: 15 * time.Minute}
+
+	backoff := HOLD_EXPIRY_RETRY_BACKOFF
+	HOLD_EXPIRY_RETRY_BACKOFF = 10 * time.Millisecond
+	t.Cleanup(func() { HOLD_EXPIRY_RETRY_BACKOFF = backoff })
+
+	// noShow is booked with its grace window over and its hold expiry due
+	noShow := func(t *testing.T, repo repository.ReservationRepository, deadlines *dlm.InMemoryDeadlineQueue) *domain.Reservation {
+		reservation := domain.NewReservation("no-show", "name", 3, d.SeatingAreaTable, time.Now().Add(-20*time.Minute))
+		require.NoError(t, repo.SaveReservation(ctx, reservation))
+		require.NoError(t, deadlines.Schedule(ctx, string(reservation.ID), window.ExpiresAt(reservation)))
+		return reservation
+	}
+
+	t.Run("hold is released after settling it failed once", func(t *testing.T) {
+		repo := &flakyReservationRepository{ReservationRepository: repository.NewInMemoryReservationRepository(logger)}
+		deadlines := dlm.NewInMemoryDeadlineQueue(logger)
+		book := NewReservationBook(logger, tables, repo, window, 2*time.Hour, deadlines, 5*time.Millisecond)
+		reservation := noShow(t, repo, deadlines)
+
+		var expired atomic.Int32
+		require.NoError(t, book.Watch(ctx, func(ctx context.Context, reservation *domain.Reservation) error {
+			expired.Add(1)
+			return nil
+		}))
+		defer book.Unwatch(ctx)
+
+		assert.Eventually(t, func() bool { return expired.Load() == 1 }, time.Second, 5*time.Millisecond)
+		stored, err := book.GetReservation(ctx, reservation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReservationNoShow, stored.Status)
+		assert.True(t, repo.failed.Load())
+	})
+
+	t.Run("tables are offered again after the callback failed once", func(t *testing.T) {
+		repo := repository.NewInMemoryReservationRepository(logger)
+		deadlines := dlm.NewInMemoryDeadlineQueue(logger)
+		book := NewReservationBook(logger, tables, repo, window, 2*time.Hour, deadlines, 5*time.Millisecond)
+		noShow(t, repo, deadlines)
+
+		var calls, expired atomic.Int32
+		require.NoError(t, book.Watch(ctx, func(ctx context.Context, reservation *domain.Reservation) error {
+			if calls.Add(1) == 1 {
+				return errors.New("could not offer tables")
+			}
+			expired.Add(1)
+			return nil
+		}))
+		defer book.Unwatch(ctx)
+
+		assert.Eventually(t, func() bool { return expired.Load() == 1 }, time.Second, 5*time.Millisecond)
+		book.Unwatch(ctx)
+		claims, err := deadlines.ClaimExpired(ctx, time.Now().Add(time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claims, "expiry is dropped once handled")
+	})
+}
@@ -0,0 +1,11 @@
+package domain
+
+// CheckInTimeoutPolicy decides what happens to a ready party that did not check in on time.
+type CheckInTimeoutPolicy string
+
+const (
+	// CheckInTimeoutSkip sends the party to the back of the waitlist as waiting.
+	CheckInTimeoutSkip CheckInTimeoutPolicy = "skip"
+	// CheckInTimeoutDrop takes the party out of the waitlist.
+	CheckInTimeoutDrop CheckInTimeoutPolicy = "drop"
+)
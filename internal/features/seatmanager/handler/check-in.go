@@ -21,7 +21,7 @@ func (h *seatManagerHandler) HandlePartyCheckIn(
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var partySession domain.PartySession
-		if err := cookieManager.GetCookie(r, cookieQueuedParty, &partySession); err != nil {
+		if err := cookieManager.GetCookie(w, r, cookieQueuedParty, &partySession); err != nil {
 			logger.LogDebug(SEAT_MANAGER_CHECKIN, "could not access session cookie from check-in")
 			redirectToVisitPage(w, r)
 			return
@@ -34,7 +34,7 @@ func (h *seatManagerHandler) HandlePartyCheckIn(
 		}
 
 		logger.LogDebug(SEAT_MANAGER_CHECKIN, "party has just checked-in", "party id", partySession.ID)
-		w.Header().Add("HX-Location", "/yummy")
+		w.Header().Add("HX-Location", d.RestaurantIDFromContext(r.Context()).Path("/yummy"))
 	}
 }
 
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"net/http"
+
+	d "queue-bite/internal/domain"
 )
 
 type seatManagerHandler struct{}
@@ -11,5 +13,5 @@ func NewSeatManagerHandler() *seatManagerHandler {
 }
 
 func redirectToVisitPage(w http.ResponseWriter, r *http.Request) {
-	http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+	http.Redirect(w, r, d.RestaurantIDFromContext(r.Context()).Path("/waitlist"), http.StatusTemporaryRedirect)
 }
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/seatmanager/domain"
+	"queue-bite/internal/features/seatmanager/service"
+	w "queue-bite/internal/features/waitlist/domain"
+	"queue-bite/pkg/session"
+)
+
+var SEAT_MANAGER_LEAVE = "seatmanager/leave"
+
+func (h *seatManagerHandler) HandlePartyLeave(
+	logger log.Logger,
+	seatManager service.SeatManager,
+	cookieManager *session.CookieManager,
+	cookieQueuedParty *session.CookieConfig,
+) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		var partySession domain.PartySession
+		if err := cookieManager.GetCookie(resp, req, cookieQueuedParty, &partySession); err != nil {
+			logger.LogDebug(SEAT_MANAGER_LEAVE, "could not access session cookie from leave")
+			resp.Header().Add("HX-Location", d.RestaurantIDFromContext(req.Context()).Path("/waitlist"))
+			return
+		}
+
+		err := seatManager.PartyLeave(req.Context(), d.PartyID(partySession.ID))
+		if err != nil && err != w.ErrPartyNotFound {
+			logger.LogErr(SEAT_MANAGER_LEAVE, err, "handle party leave failed", "party id", partySession.ID)
+			http.Error(resp, "Failed to leave the waitlist", http.StatusInternalServerError)
+			return
+		}
+
+		logger.LogDebug(SEAT_MANAGER_LEAVE, "party has just left the waitlist", "party id", partySession.ID)
+		cookieManager.ClearCookie(resp, cookieQueuedParty)
+		resp.Header().Add("HX-Location", d.RestaurantIDFromContext(req.Context()).Path("/waitlist"))
+	}
+}
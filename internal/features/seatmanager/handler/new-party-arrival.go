@@ -17,6 +17,7 @@ import (
 	"queue-bite/internal/features/seatmanager/handler/view"
 	"queue-bite/internal/features/seatmanager/service"
 	w "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
 	fm "queue-bite/pkg/form"
 	"queue-bite/pkg/session"
 	"queue-bite/pkg/utils"
@@ -30,15 +31,19 @@ func (*seatManagerHandler) HandleNewPartyArrival(
 	uni *ut.UniversalTranslator,
 	cookieManager *session.CookieManager,
 	cookieQueudParty *session.CookieConfig,
+	streamTokens *session.TokenSigner,
+	recoveryLinks *session.TokenSigner,
 	seatManager service.SeatManager,
+	waitlist ws.Waitlist,
 	hostdesk hd.HostDesk,
 ) http.HandlerFunc {
 	formDecoder := form.NewDecoder()
-	totalCapacity, _ := hostdesk.GetTotalCapacity(context.Background())
+	totalCapacity := seatingCapacity(context.Background(), hostdesk, d.SeatingAreas)
 
 	type NewPartyArrivalRequest struct {
-		PartyName string `validate:"required"`
-		PartySize int    `validate:"required,min=1"`
+		PartyName  string              `validate:"required"`
+		PartySize  int                 `validate:"required,min=1"`
+		Preference d.SeatingPreference `validate:"required,oneof=table counter any"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -48,24 +53,29 @@ func (*seatManagerHandler) HandleNewPartyArrival(
 			return
 		}
 
-		if payload.PartySize > totalCapacity {
+		if capacity := seatingCapacity(r.Context(), hostdesk, payload.Preference.Areas()); payload.PartySize > capacity {
 			formData := view.NewJoinFormData(totalCapacity)
 			fm.CopyFormValueFromPayload(formData, payload)
 			formData.PartySize.Invalid = true
-			formData.PartySize.ErrorMessage = fmt.Sprintf("Sorry, we could only take reservation under people %d right now.", totalCapacity)
+			formData.PartySize.ErrorMessage = fmt.Sprintf("Sorry, we could only take reservation under people %d right now.", capacity)
 			templ.Handler(view.JoinForm(formData)).ServeHTTP(w, r)
 			return
 		}
 
 		party := d.NewParty(d.PartyID(utils.GenerateID()), payload.PartyName, payload.PartySize)
+		party.Preference = payload.Preference
 		queuedParty, err := seatManager.ProcessNewParty(r.Context(), party)
 		if err != nil {
 			handleErrorOnNewPartyArrival(logger, w, r, payload, totalCapacity, err)
 			return
 		}
+		// party could still resume by link if its code is not indexed
+		if err := waitlist.SaveRecoveryCode(r.Context(), queuedParty.ID, recoveryCode(recoveryLinks, queuedParty.ID)); err != nil {
+			logger.LogErr(SEAT_MANAGER_ARRIVAL, err, "could not save recovery code of party", "party_id", queuedParty.ID)
+		}
 
 		setQueuedPartyCookie(w, cookieManager, cookieQueudParty, queuedParty)
-		renderQueuedParty(w, r, queuedParty)
+		renderQueuedParty(w, r, queuedParty, streamTokens, recoveryLinks)
 	}
 }
 
@@ -130,6 +140,9 @@ func setQueuedPartyCookie(w http.ResponseWriter, cookieManager *session.CookieMa
 	cookieManager.SetCookie(w, cookieQueuedParty, session)
 }
 
-func renderQueuedParty(w http.ResponseWriter, r *http.Request, party *w.QueuedParty) {
-	templ.Handler(view.QueuedParty(view.NewQueuedPartyProps(party))).ServeHTTP(w, r)
+func renderQueuedParty(w http.ResponseWriter, r *http.Request, party *w.QueuedParty, streamTokens *session.TokenSigner, recoveryLinks *session.TokenSigner) {
+	props := view.NewQueuedPartyProps(party)
+	props.StreamToken = streamTokens.Sign(string(party.ID))
+	withRecovery(r, props, recoveryLinks)
+	templ.Handler(view.QueuedParty(props)).ServeHTTP(w, r)
 }
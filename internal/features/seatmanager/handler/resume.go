@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/seatmanager/handler/view"
+	w "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
+	"queue-bite/internal/platform/ratelimit"
+	"queue-bite/pkg/session"
+)
+
+var SEAT_MANAGER_RESUME = "seatmanager/resume"
+
+// recoveryCodeLength keeps codes short enough to note down, guesses are bounded by the resume attempt limit.
+const recoveryCodeLength = 6
+
+// HandleResumeLink re-issues the session cookie to whoever opens the signed recovery link of a queued party,
+// otherwise it shows the form to resume by recovery code.
+func (*seatManagerHandler) HandleResumeLink(
+	logger log.Logger,
+	cookieManager *session.CookieManager,
+	cookieQueuedParty *session.CookieConfig,
+	recoveryLinks *session.TokenSigner,
+	waitlist ws.Waitlist,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		formData := view.NewResumeFormData()
+		partyID := d.PartyID(r.URL.Query().Get("party"))
+		if partyID == "" {
+			templ.Handler(view.ResumePage(formData)).ServeHTTP(w, r)
+			return
+		}
+
+		if err := recoveryLinks.Verify(r.URL.Query().Get("token"), resumeSubject(partyID)); err != nil {
+			logger.LogDebug(SEAT_MANAGER_RESUME, "rejected recovery link", "party_id", partyID, "reason", err)
+			formData.ErrorMessage = "This link is no longer valid, please enter your recovery code instead."
+			templ.Handler(view.ResumePage(formData)).ServeHTTP(w, r)
+			return
+		}
+
+		queuedParty, err := waitlist.GetQueuedParty(r.Context(), partyID)
+		if err != nil || queuedParty == nil {
+			logger.LogDebug(SEAT_MANAGER_RESUME, "party of recovery link no longer in queue", "party_id", partyID)
+			formData.ErrorMessage = "This party is no longer in line."
+			templ.Handler(view.ResumePage(formData)).ServeHTTP(w, r)
+			return
+		}
+
+		logger.LogDebug(SEAT_MANAGER_RESUME, "party resumed by recovery link", "party_id", partyID)
+		setQueuedPartyCookie(w, cookieManager, cookieQueuedParty, queuedParty)
+		http.Redirect(w, r, d.RestaurantIDFromContext(r.Context()).Path("/waitlist"), http.StatusSeeOther)
+	}
+}
+
+// HandleResumeCode re-issues the session cookie of the queued party matching the submitted recovery code.
+// Codes are short enough to be guessed, so failed attempts are limited per client and per code guessed.
+// Failed attempts of all clients of the restaurant together only slow every attempt down by backoff once
+// over their limit, so guesses spread over many addresses are bounded without locking diners out.
+// Client address is taken from clientIPHeader when a proxy in front sets it, otherwise from the connection.
+func (*seatManagerHandler) HandleResumeCode(
+	logger log.Logger,
+	cookieManager *session.CookieManager,
+	cookieQueuedParty *session.CookieConfig,
+	streamTokens *session.TokenSigner,
+	recoveryLinks *session.TokenSigner,
+	attempts ratelimit.Limiter,
+	restaurantAttempts ratelimit.Limiter,
+	backoff time.Duration,
+	clientIPHeader string,
+	waitlist ws.Waitlist,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		formData := view.NewResumeFormData()
+		code := normalizeRecoveryCode(r.PostFormValue(formData.Code.Name))
+		formData.Code.Value = code
+
+		client := clientAddr(r, clientIPHeader)
+		restaurantID := d.RestaurantIDFromContext(r.Context())
+		keys := []string{"client:" + client}
+		if len(code) == recoveryCodeLength {
+			keys = append(keys, "code:"+code)
+		}
+
+		reached, err := anyReached(r.Context(), attempts, keys)
+		if err != nil {
+			logger.LogErr(SEAT_MANAGER_RESUME, err, "could not check resume attempts")
+			formData.ErrorMessage = "Failed to resume your place, please try again later."
+			templ.Handler(view.ResumeForm(formData)).ServeHTTP(w, r)
+			return
+		}
+		if reached {
+			logger.LogDebug(SEAT_MANAGER_RESUME, "too many failed resume attempts", "client", client)
+			formData.ErrorMessage = "Too many attempts, please try again in a while."
+			templ.Handler(view.ResumeForm(formData)).ServeHTTP(w, r)
+			return
+		}
+
+		if reached, err := restaurantAttempts.Reached(r.Context(), string(restaurantID)); err == nil && reached {
+			logger.LogDebug(SEAT_MANAGER_RESUME, "slow down resume attempt", "restaurant", restaurantID, "backoff", backoff)
+			select {
+			case <-time.After(backoff):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		queuedParty := findPartyByRecoveryCode(r.Context(), logger, waitlist, code)
+		if queuedParty == nil {
+			recordFailedAttempt(r.Context(), logger, attempts, keys)
+			recordFailedAttempt(r.Context(), logger, restaurantAttempts, []string{string(restaurantID)})
+			formData.Code.Invalid = true
+			formData.Code.ErrorMessage = "No party in line matches this code."
+			templ.Handler(view.ResumeForm(formData)).ServeHTTP(w, r)
+			return
+		}
+
+		logger.LogDebug(SEAT_MANAGER_RESUME, "party resumed by recovery code", "party_id", queuedParty.ID)
+		setQueuedPartyCookie(w, cookieManager, cookieQueuedParty, queuedParty)
+		renderQueuedParty(w, r, queuedParty, streamTokens, recoveryLinks)
+	}
+}
+
+// anyReached tells whether any of keys used up its failed attempts.
+func anyReached(ctx context.Context, attempts ratelimit.Limiter, keys []string) (bool, error) {
+	for _, key := range keys {
+		reached, err := attempts.Reached(ctx, key)
+		if err != nil || reached {
+			return reached, err
+		}
+	}
+	return false, nil
+}
+
+func recordFailedAttempt(ctx context.Context, logger log.Logger, attempts ratelimit.Limiter, keys []string) {
+	for _, key := range keys {
+		if _, err := attempts.Allow(ctx, key); err != nil {
+			logger.LogErr(SEAT_MANAGER_RESUME, err, "could not count failed resume attempt", "key", key)
+		}
+	}
+}
+
+// findPartyByRecoveryCode looks the code up in the index saved when parties joined.
+func findPartyByRecoveryCode(ctx context.Context, logger log.Logger, waitlist ws.Waitlist, code string) *w.QueuedParty {
+	if len(code) != recoveryCodeLength {
+		return nil
+	}
+
+	party, err := waitlist.GetQueuedPartyByRecoveryCode(ctx, code)
+	if err != nil {
+		logger.LogErr(SEAT_MANAGER_RESUME, err, "could not look up party by recovery code")
+		return nil
+	}
+	return party
+}
+
+// withRecovery lets party resume its session on another device, by code or by signed link.
+func withRecovery(r *http.Request, props *view.QueuedPartyProps, recoveryLinks *session.TokenSigner) {
+	query := url.Values{}
+	query.Set("party", string(props.ID))
+	query.Set("token", recoveryLinks.Sign(resumeSubject(props.ID)))
+
+	props.RecoveryCode = recoveryCode(recoveryLinks, props.ID)
+	props.RecoveryLink = d.RestaurantIDFromContext(r.Context()).Path("/waitlist/resume") + "?" + query.Encode()
+}
+
+// recoveryCode derives the code of party from its id, so it is shown the same on every page.
+func recoveryCode(recoveryLinks *session.TokenSigner, partyID d.PartyID) string {
+	return recoveryLinks.Code(string(partyID), recoveryCodeLength)
+}
+
+// resumeSubject keeps recovery links apart from stream tokens signed by the same key for the party id.
+func resumeSubject(partyID d.PartyID) string {
+	return "resume:" + string(partyID)
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+// clientAddr is the address resume attempts are counted against, the last value of clientIPHeader
+// when set by a proxy in front, otherwise the address of the connection.
+func clientAddr(r *http.Request, clientIPHeader string) string {
+	if clientIPHeader != "" {
+		values := strings.Split(r.Header.Get(clientIPHeader), ",")
+		if addr := strings.TrimSpace(values[len(values)-1]); addr != "" {
+			return addr
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/seatmanager/domain"
+	wld "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
+	"queue-bite/pkg/session"
+)
+
+// queuedParties serves the waitlist reads resuming a session relies on.
+type queuedParties struct {
+	ws.Waitlist
+	parties []*wld.QueuedParty
+	codes   map[string]d.PartyID
+}
+
+func (q *queuedParties) GetQueuedParty(ctx context.Context, partyID d.PartyID) (*wld.QueuedParty, error) {
+	for _, party := range q.parties {
+		if party.ID == partyID {
+			return party, nil
+		}
+	}
+	return nil, nil
+}
+
+func (q *queuedParties) GetQueuedPartyByRecoveryCode(ctx context.Context, code string) (*wld.QueuedParty, error) {
+	return q.GetQueuedParty(ctx, q.codes[code])
+}
+
+// limitAttempts allows limit attempts per key.
+type limitAttempts struct {
+	limit    int
+	attempts map[string]int
+}
+
+func (l *limitAttempts) Allow(ctx context.Context, key string) (bool, error) {
+	l.attempts[key]++
+	return l.attempts[key] <= l.limit, nil
+}
+
+func (l *limitAttempts) Reached(ctx context.Context, key string) (bool, error) {
+	return l.attempts[key] >= l.limit, nil
+}
+
+func TestResumePartySession(t *testing.T) {
+	cookieManager, err := session.NewCookieManager("12345678901234567890123456789012")
+	require.NoError(t, err)
+	cookieConfig := session.NewCookieConfig("qb_qp", "localhost")
+	streamTokens, err := session.NewTokenSigner("abcdefghijabcdefghijabcdefghijab", time.Hour)
+	require.NoError(t, err)
+	recoveryLinks, err := session.NewTokenSigner("abcdefghijabcdefghijabcdefghijab", 6*time.Hour)
+	require.NoError(t, err)
+	recoveryLinks.Fixed = time.Now()
+
+	party := &wld.QueuedParty{Party: &d.Party{ID: "party-1", Name: "party-name", Size: 2, Status: d.PartyStatusWaiting}}
+	waitlist := &queuedParties{parties: []*wld.QueuedParty{
+		{Party: &d.Party{ID: "party-2", Name: "other", Size: 4, Status: d.PartyStatusWaiting}},
+		party,
+	}}
+	code := recoveryCode(recoveryLinks, party.ID)
+	waitlist.codes = map[string]d.PartyID{code: party.ID}
+
+	handler := NewSeatManagerHandler()
+	submitCode := func(handle http.HandlerFunc, code string, remoteAddr string, forwardedFor ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/waitlist/resume", strings.NewReader(url.Values{"Code": {code}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(forwardedFor) > 0 {
+			req.Header.Set("X-Forwarded-For", strings.Join(forwardedFor, ", "))
+		}
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handle(w, req)
+		return w
+	}
+	sessionOf := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1, "session cookie is re-issued")
+		req := httptest.NewRequest(http.MethodGet, "/waitlist", nil)
+		req.AddCookie(cookies[0])
+		var partySession domain.PartySession
+		require.NoError(t, cookieManager.GetCookie(httptest.NewRecorder(), req, cookieConfig, &partySession))
+		return string(partySession.ID)
+	}
+
+	t.Run("resume party by its recovery code", func(t *testing.T) {
+		handle := handler.HandleResumeCode(log.NewNoopLogger(), cookieManager, cookieConfig, streamTokens, recoveryLinks,
+			&limitAttempts{limit: 5, attempts: map[string]int{}}, &limitAttempts{limit: 100, attempts: map[string]int{}}, time.Second, "", waitlist)
+
+		w := submitCode(handle, " "+strings.ToLower(code[:3])+"-"+code[3:]+" ", "10.0.0.1:5000")
+		assert.Equal(t, string(party.ID), sessionOf(t, w))
+	})
+
+	t.Run("reject unknown code without session", func(t *testing.T) {
+		handle := handler.HandleResumeCode(log.NewNoopLogger(), cookieManager, cookieConfig, streamTokens, recoveryLinks,
+			&limitAttempts{limit: 5, attempts: map[string]int{}}, &limitAttempts{limit: 100, attempts: map[string]int{}}, time.Second, "", waitlist)
+
+		for _, guess := range []string{"", "ABC", "ZZZZZZ"} {
+			if guess == code {
+				continue
+			}
+			w := submitCode(handle, guess, "10.0.0.1:5000")
+			assert.Empty(t, w.Result().Cookies())
+		}
+	})
+
+	t.Run("reject right code once client is over attempt limit", func(t *testing.T) {
+		handle := handler.HandleResumeCode(log.NewNoopLogger(), cookieManager, cookieConfig, streamTokens, recoveryLinks,
+			&limitAttempts{limit: 2, attempts: map[string]int{}}, &limitAttempts{limit: 100, attempts: map[string]int{}}, time.Second, "", waitlist)
+
+		submitCode(handle, "ZZZZZZ", "10.0.0.1:5000")
+		submitCode(handle, "ZZZZZZ", "10.0.0.1:5001")
+		w := submitCode(handle, code, "10.0.0.1:5002")
+		assert.Empty(t, w.Result().Cookies(), "attempts are counted per address whatever the port")
+
+		w = submitCode(handle, code, "10.0.0.2:5000")
+		assert.Equal(t, string(party.ID), sessionOf(t, w))
+	})
+
+	t.Run("successful attempts are not counted", func(t *testing.T) {
+		handle := handler.HandleResumeCode(log.NewNoopLogger(), cookieManager, cookieConfig, streamTokens, recoveryLinks,
+			&limitAttempts{limit: 1, attempts: map[string]int{}}, &limitAttempts{limit: 1, attempts: map[string]int{}}, time.Second, "", waitlist)
+
+		for i := 0; i < 3; i++ {
+			w := submitCode(handle, code, "10.0.0.1:5000")
+			assert.Equal(t, string(party.ID), sessionOf(t, w))
+		}
+	})
+
+	t.Run("reject guesses of a code from many addresses", func(t *testing.T) {
+		handle := handler.HandleResumeCode(log.NewNoopLogger(), cookieManager, cookieConfig, streamTokens, recoveryLinks,
+			&limitAttempts{limit: 2, attempts: map[string]int{}}, &limitAttempts{limit: 100, attempts: map[string]int{}}, time.Second, "", waitlist)
+
+		submitCode(handle, "ZZZZZZ", "10.0.0.1:5000")
+		submitCode(handle, "ZZZZZZ", "10.0.0.2:5000")
+		w := submitCode(handle, "ZZZZZZ", "10.0.0.3:5000")
+		assert.Contains(t, w.Body.String(), "Too many attempts")
+	})
+
+	t.Run("guesses of other clients only slow the right code down", func(t *testing.T) {
+		backoff := 50 * time.Millisecond
+		handle := handler.HandleResumeCode(log.NewNoopLogger(), cookieManager, cookieConfig, streamTokens, recoveryLinks,
+			&limitAttempts{limit: 5, attempts: map[string]int{}}, &limitAttempts{limit: 2, attempts: map[string]int{}}, backoff, "", waitlist)
+
+		submitCode(handle, "ZZZZZY", "10.0.0.1:5000")
+		submitCode(handle, "ZZZZZX", "10.0.0.2:5000")
+		start := time.Now()
+		w := submitCode(handle, code, "10.0.0.3:5000")
+		assert.Equal(t, string(party.ID), sessionOf(t, w), "a restaurant over its limit does not lock diners out")
+		assert.GreaterOrEqual(t, time.Since(start), backoff)
+	})
+
+	t.Run("count attempts against address set by proxy", func(t *testing.T) {
+		handle := handler.HandleResumeCode(log.NewNoopLogger(), cookieManager, cookieConfig, streamTokens, recoveryLinks,
+			&limitAttempts{limit: 2, attempts: map[string]int{}}, &limitAttempts{limit: 100, attempts: map[string]int{}}, time.Second, "X-Forwarded-For", waitlist)
+
+		// every request comes through the proxy, clients could only prepend values of their own
+		submitCode(handle, "ZZZZZZ", "192.168.0.1:5000", "1.1.1.1", "10.0.0.1")
+		submitCode(handle, "ZZZZZZ", "192.168.0.1:5000", "2.2.2.2", "10.0.0.1")
+		w := submitCode(handle, code, "192.168.0.1:5000", "3.3.3.3", "10.0.0.1")
+		assert.Empty(t, w.Result().Cookies())
+
+		w = submitCode(handle, code, "192.168.0.1:5000", "10.0.0.2")
+		assert.Equal(t, string(party.ID), sessionOf(t, w))
+	})
+
+	t.Run("resume party by its recovery link", func(t *testing.T) {
+		handle := handler.HandleResumeLink(log.NewNoopLogger(), cookieManager, cookieConfig, recoveryLinks, waitlist)
+
+		query := url.Values{"party": {string(party.ID)}, "token": {recoveryLinks.Sign(resumeSubject(party.ID))}}
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest(http.MethodGet, "/waitlist/resume?"+query.Encode(), nil))
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, string(party.ID), sessionOf(t, w))
+	})
+
+	t.Run("reject recovery link not signed for party", func(t *testing.T) {
+		handle := handler.HandleResumeLink(log.NewNoopLogger(), cookieManager, cookieConfig, recoveryLinks, waitlist)
+
+		links := map[string]url.Values{
+			"stream token":        {"party": {string(party.ID)}, "token": {streamTokens.Sign(string(party.ID))}},
+			"link of other party": {"party": {"party-2"}, "token": {recoveryLinks.Sign(resumeSubject(party.ID))}},
+			"missing token":       {"party": {string(party.ID)}},
+		}
+		for name, query := range links {
+			w := httptest.NewRecorder()
+			handle(w, httptest.NewRequest(http.MethodGet, "/waitlist/resume?"+query.Encode(), nil))
+			assert.Equal(t, http.StatusOK, w.Code, name)
+			assert.Empty(t, w.Result().Cookies(), name)
+		}
+	})
+}
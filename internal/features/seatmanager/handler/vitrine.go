@@ -7,6 +7,7 @@ import (
 	"queue-bite/pkg/session"
 
 	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
 	hd "queue-bite/internal/features/hostdesk/service"
 	"queue-bite/internal/features/seatmanager/domain"
 	"queue-bite/internal/features/seatmanager/handler/view"
@@ -28,13 +29,15 @@ func (h *VitrineHandler) HandleVitrineDisplay(
 	logger log.Logger,
 	cookieManager *session.CookieManager,
 	cookieQueuedParty *session.CookieConfig,
+	streamTokens *session.TokenSigner,
+	recoveryLinks *session.TokenSigner,
 	waitlist ws.Waitlist,
 	hostdesk hd.HostDesk,
 ) http.HandlerFunc {
-	totalCapacity, _ := hostdesk.GetTotalCapacity(context.Background())
+	totalCapacity := seatingCapacity(context.Background(), hostdesk, d.SeatingAreas)
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		status, err := waitlist.GetQueueStatus(r.Context())
+		status, err := waitlist.GetQueueStatus(r.Context(), d.SeatingAreaTable)
 		if err != nil {
 			logger.LogErr(VITRINE, err, "failed to fetch queue status")
 			h.renderVisitorView(w, r, status, totalCapacity)
@@ -42,7 +45,7 @@ func (h *VitrineHandler) HandleVitrineDisplay(
 		}
 
 		var partySession domain.PartySession
-		if err := cookieManager.GetCookie(r, cookieQueuedParty, &partySession); err != nil {
+		if err := cookieManager.GetCookie(w, r, cookieQueuedParty, &partySession); err != nil {
 			h.renderVisitorView(w, r, status, totalCapacity)
 			return
 		}
@@ -57,7 +60,7 @@ func (h *VitrineHandler) HandleVitrineDisplay(
 		}
 
 		logger.LogDebug(VITRINE, "rendering queued party view", "party_id", queuedParty.ID, "position", queuedParty.Position)
-		h.renderQueuedPartyView(w, r, queuedParty, status, totalCapacity)
+		h.renderQueuedPartyView(w, r, queuedParty, status, totalCapacity, streamTokens, recoveryLinks)
 	}
 }
 
@@ -77,7 +80,22 @@ func (h *VitrineHandler) renderQueuedPartyView(
 	party *w.QueuedParty,
 	status *w.QueueStatus,
 	totalCapacity int,
+	streamTokens *session.TokenSigner,
+	recoveryLinks *session.TokenSigner,
 ) {
 	props := view.ToVitrineProps(party, status, totalCapacity)
+	props.QueuedPartyProps.StreamToken = streamTokens.Sign(string(party.ID))
+	withRecovery(r, props.QueuedPartyProps, recoveryLinks)
 	templ.Handler(view.VitrinePage(props)).ServeHTTP(w, r)
 }
+
+// seatingCapacity is the largest party any of areas could seat.
+func seatingCapacity(ctx context.Context, hostdesk hd.HostDesk, areas []d.SeatingArea) int {
+	capacity := 0
+	for _, area := range areas {
+		if size, err := hostdesk.GetMaxPartySize(ctx, area); err == nil && size > capacity {
+			capacity = size
+		}
+	}
+	return capacity
+}
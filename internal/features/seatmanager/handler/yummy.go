@@ -19,7 +19,7 @@ func (h *seatManagerHandler) HandleServingDisplay(
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var partySession domain.PartySession
-		if err := cookieManager.GetCookie(r, cookieQueuedParty, &partySession); err != nil {
+		if err := cookieManager.GetCookie(w, r, cookieQueuedParty, &partySession); err != nil {
 			logger.LogDebug(SEAT_MANAGER_CHECKIN, "could not access session cookie from yummy")
 			redirectToVisitPage(w, r)
 			return
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	w "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
+)
+
+// BestFitSeatingStrategy offers vacancy to the party leaving fewest seats unused,
+// looking only at the first window waiting parties so that late comers cannot jump far ahead.
+// Ties go to the party which joined earlier.
+type BestFitSeatingStrategy struct {
+	waitlist ws.QueuedPartyProvider
+	window   int
+}
+
+func NewBestFitSeatingStrategy(waitlist ws.QueuedPartyProvider, window int) PartySelectionStrategy {
+	return &BestFitSeatingStrategy{
+		waitlist: waitlist,
+		window:   window,
+	}
+}
+
+func (s *BestFitSeatingStrategy) EvaluateNextParty(ctx context.Context, vacancy *hdd.SeatingVacancy) (*w.QueuedParty, error) {
+	parties, err := waitingParties(ctx, s.waitlist, vacancy.Area, s.window)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *w.QueuedParty
+	for _, party := range parties {
+		if !vacancy.CanSeat(party.Size) {
+			continue
+		}
+		if best == nil || party.Size > best.Size {
+			best = party
+		}
+	}
+	return best, nil
+}
+
+// waitingParties collects up to limit waiting parties of the area queue in queue order, no limit if limit <= 0.
+func waitingParties(ctx context.Context, waitlist ws.QueuedPartyProvider, area d.SeatingArea, limit int) ([]*w.QueuedParty, error) {
+	// stop streaming the rest of queue once enough parties are collected
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queuedParties, err := waitlist.GetQueuedParties(ctx, area)
+	if err != nil {
+		return nil, err
+	}
+
+	parties := []*w.QueuedParty{}
+	for party := range queuedParties {
+		if party.Status != d.PartyStatusWaiting {
+			continue
+		}
+		parties = append(parties, party)
+		if limit > 0 && len(parties) >= limit {
+			break
+		}
+	}
+	return parties, nil
+}
@@ -2,22 +2,94 @@ package service
 
 import (
 	"context"
+
+	d "queue-bite/internal/domain"
+	hsd "queue-bite/internal/features/history/domain"
 	hdd "queue-bite/internal/features/hostdesk/domain"
+	rsd "queue-bite/internal/features/reservation/domain"
+	"queue-bite/internal/features/seatmanager/domain"
+	w "queue-bite/internal/features/waitlist/domain"
 	"queue-bite/internal/platform/eventbus"
 )
 
 func (m *seatManager) handleSeatPreservedEvent(ctx context.Context, event eventbus.Event) error {
 	e := event.(*hdd.SeatsPreservedEvent)
+	if e.RestaurantID != m.restaurantID {
+		return nil
+	}
 
 	if err := m.waitlist.HandlePartyReady(ctx, e.PartyID); err != nil {
 		m.logger.LogErr(SEAT_MANAGER, err, "failed to make party ready", "event", e)
 		return err
 	}
+	if party, err := m.waitlist.GetQueuedParty(ctx, e.PartyID); err == nil && party != nil {
+		m.recordHistory(ctx, party.Party, hsd.PartyReady)
+	}
 
+	m.scheduleCheckInDeadline(ctx, e.PartyID)
+	m.notifyHostDesk(ctx)
 	return nil
 }
 
 func (m *seatManager) handlePartyServiceCompleted(ctx context.Context, event eventbus.Event) error {
+	e := event.(*hdd.PartyServiceCompeletedEvent)
+	if e.RestaurantID != m.restaurantID {
+		return nil
+	}
+	if m.history != nil {
+		if err := m.history.Record(ctx, e.PartyID, e.PartySize, hsd.PartyCompleted, e.CompletedAt); err != nil {
+			m.logger.LogErr(SEAT_MANAGER, err, "could not record party history", "event", e)
+		}
+	}
+	m.notifyHostDesk(ctx)
 	m.checkAndAssignSeating(ctx)
 	return nil
 }
+
+// handleCheckInExpired is called on the single instance which claimed the check-in deadline of party.
+// Party checked in or removed in the meantime holds no preserved seats anymore and is left as is.
+func (m *seatManager) handleCheckInExpired(ctx context.Context, id string) error {
+	partyID := d.PartyID(id)
+	party, err := m.waitlist.GetQueuedParty(ctx, partyID)
+	if err != nil {
+		return err
+	}
+
+	released, err := m.hostdesk.ReleasePreservedSeats(ctx, partyID)
+	if err != nil {
+		return err
+	}
+	if !released || party == nil {
+		m.logger.LogDebug(SEAT_MANAGER, "check-in deadline expired for party without preserved seats", "party id", partyID)
+		return nil
+	}
+	m.recordHistory(ctx, party.Party, hsd.PartyExpired)
+
+	switch m.checkInPolicy {
+	case domain.CheckInTimeoutDrop:
+		if err := m.waitlist.LeaveQueue(ctx, partyID); err != nil && err != w.ErrPartyNotFound {
+			m.logger.LogErr(SEAT_MANAGER, err, "could not drop party missed check-in", "party", party)
+			return err
+		}
+		m.logger.LogDebug(SEAT_MANAGER, "party dropped for missing check-in", "party", party)
+	default:
+		skipped, err := m.waitlist.SendToBack(ctx, partyID)
+		if err != nil {
+			m.logger.LogErr(SEAT_MANAGER, err, "could not skip party missed check-in", "party", party)
+			return err
+		}
+		m.logger.LogDebug(SEAT_MANAGER, "party skipped for missing check-in", "party", skipped)
+	}
+
+	m.notifyHostDesk(ctx)
+	m.notifyPartiesBehind(party)
+	return m.checkAndAssignSeating(ctx)
+}
+
+// handleReservationHoldExpired is called on the single instance which claimed the hold expiry of a reservation
+// whose party did not show up, its tables are free again and offered to the waitlist.
+func (m *seatManager) handleReservationHoldExpired(ctx context.Context, reservation *rsd.Reservation) error {
+	m.logger.LogDebug(SEAT_MANAGER, "reservation hold expired", "reservation", reservation)
+	m.notifyHostDesk(ctx)
+	return m.checkAndAssignSeating(ctx)
+}
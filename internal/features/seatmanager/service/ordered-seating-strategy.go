@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
 	w "queue-bite/internal/features/waitlist/domain"
 	ws "queue-bite/internal/features/waitlist/service"
 )
@@ -17,14 +19,29 @@ func NewOrderedSeatingStrategy(waitlist ws.QueuedPartyProvider) PartySelectionSt
 	}
 }
 
-func (s *OrderedSeatingStrategy) EvaluateNextParty(ctx context.Context, vacancySeats int) (*w.QueuedParty, error) {
-	queuedParties, err := s.waitlist.GetQueuedParties(ctx)
+func (s *OrderedSeatingStrategy) EvaluateNextParty(ctx context.Context, vacancy *hdd.SeatingVacancy) (*w.QueuedParty, error) {
+	return findFirstFit(ctx, s.waitlist, vacancy.Area, vacancy, func(*w.QueuedParty) bool { return true })
+}
+
+// findFirstFit walks the area queue in order for the first waiting party which could be seated at vacancy and is accepted.
+func findFirstFit(
+	ctx context.Context,
+	waitlist ws.QueuedPartyProvider,
+	area d.SeatingArea,
+	vacancy *hdd.SeatingVacancy,
+	accept func(*w.QueuedParty) bool,
+) (*w.QueuedParty, error) {
+	// stop streaming the rest of queue once a party is found
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queuedParties, err := waitlist.GetQueuedParties(ctx, area)
 	if err != nil {
 		return nil, err
 	}
 
 	for party := range queuedParties {
-		if party.Size < vacancySeats {
+		if party.Status == d.PartyStatusWaiting && vacancy.CanSeat(party.Size) && accept(party) {
 			return party, nil
 		}
 	}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	w "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
+)
+
+// PreferenceSeatingStrategy seats parties of the area queue in order like OrderedSeatingStrategy.
+// When nobody in that queue fits, vacancy is offered to parties waiting in other queues which accept the area,
+// e.g. a counter seat goes to a party without preference waiting because tables are full.
+type PreferenceSeatingStrategy struct {
+	waitlist ws.QueuedPartyProvider
+}
+
+func NewPreferenceSeatingStrategy(waitlist ws.QueuedPartyProvider) PartySelectionStrategy {
+	return &PreferenceSeatingStrategy{
+		waitlist: waitlist,
+	}
+}
+
+func (s *PreferenceSeatingStrategy) EvaluateNextParty(ctx context.Context, vacancy *hdd.SeatingVacancy) (*w.QueuedParty, error) {
+	area := vacancy.Area
+	party, err := findFirstFit(ctx, s.waitlist, area, vacancy, func(*w.QueuedParty) bool { return true })
+	if err != nil || party != nil {
+		return party, err
+	}
+
+	acceptsArea := func(party *w.QueuedParty) bool { return party.Preference.Accepts(area) }
+	for _, other := range d.SeatingAreas {
+		if other == area {
+			continue
+		}
+
+		party, err := findFirstFit(ctx, s.waitlist, other, vacancy, acceptsArea)
+		if err != nil || party != nil {
+			return party, err
+		}
+	}
+
+	return nil, nil
+}
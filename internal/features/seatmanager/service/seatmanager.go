@@ -4,21 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	log "queue-bite/internal/config/logger"
 	d "queue-bite/internal/domain"
+	hsd "queue-bite/internal/features/history/domain"
+	history "queue-bite/internal/features/history/service"
 	hdd "queue-bite/internal/features/hostdesk/domain"
 	hostdesk "queue-bite/internal/features/hostdesk/service"
+	rsd "queue-bite/internal/features/reservation/domain"
+	reservation "queue-bite/internal/features/reservation/service"
 	"queue-bite/internal/features/seatmanager/domain"
 	"queue-bite/internal/features/sse"
 	w "queue-bite/internal/features/waitlist/domain"
 	waitlist "queue-bite/internal/features/waitlist/service"
+	"queue-bite/internal/platform/deadline"
 	"queue-bite/internal/platform/eventbus"
+	"queue-bite/internal/platform/metrics"
+	"queue-bite/pkg/utils"
 
 	"github.com/jinzhu/copier"
 )
 
 var SEAT_MANAGER = "seatmanager"
+var CHECK_IN_CLAIM_BATCH = 10
 
 type SeatManager interface {
 	WatchSeatVacancy(ctx context.Context) error
@@ -29,34 +38,126 @@ type SeatManager interface {
 	ProcessNewParty(ctx context.Context, party *d.Party) (*w.QueuedParty, error)
 	// PartyCheckIn handles party check-in process and triggers queue updates.
 	PartyCheckIn(ctx context.Context, partyID d.PartyID) error
+
+	// PartyLeave lets party give up its spot in the waitlist by itself,
+	// releasing any seats preserved for it.
+	PartyLeave(ctx context.Context, partyID d.PartyID) error
+	// RemoveParty takes party out of the waitlist on behalf of the host,
+	// releasing any seats preserved for it.
+	RemoveParty(ctx context.Context, partyID d.PartyID) error
+	// ReadyParty lets the host call a waiting party to be seated regardless of queue order.
+	ReadyParty(ctx context.Context, partyID d.PartyID) error
+	// PartyServiceComplete ends service of a seated party when its table actually leaves.
+	PartyServiceComplete(ctx context.Context, partyID d.PartyID) error
+
+	// ProcessReservationArrival queues the party of a reservation which just arrived, it checks in through PartyCheckIn.
+	// Party arriving within the grace window is seated ahead of walk-ins, a later one is processed as a walk-in.
+	ProcessReservationArrival(ctx context.Context, id rsd.ReservationID) (*w.QueuedParty, error)
+	// CancelReservation gives up a reservation and offers tables held for it to the waitlist.
+	CancelReservation(ctx context.Context, id rsd.ReservationID) error
 }
 
 type PartySelectionStrategy interface {
-	EvaluateNextParty(ctx context.Context, vacancySeats int) (*w.QueuedParty, error)
+	// EvaluateNextParty picks the party to offer free tables of vacancy to, nil if nobody could be seated.
+	EvaluateNextParty(ctx context.Context, vacancy *hdd.SeatingVacancy) (*w.QueuedParty, error)
 }
 
 type seatManager struct {
-	logger     log.Logger
-	eventbus   eventbus.EventBus
-	waitlist   waitlist.Waitlist
-	hostdesk   hostdesk.HostDesk
-	processing PartyProcessingStrategy
-	selection  PartySelectionStrategy
+	logger       log.Logger
+	restaurantID d.RestaurantID
+	eventbus     eventbus.EventBus
+	waitlist     waitlist.Waitlist
+	hostdesk     hostdesk.HostDesk
+	processing   PartyProcessingStrategy
+	selection    PartySelectionStrategy
+	subscribed   []eventbus.SubscriptionID
 
 	preserveMaxRetries int
+
+	checkInDeadlines deadline.Queue
+	checkInPoller    *deadline.Poller
+	checkInTimeout   time.Duration
+	checkInPolicy    domain.CheckInTimeoutPolicy
+
+	maxSkips     int
+	overdueLimit time.Duration
+
+	reservations reservation.ReservationBook
+	history      history.PartyHistory
+
+	lockRetries   *metrics.CounterVec
+	lockExhausted *metrics.CounterVec
+}
+
+type SeatManagerOption func(*seatManager)
+
+// WithCheckInTimeout gives a ready party timeout to check in, counted from the moment its seats are preserved.
+// Deadlines live in the shared queue and are claimed by a single instance, which releases the seats,
+// applies policy to the party and offers the seats to the next party in line.
+func WithCheckInTimeout(
+	deadlines deadline.Queue,
+	timeout time.Duration,
+	pollInterval time.Duration,
+	policy domain.CheckInTimeoutPolicy,
+) SeatManagerOption {
+	return func(m *seatManager) {
+		m.checkInDeadlines = deadlines
+		m.checkInTimeout = timeout
+		m.checkInPolicy = policy
+		m.checkInPoller = deadline.NewPoller(m.logger, deadlines, pollInterval, CHECK_IN_CLAIM_BATCH, m.handleCheckInExpired)
+	}
+}
+
+// WithStarvationGuard keeps parties from being passed over forever by parties behind them.
+// Once the longest waiting party was skipped maxSkips times or waited overdueLimit past its estimated end of service,
+// vacancy of its area is held for it instead of being handed out. Zero disables either threshold.
+func WithStarvationGuard(maxSkips int, overdueLimit time.Duration) SeatManagerOption {
+	return func(m *seatManager) {
+		m.maxSkips = maxSkips
+		m.overdueLimit = overdueLimit
+	}
+}
+
+// WithReservations lets parties arrive for their reservations, tables held for reservations which
+// did not show up are offered to the waitlist once the hold expires.
+func WithReservations(reservations reservation.ReservationBook) SeatManagerOption {
+	return func(m *seatManager) {
+		m.reservations = reservations
+	}
+}
+
+// WithHistory records lifecycle of every party to history, from joining to leaving the restaurant.
+func WithHistory(history history.PartyHistory) SeatManagerOption {
+	return func(m *seatManager) {
+		m.history = history
+	}
+}
+
+// WithMetrics counts optimistic lock retries of seat preservation when new parties arrive,
+// and arrivals which gave up after too many retries.
+func WithMetrics(registry *metrics.Registry) SeatManagerOption {
+	return func(m *seatManager) {
+		m.lockRetries = registry.Counter("queuebite_optimistic_lock_retries_total",
+			"Seat preservations retried on version mismatch while processing new parties.", "restaurant")
+		m.lockExhausted = registry.Counter("queuebite_optimistic_lock_retries_exhausted_total",
+			"New parties failed with too many optimistic lock retries.", "restaurant")
+	}
 }
 
 func NewSeatManager(
 	logger log.Logger,
+	restaurantID d.RestaurantID,
 	eventbus eventbus.EventBus,
 	waitlist waitlist.Waitlist,
 	hostdesk hostdesk.HostDesk,
 	processing PartyProcessingStrategy,
 	selection PartySelectionStrategy,
 	preserveMaxRetries int,
+	opts ...SeatManagerOption,
 ) SeatManager {
-	return &seatManager{
+	m := &seatManager{
 		logger:             logger,
+		restaurantID:       restaurantID,
 		eventbus:           eventbus,
 		waitlist:           waitlist,
 		hostdesk:           hostdesk,
@@ -64,22 +165,57 @@ func NewSeatManager(
 		selection:          selection,
 		preserveMaxRetries: preserveMaxRetries,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (m *seatManager) WatchSeatVacancy(ctx context.Context) error {
-	m.eventbus.Subscribe(hdd.TopicPartyPreserved, m.handleSeatPreservedEvent)
-	m.eventbus.Subscribe(hdd.TopicPartyServiceCompleted, m.handlePartyServiceCompleted)
+	// seats are assigned once per event, by whichever instance gets it
+	group := eventbus.WithCompetingConsumers(SEAT_MANAGER + ":" + string(m.restaurantID))
+	handlers := map[string]eventbus.Handler{
+		hdd.TopicPartyPreserved:        m.handleSeatPreservedEvent,
+		hdd.TopicPartyServiceCompleted: m.handlePartyServiceCompleted,
+	}
+	for topic, handler := range handlers {
+		id, err := m.eventbus.Subscribe(topic, handler, group)
+		if err != nil {
+			return err
+		}
+		m.subscribed = append(m.subscribed, id)
+	}
+	if m.checkInPoller != nil {
+		m.checkInPoller.Start(ctx)
+	}
+	if m.reservations != nil {
+		if err := m.reservations.Watch(ctx, m.handleReservationHoldExpired); err != nil {
+			m.logger.LogErr(SEAT_MANAGER, err, "could not watch reservation holds")
+		}
+	}
 	return nil
 }
 
 func (m *seatManager) UnwatchSeatVacancy(ctx context.Context) error {
 	m.logger.LogDebug(SEAT_MANAGER, "Seat manager stop observing")
+	for _, id := range m.subscribed {
+		if err := m.eventbus.Unsubscribe(id); err != nil {
+			m.logger.LogErr(SEAT_MANAGER, err, "could not unsubscribe from seat vacancy events", "topic", id.Topic)
+		}
+	}
+	m.subscribed = nil
+	if m.checkInPoller != nil {
+		m.checkInPoller.Stop()
+	}
+	if m.reservations != nil {
+		return m.reservations.Unwatch(ctx)
+	}
 	return nil
 }
 
 // ProcessNewParty handles party arrival using optimistic locking for seat preservation.
 // Flow:
-//  1. Get current capacity and queue status
+//  1. Get current capacity and queue status of the areas party accepts
 //  2. Determine party state based on strategy (waiting/ready/serving)
 //  3. Try to preserve seats if strategy allows
 //  4. Either start service immediately or add to queue
@@ -88,24 +224,11 @@ func (m *seatManager) UnwatchSeatVacancy(ctx context.Context) error {
 // Cleanup: Releases preserved seats if operation fails after preservation
 func (m *seatManager) ProcessNewParty(ctx context.Context, party *d.Party) (*w.QueuedParty, error) {
 	for retries := 0; retries < m.preserveMaxRetries; retries++ {
-		capacity, version, err := m.hostdesk.GetCurrentCapacity(ctx)
-		if err != nil {
-			m.logger.LogErr(SEAT_MANAGER, err, "failed to get current capacity")
-			return nil, err
-		}
-
-		queueStatus, err := m.waitlist.GetQueueStatus(ctx)
+		seating, err := m.evaluateSeating(ctx, party)
 		if err != nil {
-			m.logger.LogErr(SEAT_MANAGER, err, "failed to get current waitlist status")
 			return nil, err
 		}
-
-		seatingCtx := &SeatingContext{
-			SeatsAvailable: capacity >= party.Size,
-			QueueStatus:    queueStatus,
-		}
-		newPartyStatus, shouldPreserve := m.processing.DeterminePartyState(ctx, seatingCtx)
-		m.logger.LogDebug(SEAT_MANAGER, "determine new party should wait or serve", "seating ctx", seatingCtx, "new party stats", newPartyStatus, "should preserve", shouldPreserve)
+		newPartyStatus, shouldPreserve := seating.status, seating.shouldPreserve
 
 		var needReleaseSeats bool
 		defer func() {
@@ -118,10 +241,11 @@ func (m *seatManager) ProcessNewParty(ctx context.Context, party *d.Party) (*w.Q
 		}()
 
 		if shouldPreserve {
-			ok, err := m.hostdesk.PreserveSeats(ctx, party.ID, party.Size, version)
+			ok, err := m.hostdesk.PreserveSeats(ctx, party.ID, party.Size, seating.area, seating.version)
 			if err != nil {
 				m.logger.LogErr(SEAT_MANAGER, err, "failed preserve seats on processing new party", "retry", retries)
 				if errors.Is(err, d.ErrVersionMismatch) {
+					m.lockRetries.Inc(string(m.restaurantID))
 					continue
 				}
 				return nil, domain.ErrPreserveSeats
@@ -138,6 +262,9 @@ func (m *seatManager) ProcessNewParty(ctx context.Context, party *d.Party) (*w.Q
 			copier.Copy(queuedParty, party)
 			if err := m.hostdesk.CheckIn(ctx, queuedParty); err == nil {
 				m.logger.LogDebug(SEAT_MANAGER, "start serving immediately", "party", queuedParty)
+				m.recordHistory(ctx, party, hsd.PartyJoined)
+				m.recordHistory(ctx, party, hsd.PartyCheckedIn)
+				m.notifyHostDesk(ctx)
 				return queuedParty, nil
 			}
 			m.logger.LogErr(SEAT_MANAGER, err, "could not check in immediately when new party joins, fallback to waitlist queue as ready")
@@ -154,12 +281,61 @@ func (m *seatManager) ProcessNewParty(ctx context.Context, party *d.Party) (*w.Q
 		}
 
 		m.logger.LogDebug(SEAT_MANAGER, "party will join waitlist queue", "status", party.Status, "party", queuedParty)
+		m.recordHistory(ctx, party, hsd.PartyJoined)
+		if party.Status == d.PartyStatusReady {
+			m.recordHistory(ctx, party, hsd.PartyReady)
+			m.scheduleCheckInDeadline(ctx, party.ID)
+		}
+		m.notifyHostDesk(ctx)
 		return queuedParty, nil
 	}
 
+	m.lockExhausted.Inc(string(m.restaurantID))
 	return nil, d.ErrTooManyOptimisticLockRetries
 }
 
+type seatingDecision struct {
+	area           d.SeatingArea
+	version        d.Version
+	status         d.PartyStatus
+	shouldPreserve bool
+}
+
+// evaluateSeating goes through the areas party accepts in order of preference and picks
+// the first one where strategy lets party in, otherwise party waits for its most preferred area.
+func (m *seatManager) evaluateSeating(ctx context.Context, party *d.Party) (*seatingDecision, error) {
+	var decision *seatingDecision
+	for _, area := range party.Preference.Areas() {
+		vacancy, err := m.hostdesk.GetVacancy(ctx, area)
+		if err != nil {
+			m.logger.LogErr(SEAT_MANAGER, err, "failed to get current vacancy", "area", area)
+			return nil, err
+		}
+
+		queueStatus, err := m.waitlist.GetQueueStatus(ctx, area)
+		if err != nil {
+			m.logger.LogErr(SEAT_MANAGER, err, "failed to get current waitlist status", "area", area)
+			return nil, err
+		}
+
+		seatingCtx := &SeatingContext{
+			SeatsAvailable: vacancy.CanSeat(party.Size),
+			QueueStatus:    queueStatus,
+		}
+		status, shouldPreserve := m.processing.DeterminePartyState(ctx, seatingCtx)
+		m.logger.LogDebug(SEAT_MANAGER, "determine new party should wait or serve", "area", area, "seating ctx", seatingCtx, "new party stats", status, "should preserve", shouldPreserve)
+
+		current := &seatingDecision{area: area, version: vacancy.Version, status: status, shouldPreserve: shouldPreserve}
+		if shouldPreserve {
+			return current, nil
+		}
+		if decision == nil {
+			decision = current
+		}
+	}
+	return decision, nil
+}
+
 // PartyCheckIn transitions party from queue to service.
 // Flow:
 //  1. Verify party exists in queue
@@ -188,51 +364,346 @@ func (m *seatManager) PartyCheckIn(ctx context.Context, partyID d.PartyID) error
 		return err
 	}
 	m.logger.LogDebug(SEAT_MANAGER, "party check in", "party", party)
+	m.recordHistory(ctx, party.Party, hsd.PartyCheckedIn)
+	m.cancelCheckInDeadline(ctx, party.ID)
+	m.notifyHostDesk(ctx)
+	m.notifyPartiesBehind(party)
+	return nil
+}
+
+// PartyLeave drops party from the waitlist on its own request.
+// Seats preserved for a ready party are given back and offered to the next party in line.
+func (m *seatManager) PartyLeave(ctx context.Context, partyID d.PartyID) error {
+	if err := m.dropParty(ctx, partyID); err != nil {
+		return err
+	}
+	m.logger.LogDebug(SEAT_MANAGER, "party left waitlist", "party id", partyID)
+	return m.checkAndAssignSeating(ctx)
+}
+
+// RemoveParty drops party from the waitlist by host decision.
+// Seats preserved for a ready party are given back and offered to the next party in line.
+func (m *seatManager) RemoveParty(ctx context.Context, partyID d.PartyID) error {
+	if err := m.dropParty(ctx, partyID); err != nil {
+		return err
+	}
+	m.logger.LogDebug(SEAT_MANAGER, "party removed from waitlist", "party id", partyID)
+	return m.checkAndAssignSeating(ctx)
+}
+
+// ReadyParty preserves seats for a waiting party picked by the host.
+// The party turns ready once the seats preserved event is handled.
+func (m *seatManager) ReadyParty(ctx context.Context, partyID d.PartyID) error {
+	party, err := m.waitlist.GetQueuedParty(ctx, partyID)
+	if err != nil {
+		return err
+	}
+	if party == nil {
+		return w.ErrPartyNotFound
+	}
+	if party.Status != d.PartyStatusWaiting {
+		return w.ErrInvalidPartyStatusTransition
+	}
+
+	area := party.Preference.QueueArea()
+	for _, accepted := range party.Preference.Areas() {
+		if vacancy, err := m.hostdesk.GetVacancy(ctx, accepted); err == nil && vacancy.CanSeat(party.Size) {
+			area = accepted
+			break
+		}
+	}
+
+	if err := m.hostdesk.NotifyPartyReady(ctx, party, area); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "failed to make party ready by host", "party", party)
+		return err
+	}
+	m.logger.LogDebug(SEAT_MANAGER, "party called by host", "party", party)
+	return nil
+}
+
+// PartyServiceComplete frees seats of a seated party on staff request instead of waiting for the service timer.
+// Pending service timer is cancelled by the host desk, and the published service completed event
+// lets seat manager offer the freed seats to the next party.
+func (m *seatManager) PartyServiceComplete(ctx context.Context, partyID d.PartyID) error {
+	party := &w.QueuedParty{Party: &d.Party{ID: partyID}}
+	if err := m.hostdesk.ServiceComplete(ctx, party); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "failed to complete party service", "party id", partyID)
+		return err
+	}
+
+	m.logger.LogDebug(SEAT_MANAGER, "party service completed by staff", "party id", partyID)
+	return nil
+}
+
+// ProcessReservationArrival settles reservation as arrived, which gives back its held tables,
+// and preserves tables for the party right away if free tables fit it.
+// Otherwise party waits in line and the next vacancy fitting it is held for it ahead of walk-ins.
+func (m *seatManager) ProcessReservationArrival(ctx context.Context, id rsd.ReservationID) (*w.QueuedParty, error) {
+	if m.reservations == nil {
+		return nil, rsd.ErrReservationNotFound
+	}
+
+	partyID := d.PartyID(utils.GenerateID())
+	reservation, onTime, err := m.reservations.Arrive(ctx, id, partyID)
+	if err != nil {
+		return nil, err
+	}
+
+	party := d.NewParty(partyID, reservation.Name, reservation.Size)
+	party.Preference = d.SeatingPreference(reservation.Area)
+	if !onTime {
+		m.logger.LogDebug(SEAT_MANAGER, "reservation arrived past grace window, queue as walk-in", "reservation", reservation)
+		return m.ProcessNewParty(ctx, party)
+	}
+
+	party.ReservationID = string(reservation.ID)
+	party.Status = d.PartyStatusWaiting
+	ok, err := m.hostdesk.PreserveSeats(ctx, party.ID, party.Size, reservation.Area, hostdesk.SKIP_VERSION_CHECK)
+	if err != nil && !errors.Is(err, hdd.ErrInsufficientCapacity) {
+		m.logger.LogErr(SEAT_MANAGER, err, "failed preserve seats on reservation arrival", "reservation", reservation)
+		return nil, domain.ErrPreserveSeats
+	}
+	if ok {
+		party.Status = d.PartyStatusReady
+	}
+
+	queuedParty, err := m.waitlist.JoinQueue(ctx, party)
+	if err != nil {
+		if ok {
+			if _, err := m.hostdesk.ReleasePreservedSeats(ctx, party.ID); err != nil {
+				m.logger.LogErr(SEAT_MANAGER, err, "failed release preserved seats on reservation arrival")
+			}
+		}
+		return nil, domain.ErrJoinWaitlist
+	}
+
+	m.logger.LogDebug(SEAT_MANAGER, "reserved party joins waitlist", "status", party.Status, "party", queuedParty)
+	m.recordHistory(ctx, party, hsd.PartyJoined)
+	if ok {
+		m.recordHistory(ctx, party, hsd.PartyReady)
+		m.scheduleCheckInDeadline(ctx, party.ID)
+	}
+	m.notifyHostDesk(ctx)
+	return queuedParty, nil
+}
+
+func (m *seatManager) CancelReservation(ctx context.Context, id rsd.ReservationID) error {
+	if m.reservations == nil {
+		return rsd.ErrReservationNotFound
+	}
+
+	if err := m.reservations.Cancel(ctx, id); err != nil {
+		return err
+	}
+	m.logger.LogDebug(SEAT_MANAGER, "reservation cancelled", "reservation id", id)
+	m.notifyHostDesk(ctx)
+	return m.checkAndAssignSeating(ctx)
+}
+
+// dropParty takes party out of the waitlist and gives back its preserved seats,
+// then lets the host desk and the parties queued behind know about it.
+func (m *seatManager) dropParty(ctx context.Context, partyID d.PartyID) error {
+	party, err := m.waitlist.GetQueuedParty(ctx, partyID)
+	if err != nil {
+		return err
+	}
+	if party == nil {
+		return w.ErrPartyNotFound
+	}
+
+	if err := m.waitlist.LeaveQueue(ctx, partyID); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "leave queue failed", "party", party)
+		return err
+	}
+	m.cancelCheckInDeadline(ctx, partyID)
+
+	// party could have seats preserved before its status turns ready
+	if _, err := m.hostdesk.ReleasePreservedSeats(ctx, partyID); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "failed to release preserved seats of dropped party", "party", party)
+	}
+
+	m.recordHistory(ctx, party.Party, hsd.PartyLeft)
+	m.notifyHostDesk(ctx)
+	m.notifyPartiesBehind(party)
+	return nil
+}
+
+// recordHistory appends lifecycle step of party to history, failing to do so does not fail the step itself.
+func (m *seatManager) recordHistory(ctx context.Context, party *d.Party, kind hsd.PartyEventKind) {
+	if m.history == nil {
+		return
+	}
+
+	if err := m.history.Record(ctx, party.ID, party.Size, kind, time.Now()); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "could not record party history", "party", party, "kind", kind)
+	}
+}
+
+// scheduleCheckInDeadline starts the check-in window of party from the time its seats were preserved.
+func (m *seatManager) scheduleCheckInDeadline(ctx context.Context, partyID d.PartyID) {
+	if m.checkInDeadlines == nil || m.checkInTimeout <= 0 {
+		return
+	}
+
+	state, err := m.hostdesk.GetPartyServiceState(ctx, partyID)
+	if err != nil || state == nil || state.Status != hdd.SeatPreserved {
+		m.logger.LogDebug(SEAT_MANAGER, "no preserved seats to start check-in window", "party id", partyID, "err", err)
+		return
+	}
+
+	if err := m.checkInDeadlines.Schedule(ctx, string(partyID), state.PreservedAt.Add(m.checkInTimeout)); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "could not schedule check-in deadline", "party id", partyID)
+	}
+}
+
+func (m *seatManager) cancelCheckInDeadline(ctx context.Context, partyID d.PartyID) {
+	if m.checkInDeadlines == nil {
+		return
+	}
+
+	if err := m.checkInDeadlines.Cancel(ctx, string(partyID)); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "could not cancel check-in deadline", "party id", partyID)
+	}
+}
+
+// notifyPartiesBehind asynchronously pushes queue status to waiting parties
+// whose position moved up after the party ahead of them left the queue.
+func (m *seatManager) notifyPartiesBehind(left *w.QueuedParty) {
+	area, position := left.Preference.QueueArea(), left.Position
 	go func() {
 		ctx := context.Background()
-		queuedParties, err := m.waitlist.GetQueuedParties(ctx)
+		queuedParties, err := m.waitlist.GetQueuedParties(ctx, area)
 		if err != nil {
 			m.logger.LogErr(SEAT_MANAGER, err, "could not get parties in queue")
 			return
 		}
 
 		for party := range queuedParties {
-			if party.Status == d.PartyStatusWaiting {
-				m.eventbus.Publish(ctx, &sse.NotifyPartyQueueStatusUpdateEvent{QueuedParty: party})
+			if party.Status == d.PartyStatusWaiting && party.Position >= position {
+				m.eventbus.Publish(ctx, &sse.NotifyPartyQueueStatusUpdateEvent{RestaurantID: m.restaurantID, QueuedParty: party})
 			}
 		}
 	}()
-	return nil
 }
 
-func (m *seatManager) checkAndAssignSeating(ctx context.Context) error {
-	capacity, _, err := m.hostdesk.GetCurrentCapacity(ctx)
-	if err != nil {
-		m.logger.LogErr(SEAT_MANAGER, err, "get capacity of hostdesk failed")
-		return fmt.Errorf("get capacity of hostdesk failed: %w", err)
+// notifyHostDesk lets host dashboards refresh, every change they are told about could also move
+// seating forecasts of waiting parties, so the parties are told as well.
+func (m *seatManager) notifyHostDesk(ctx context.Context) {
+	if err := m.eventbus.Publish(ctx, &sse.NotifyHostDeskUpdateEvent{RestaurantID: m.restaurantID}); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "could not publish host desk update")
 	}
+	m.notifyWaitForecasts()
+}
 
-	if capacity > 0 {
-		if err := m.processAvailableCapacity(ctx, capacity); err != nil {
-			m.logger.LogErr(SEAT_MANAGER, err, "process available capacity")
-			return err
+// notifyWaitForecasts asynchronously pushes queue status to waiting parties whose seating forecast moved materially.
+// Moved forecasts are stored before they are pushed, so a party is told once even if several instances refresh.
+func (m *seatManager) notifyWaitForecasts() {
+	go func() {
+		ctx := context.Background()
+		for _, area := range d.SeatingAreas {
+			moved, err := m.waitlist.RefreshWaitForecasts(ctx, area)
+			if err != nil {
+				m.logger.LogErr(SEAT_MANAGER, err, "could not refresh seating forecasts", "area", area)
+				continue
+			}
+			for _, party := range moved {
+				m.eventbus.Publish(ctx, &sse.NotifyPartyQueueStatusUpdateEvent{RestaurantID: m.restaurantID, QueuedParty: party})
+			}
+		}
+	}()
+}
+
+// checkAndAssignSeating offers vacancy of every area to the next party, tables go first
+// so parties without preference only get counter seats when tables cannot take them.
+func (m *seatManager) checkAndAssignSeating(ctx context.Context) error {
+	for _, area := range d.SeatingAreas {
+		vacancy, err := m.hostdesk.GetVacancy(ctx, area)
+		if err != nil {
+			m.logger.LogErr(SEAT_MANAGER, err, "get vacancy of hostdesk failed", "area", area)
+			return fmt.Errorf("get vacancy of hostdesk failed: %w", err)
+		}
+
+		if len(vacancy.Tables) > 0 {
+			if err := m.processAvailableCapacity(ctx, vacancy); err != nil {
+				m.logger.LogErr(SEAT_MANAGER, err, "process available capacity", "area", area)
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-func (m *seatManager) processAvailableCapacity(ctx context.Context, availableSeats int) error {
-	nextParty, err := m.selection.EvaluateNextParty(ctx, availableSeats)
+func (m *seatManager) processAvailableCapacity(ctx context.Context, vacancy *hdd.SeatingVacancy) error {
+	area := vacancy.Area
+	waiting, err := waitingParties(ctx, m.waitlist, area, 0)
 	if err != nil {
-		return fmt.Errorf("evaluate next party failed: %w", err)
+		return fmt.Errorf("get waiting parties failed: %w", err)
+	}
+
+	nextParty, reason := m.priorityParty(waiting)
+	if nextParty != nil {
+		if !vacancy.CanSeat(nextParty.Size) {
+			m.logger.LogDebug(SEAT_MANAGER, "hold vacancy for "+reason+" party", "area", area, "party", nextParty, "free seats", vacancy.Seats())
+			return nil
+		}
+		m.logger.LogDebug(SEAT_MANAGER, "vacancy goes to "+reason+" party", "area", area, "party", nextParty)
+	} else {
+		nextParty, err = m.selection.EvaluateNextParty(ctx, vacancy)
+		if err != nil {
+			return fmt.Errorf("evaluate next party failed: %w", err)
+		}
 	}
 
 	if nextParty != nil {
-		m.logger.LogDebug(SEAT_MANAGER, "available seats are enough for next party", "party", nextParty)
-		if err := m.hostdesk.NotifyPartyReady(ctx, nextParty); err != nil {
+		m.logger.LogDebug(SEAT_MANAGER, "available seats are enough for next party", "area", area, "party", nextParty)
+		if err := m.hostdesk.NotifyPartyReady(ctx, nextParty, area); err != nil {
 			return err
 		}
+		m.recordSkips(ctx, waiting, nextParty)
+	}
+	return nil
+}
+
+// priorityParty is the party vacancy is held for regardless of selection strategy, with the reason for it.
+// Reservations arrived on time go first, then parties out of patience, nil if nobody has priority.
+func (m *seatManager) priorityParty(waiting []*w.QueuedParty) (*w.QueuedParty, string) {
+	for _, party := range waiting {
+		if party.ReservationID != "" {
+			return party, "reserved"
+		}
+	}
+	if party := m.starvingParty(waiting); party != nil {
+		return party, "starving"
+	}
+	return nil, ""
+}
+
+// starvingParty is the longest waiting party out of patience, either skipped too many times
+// or waiting too long past its estimated end of service, nil if nobody starves.
+func (m *seatManager) starvingParty(waiting []*w.QueuedParty) *w.QueuedParty {
+	for _, party := range waiting {
+		if m.maxSkips > 0 && party.SkipCount >= m.maxSkips {
+			return party
+		}
+		if m.overdueLimit > 0 && time.Since(party.JoinedAt) > party.EstimatedEndOfServiceTime+m.overdueLimit {
+			return party
+		}
 	}
 	return nil
 }
+
+// recordSkips counts waiting parties ahead of the chosen one as passed over.
+func (m *seatManager) recordSkips(ctx context.Context, waiting []*w.QueuedParty, chosen *w.QueuedParty) {
+	skipped := []d.PartyID{}
+	for _, party := range waiting {
+		if party.ID == chosen.ID {
+			break
+		}
+		skipped = append(skipped, party.ID)
+	}
+
+	if err := m.waitlist.RecordSkips(ctx, skipped); err != nil {
+		m.logger.LogErr(SEAT_MANAGER, err, "could not record skipped parties", "party ids", skipped)
+	}
+}
@@ -6,11 +6,15 @@ import (
 	"os"
 	log "queue-bite/internal/config/logger"
 	"queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
 	hdr "queue-bite/internal/features/hostdesk/repository"
 	hd "queue-bite/internal/features/hostdesk/service"
+	smd "queue-bite/internal/features/seatmanager/domain"
 	st "queue-bite/internal/features/servicetime/service"
+	w "queue-bite/internal/features/waitlist/domain"
 	wr "queue-bite/internal/features/waitlist/repository/redis"
 	waitlist "queue-bite/internal/features/waitlist/service"
+	dlr "queue-bite/internal/platform/deadline/redis"
 	"queue-bite/internal/platform/eventbus"
 	ebr "queue-bite/internal/platform/eventbus/redis"
 	"testing"
@@ -25,6 +29,7 @@ import (
 )
 
 type testDeps struct {
+	redis                *redis.Client
 	logger               log.Logger
 	eventbus             eventbus.EventBus
 	waitlist             waitlist.Waitlist
@@ -40,14 +45,14 @@ func TestHandleNewPartyArrival(t *testing.T) {
 		selection := NewOrderedSeatingStrategy(deps.waitlist)
 		processing := NewInstantServingStrategy()
 		logger := log.NewNoopLogger()
-		service := NewSeatManager(logger, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries)
+		service := NewSeatManager(logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries)
 
 		t.Run("serving success", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 0, queue.TotalParties)
 
-			capacity, version, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, version, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 10, capacity)
 			assert.Equal(t, 0, int(version))
@@ -58,11 +63,11 @@ func TestHandleNewPartyArrival(t *testing.T) {
 		})
 
 		t.Run("serving if capacity still enough", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 0, queue.TotalParties)
 
-			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 2, capacity)
 
@@ -72,11 +77,11 @@ func TestHandleNewPartyArrival(t *testing.T) {
 		})
 
 		t.Run("wait in queue if capacity is insufficient", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 0, queue.TotalParties)
 
-			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 1, capacity)
 
@@ -86,11 +91,11 @@ func TestHandleNewPartyArrival(t *testing.T) {
 		})
 
 		t.Run("wait in queue even if the capacity is enough but there waiting parties in queue", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 1, queue.TotalParties)
 
-			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 1, capacity)
 
@@ -105,14 +110,14 @@ func TestHandleNewPartyArrival(t *testing.T) {
 		deps := setupTestDepdencies(t, 10)
 		selection := NewOrderedSeatingStrategy(deps.waitlist)
 		processing := NewFairOrderStrategy()
-		service := NewSeatManager(deps.logger, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries)
+		service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries)
 
 		t.Run("ready to check in", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 0, queue.TotalParties)
 
-			capacity, version, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, version, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 10, capacity)
 			assert.Equal(t, 0, int(version))
@@ -121,19 +126,19 @@ func TestHandleNewPartyArrival(t *testing.T) {
 			result, err := service.ProcessNewParty(ctx, party)
 			assert.Equal(t, domain.PartyStatusReady, result.Status)
 
-			queue, err = deps.waitlist.GetQueueStatus(ctx)
+			queue, err = deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 1, queue.TotalParties)
 			assert.Equal(t, 0, queue.WaitingParties)
 		})
 
 		t.Run("ready to check in in the queue if capacity still enough", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 1, queue.TotalParties)
 			assert.Equal(t, 0, queue.WaitingParties)
 
-			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 2, capacity)
 
@@ -141,19 +146,19 @@ func TestHandleNewPartyArrival(t *testing.T) {
 			result, err := service.ProcessNewParty(ctx, party)
 			assert.Equal(t, domain.PartyStatusReady, result.Status)
 
-			queue, err = deps.waitlist.GetQueueStatus(ctx)
+			queue, err = deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 2, queue.TotalParties)
 			assert.Equal(t, 0, queue.WaitingParties)
 		})
 
 		t.Run("wait in queue if capacity is insufficient", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 2, queue.TotalParties)
 			assert.Equal(t, 0, queue.WaitingParties)
 
-			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 1, capacity)
 
@@ -161,19 +166,19 @@ func TestHandleNewPartyArrival(t *testing.T) {
 			result, err := service.ProcessNewParty(ctx, party)
 			assert.Equal(t, domain.PartyStatusWaiting, result.Status)
 
-			queue, err = deps.waitlist.GetQueueStatus(ctx)
+			queue, err = deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 3, queue.TotalParties)
 			assert.Equal(t, 1, queue.WaitingParties)
 		})
 
 		t.Run("wait in queue even if the capacity is enough but there waiting parties in queue", func(t *testing.T) {
-			queue, err := deps.waitlist.GetQueueStatus(ctx)
+			queue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 3, queue.TotalParties)
 			assert.Equal(t, 1, queue.WaitingParties)
 
-			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx)
+			capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 1, capacity)
 
@@ -181,7 +186,7 @@ func TestHandleNewPartyArrival(t *testing.T) {
 			result, err := service.ProcessNewParty(ctx, party)
 			assert.Equal(t, domain.PartyStatusWaiting, result.Status)
 
-			queue, err = deps.waitlist.GetQueueStatus(ctx)
+			queue, err = deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
 			require.NoError(t, err)
 			assert.Equal(t, 4, queue.TotalParties)
 			assert.Equal(t, 2, queue.WaitingParties)
@@ -194,17 +199,17 @@ func TestHandleNewPartyArrival(t *testing.T) {
 		deps := setupTestDepdencies(t, 10)
 		selection := NewOrderedSeatingStrategy(deps.waitlist)
 		processing := NewInstantServingStrategy()
-		service := NewSeatManager(deps.logger, deps.eventbus, deps.waitlist, hostdesk, processing, selection, deps.maxOptimisticRetries)
+		service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, hostdesk, processing, selection, deps.maxOptimisticRetries)
 
 		hostdesk.
 			EXPECT().
-			GetCurrentCapacity(ctx).
-			Return(10, domain.Version(0), nil).
+			GetVacancy(ctx, domain.SeatingAreaTable).
+			Return(seatPoolVacancy(domain.SeatingAreaTable, 10, 0), nil).
 			AnyTimes()
 
 		hostdesk.
 			EXPECT().
-			PreserveSeats(ctx, gomock.Any(), gomock.Any(), gomock.Any()).
+			PreserveSeats(ctx, gomock.Any(), gomock.Any(), gomock.Eq(domain.SeatingAreaTable), gomock.Any()).
 			Return(true, nil).
 			AnyTimes()
 
@@ -228,16 +233,16 @@ func TestHandleNewPartyArrival(t *testing.T) {
 			deps := setupTestDepdencies(t, 10)
 			selection := NewOrderedSeatingStrategy(deps.waitlist)
 			processing := NewFairOrderStrategy()
-			service := NewSeatManager(deps.logger, deps.eventbus, deps.waitlist, hostdesk, processing, selection, deps.maxOptimisticRetries)
+			service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, hostdesk, processing, selection, deps.maxOptimisticRetries)
 			hostdesk.
 				EXPECT().
-				GetCurrentCapacity(ctx).
-				Return(10, domain.Version(0), nil).
+				GetVacancy(ctx, domain.SeatingAreaTable).
+				Return(seatPoolVacancy(domain.SeatingAreaTable, 10, 0), nil).
 				AnyTimes()
 
 			hostdesk.
 				EXPECT().
-				PreserveSeats(ctx, gomock.Eq(domain.PartyID("party-1")), gomock.Eq(8), gomock.Eq(domain.Version(0))).
+				PreserveSeats(ctx, gomock.Eq(domain.PartyID("party-1")), gomock.Eq(8), gomock.Eq(domain.SeatingAreaTable), gomock.Eq(domain.Version(0))).
 				Return(false, domain.ErrVersionMismatch).
 				AnyTimes()
 
@@ -252,29 +257,29 @@ func TestHandleNewPartyArrival(t *testing.T) {
 			deps := setupTestDepdencies(t, 10)
 			selection := NewOrderedSeatingStrategy(deps.waitlist)
 			processing := NewFairOrderStrategy()
-			service := NewSeatManager(deps.logger, deps.eventbus, deps.waitlist, hostdesk, processing, selection, deps.maxOptimisticRetries)
+			service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, hostdesk, processing, selection, deps.maxOptimisticRetries)
 			gomock.InOrder(
 				hostdesk.
 					EXPECT().
-					GetCurrentCapacity(ctx).
-					Return(10, domain.Version(0), nil).
+					GetVacancy(ctx, domain.SeatingAreaTable).
+					Return(seatPoolVacancy(domain.SeatingAreaTable, 10, 0), nil).
 					Times(1),
 				hostdesk.
 					EXPECT().
-					GetCurrentCapacity(ctx).
-					Return(8, domain.Version(1), nil).
+					GetVacancy(ctx, domain.SeatingAreaTable).
+					Return(seatPoolVacancy(domain.SeatingAreaTable, 8, 1), nil).
 					AnyTimes(),
 			)
 
 			gomock.InOrder(
 				hostdesk.
 					EXPECT().
-					PreserveSeats(ctx, gomock.Eq(domain.PartyID("party-1")), gomock.Eq(8), gomock.Eq(domain.Version(0))).
+					PreserveSeats(ctx, gomock.Eq(domain.PartyID("party-1")), gomock.Eq(8), gomock.Eq(domain.SeatingAreaTable), gomock.Eq(domain.Version(0))).
 					Return(false, domain.ErrVersionMismatch).
 					Times(1),
 				hostdesk.
 					EXPECT().
-					PreserveSeats(ctx, gomock.Eq(domain.PartyID("party-1")), gomock.Eq(8), gomock.Eq(domain.Version(1))).
+					PreserveSeats(ctx, gomock.Eq(domain.PartyID("party-1")), gomock.Eq(8), gomock.Eq(domain.SeatingAreaTable), gomock.Eq(domain.Version(1))).
 					Return(true, nil).
 					Times(1),
 			)
@@ -288,7 +293,426 @@ func TestHandleNewPartyArrival(t *testing.T) {
 
 }
 
+func TestHostPartyActions(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDepdencies(t, 10)
+	selection := NewOrderedSeatingStrategy(deps.waitlist)
+	processing := NewFairOrderStrategy()
+	service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries)
+
+	ready, err := service.ProcessNewParty(ctx, domain.NewParty("party-1", "name", 8))
+	require.NoError(t, err)
+	assert.Equal(t, domain.PartyStatusReady, ready.Status)
+
+	waiting, err := service.ProcessNewParty(ctx, domain.NewParty("party-2", "name", 4))
+	require.NoError(t, err)
+	assert.Equal(t, domain.PartyStatusWaiting, waiting.Status)
+
+	t.Run("remove ready party releases preserved seats to next party", func(t *testing.T) {
+		err := service.RemoveParty(ctx, "party-1")
+		require.NoError(t, err)
+		assert.False(t, deps.waitlist.HasPartyExists(ctx, "party-1"))
+
+		capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 6, capacity)
+	})
+
+	t.Run("remove unknown party", func(t *testing.T) {
+		err := service.RemoveParty(ctx, "party-unknown")
+		assert.ErrorIs(t, err, w.ErrPartyNotFound)
+	})
+
+	t.Run("host calls waiting party", func(t *testing.T) {
+		// instant serving keeps newcomers waiting while the queue is not empty
+		instantService := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, NewInstantServingStrategy(), selection, deps.maxOptimisticRetries)
+		queued, err := instantService.ProcessNewParty(ctx, domain.NewParty("party-3", "name", 2))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusWaiting, queued.Status)
+
+		err = service.ReadyParty(ctx, "party-3")
+		require.NoError(t, err)
+
+		capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 4, capacity)
+	})
+
+	t.Run("host calls unknown party", func(t *testing.T) {
+		err := service.ReadyParty(ctx, "party-unknown")
+		assert.ErrorIs(t, err, w.ErrPartyNotFound)
+	})
+}
+
+func TestPartyLeave(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDepdencies(t, 10)
+	selection := NewOrderedSeatingStrategy(deps.waitlist)
+	processing := NewFairOrderStrategy()
+	service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries)
+
+	_, err := service.ProcessNewParty(ctx, domain.NewParty("party-1", "name", 8))
+	require.NoError(t, err)
+	_, err = service.ProcessNewParty(ctx, domain.NewParty("party-2", "name", 4))
+	require.NoError(t, err)
+	_, err = service.ProcessNewParty(ctx, domain.NewParty("party-3", "name", 2))
+	require.NoError(t, err)
+
+	t.Run("waiting party leaves and parties behind move up", func(t *testing.T) {
+		err := service.PartyLeave(ctx, "party-2")
+		require.NoError(t, err)
+		assert.False(t, deps.waitlist.HasPartyExists(ctx, "party-2"))
+
+		party, err := deps.waitlist.GetQueuedParty(ctx, "party-3")
+		require.NoError(t, err)
+		assert.Equal(t, 1, party.Position)
+	})
+
+	t.Run("ready party leaves and gives seats back", func(t *testing.T) {
+		err := service.PartyLeave(ctx, "party-1")
+		require.NoError(t, err)
+		assert.False(t, deps.waitlist.HasPartyExists(ctx, "party-1"))
+
+		capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 8, capacity)
+	})
+
+	t.Run("unknown party leaves", func(t *testing.T) {
+		err := service.PartyLeave(ctx, "party-unknown")
+		assert.ErrorIs(t, err, w.ErrPartyNotFound)
+	})
+}
+
+func TestCheckInTimeout(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDepdencies(t, 10)
+	deadlines := dlr.NewRedisDeadlineQueue(deps.logger, deps.redis, "check-in")
+	selection := NewOrderedSeatingStrategy(deps.waitlist)
+	processing := NewFairOrderStrategy()
+	newService := func(policy smd.CheckInTimeoutPolicy) *seatManager {
+		return NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries,
+			WithCheckInTimeout(deadlines, time.Millisecond, time.Hour, policy)).(*seatManager)
+	}
+	skipService := newService(smd.CheckInTimeoutSkip)
+	dropService := newService(smd.CheckInTimeoutDrop)
+
+	ready, err := skipService.ProcessNewParty(ctx, domain.NewParty("party-1", "name", 8))
+	require.NoError(t, err)
+	assert.Equal(t, domain.PartyStatusReady, ready.Status)
+	_, err = skipService.ProcessNewParty(ctx, domain.NewParty("party-2", "name", 4))
+	require.NoError(t, err)
+
+	t.Run("ready party gets a check-in deadline", func(t *testing.T) {
+		time.Sleep(10 * time.Millisecond)
+		claims, err := deadlines.ClaimExpired(ctx, time.Now(), time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claims, 1)
+		assert.Equal(t, "party-1", claims[0].ID)
+	})
+
+	t.Run("skip policy sends party to the back and serves next party", func(t *testing.T) {
+		err := skipService.handleCheckInExpired(ctx, "party-1")
+		require.NoError(t, err)
+
+		party, err := deps.waitlist.GetQueuedParty(ctx, "party-1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusWaiting, party.Status)
+		assert.Equal(t, 1, party.Position)
+
+		capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 6, capacity)
+	})
+
+	t.Run("drop policy takes party out of the waitlist", func(t *testing.T) {
+		err := dropService.handleCheckInExpired(ctx, "party-2")
+		require.NoError(t, err)
+		assert.False(t, deps.waitlist.HasPartyExists(ctx, "party-2"))
+
+		capacity, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 2, capacity)
+	})
+
+	t.Run("checked in party is left as is", func(t *testing.T) {
+		// seats preserved event is not watched in this test, turn party ready as its handler does
+		require.NoError(t, deps.waitlist.HandlePartyReady(ctx, "party-1"))
+		err := dropService.PartyCheckIn(ctx, "party-1")
+		require.NoError(t, err)
+
+		err = dropService.handleCheckInExpired(ctx, "party-1")
+		require.NoError(t, err)
+		assert.True(t, deps.hostdesk.HasPartyOccupiedSeat(ctx, "party-1"))
+	})
+}
+
+func TestSeatingPreference(t *testing.T) {
+	ctx := context.Background()
+	deps := setupAreaTestDepdencies(t, map[domain.SeatingArea]int{
+		domain.SeatingAreaTable:   4,
+		domain.SeatingAreaCounter: 2,
+	})
+	selection := NewPreferenceSeatingStrategy(deps.waitlist)
+	service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, NewInstantServingStrategy(), selection, deps.maxOptimisticRetries)
+
+	newParty := func(id domain.PartyID, size int, preference domain.SeatingPreference) *domain.Party {
+		party := domain.NewParty(id, "name", size)
+		party.Preference = preference
+		return party
+	}
+
+	t.Run("counter party is seated at counter", func(t *testing.T) {
+		served, err := service.ProcessNewParty(ctx, newParty("party-1", 2, domain.SeatingPreferenceCounter))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusServing, served.Status)
+
+		counter, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaCounter)
+		require.NoError(t, err)
+		assert.Equal(t, 0, counter)
+
+		table, _, err := deps.hostdesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 4, table)
+	})
+
+	t.Run("counter party waits for counter even though tables are free", func(t *testing.T) {
+		waiting, err := service.ProcessNewParty(ctx, newParty("party-2", 2, domain.SeatingPreferenceCounter))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusWaiting, waiting.Status)
+
+		counterQueue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaCounter)
+		require.NoError(t, err)
+		assert.Equal(t, 1, counterQueue.TotalParties)
+
+		tableQueue, err := deps.waitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 0, tableQueue.TotalParties)
+	})
+
+	t.Run("party without preference takes a table", func(t *testing.T) {
+		served, err := service.ProcessNewParty(ctx, newParty("party-3", 4, domain.SeatingPreferenceAny))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusServing, served.Status)
+
+		waiting, err := service.ProcessNewParty(ctx, newParty("party-4", 2, domain.SeatingPreferenceAny))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusWaiting, waiting.Status)
+	})
+
+	t.Run("counter vacancy goes to counter queue first", func(t *testing.T) {
+		next, err := selection.EvaluateNextParty(ctx, seatPoolVacancy(domain.SeatingAreaCounter, 2, 0))
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		assert.Equal(t, domain.PartyID("party-2"), next.ID)
+	})
+
+	t.Run("counter vacancy falls back to parties accepting counter", func(t *testing.T) {
+		err := service.PartyLeave(ctx, "party-2")
+		require.NoError(t, err)
+
+		next, err := selection.EvaluateNextParty(ctx, seatPoolVacancy(domain.SeatingAreaCounter, 2, 0))
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		assert.Equal(t, domain.PartyID("party-4"), next.ID)
+	})
+}
+
+func TestStarvationGuard(t *testing.T) {
+	ctx := context.Background()
+	deps := setupTestDepdencies(t, 6)
+	selection := NewOrderedSeatingStrategy(deps.waitlist)
+	processing := NewFairOrderStrategy()
+	service := NewSeatManager(deps.logger, domain.DefaultRestaurantID, deps.eventbus, deps.waitlist, deps.hostdesk, processing, selection, deps.maxOptimisticRetries,
+		WithStarvationGuard(1, 0))
+
+	for _, party := range []*domain.Party{
+		domain.NewParty("party-1", "name", 4),
+		domain.NewParty("party-2", "name", 6),
+		domain.NewParty("party-3", "name", 2),
+		domain.NewParty("party-4", "name", 2),
+	} {
+		_, err := service.ProcessNewParty(ctx, party)
+		require.NoError(t, err)
+	}
+
+	t.Run("large party is skipped by a smaller one", func(t *testing.T) {
+		require.NoError(t, service.RemoveParty(ctx, "party-4"))
+
+		state, err := deps.hostdesk.GetPartyServiceState(ctx, "party-3")
+		require.NoError(t, err)
+		assert.NotNil(t, state)
+
+		party, err := deps.waitlist.GetQueuedParty(ctx, "party-2")
+		require.NoError(t, err)
+		assert.Equal(t, 1, party.SkipCount)
+	})
+
+	t.Run("vacancy is held for starving party", func(t *testing.T) {
+		_, err := service.ProcessNewParty(ctx, domain.NewParty("party-5", "name", 2))
+		require.NoError(t, err)
+		require.NoError(t, service.PartyLeave(ctx, "party-1"))
+
+		vacancy, err := deps.hostdesk.GetVacancy(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 4, vacancy.Seats())
+
+		state, err := deps.hostdesk.GetPartyServiceState(ctx, "party-5")
+		require.NoError(t, err)
+		assert.Nil(t, state)
+	})
+
+	t.Run("starving party is seated once it fits", func(t *testing.T) {
+		require.NoError(t, service.PartyLeave(ctx, "party-3"))
+
+		state, err := deps.hostdesk.GetPartyServiceState(ctx, "party-2")
+		require.NoError(t, err)
+		assert.NotNil(t, state)
+	})
+}
+
+func TestRestaurantIsolation(t *testing.T) {
+	ctx := context.Background()
+	redisClient, cleanup := setupRedisContainer(t)
+	t.Cleanup(cleanup)
+	logger := log.NewNoopLogger()
+	bus := ebr.NewRedisEventBus(logger, redisClient, eventbus.NewEventRegistry())
+
+	newRestaurant := func(id domain.RestaurantID) (waitlist.Waitlist, hd.HostDesk, SeatManager) {
+		wl := waitlist.NewWaitlistService(logger, id,
+			wr.NewRedisWaitlistRepository(logger, redisClient, id, 5*time.Second, 5),
+			st.NewFixedRateEstimator(1*time.Minute),
+			bus,
+		)
+		desk := hd.NewInstantServeHostDesk(logger, id, hdd.NewSeatPool(domain.SeatingAreaTable, 4),
+			hdr.NewRedisHostDeskRepository(logger, redisClient, id), bus, nil)
+		return wl, desk, NewSeatManager(logger, id, bus, wl, desk, NewInstantServingStrategy(), NewOrderedSeatingStrategy(wl), 3)
+	}
+	downtownWaitlist, downtownDesk, downtown := newRestaurant("downtown")
+	uptownWaitlist, uptownDesk, uptown := newRestaurant("uptown")
+
+	served, err := downtown.ProcessNewParty(ctx, domain.NewParty("party-1", "name", 4))
+	require.NoError(t, err)
+	assert.Equal(t, domain.PartyStatusServing, served.Status)
+
+	waiting, err := downtown.ProcessNewParty(ctx, domain.NewParty("party-2", "name", 2))
+	require.NoError(t, err)
+	assert.Equal(t, domain.PartyStatusWaiting, waiting.Status)
+
+	t.Run("seats of other restaurant are untouched", func(t *testing.T) {
+		downtownCapacity, _, err := downtownDesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 0, downtownCapacity)
+
+		uptownCapacity, _, err := uptownDesk.GetCurrentCapacity(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 4, uptownCapacity)
+	})
+
+	t.Run("queue of other restaurant is untouched", func(t *testing.T) {
+		assert.True(t, downtownWaitlist.HasPartyExists(ctx, "party-2"))
+		assert.False(t, uptownWaitlist.HasPartyExists(ctx, "party-2"))
+
+		status, err := uptownWaitlist.GetQueueStatus(ctx, domain.SeatingAreaTable)
+		require.NoError(t, err)
+		assert.Equal(t, 0, status.TotalParties)
+	})
+
+	t.Run("same party id could queue at both restaurants", func(t *testing.T) {
+		served, err := uptown.ProcessNewParty(ctx, domain.NewParty("party-2", "name", 2))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusServing, served.Status)
+
+		queued, err := downtownWaitlist.GetQueuedParty(ctx, "party-2")
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyStatusWaiting, queued.Status)
+	})
+}
+
+func TestSelectionStrategies(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ordered seats exact fit", func(t *testing.T) {
+		queue := newStubQueue(stubParty("party-1", 4))
+		next, err := NewOrderedSeatingStrategy(queue).EvaluateNextParty(ctx, seatPoolVacancy(domain.SeatingAreaTable, 4, 0))
+		require.NoError(t, err)
+		require.NotNil(t, next)
+		assert.Equal(t, domain.PartyID("party-1"), next.ID)
+	})
+
+	t.Run("best fit leaves fewest seats within window", func(t *testing.T) {
+		queue := newStubQueue(stubParty("party-1", 2), stubParty("party-2", 4), stubParty("party-3", 3))
+		vacancy := seatPoolVacancy(domain.SeatingAreaTable, 4, 0)
+
+		next, err := NewBestFitSeatingStrategy(queue, 3).EvaluateNextParty(ctx, vacancy)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyID("party-2"), next.ID)
+
+		next, err = NewBestFitSeatingStrategy(queue, 1).EvaluateNextParty(ctx, vacancy)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyID("party-1"), next.ID)
+	})
+
+	t.Run("size buckets serve line of largest parties fitting", func(t *testing.T) {
+		queue := newStubQueue(stubParty("party-1", 2), stubParty("party-2", 6), stubParty("party-3", 4), stubParty("party-4", 5))
+		selection := NewSizeBucketSeatingStrategy(queue)
+
+		next, err := selection.EvaluateNextParty(ctx, seatPoolVacancy(domain.SeatingAreaTable, 5, 0))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyID("party-3"), next.ID, "party of 5 waits behind head of its line")
+
+		next, err = selection.EvaluateNextParty(ctx, seatPoolVacancy(domain.SeatingAreaTable, 6, 0))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyID("party-2"), next.ID)
+
+		next, err = selection.EvaluateNextParty(ctx, seatPoolVacancy(domain.SeatingAreaTable, 2, 0))
+		require.NoError(t, err)
+		assert.Equal(t, domain.PartyID("party-1"), next.ID)
+	})
+}
+
+// seatPoolVacancy is vacancy of seats free in a seat pool area.
+func seatPoolVacancy(area domain.SeatingArea, seats int, version domain.Version) *hdd.SeatingVacancy {
+	return hdd.NewSeatingVacancy(area, hdd.NewSeatPool(area, seats), nil, version)
+}
+
+// stubQueue streams fixed parties as a single queue of every area.
+type stubQueue struct {
+	parties []*w.QueuedParty
+}
+
+func newStubQueue(parties ...*w.QueuedParty) *stubQueue {
+	return &stubQueue{parties: parties}
+}
+
+func stubParty(id domain.PartyID, size int) *w.QueuedParty {
+	party := domain.NewParty(id, "name", size)
+	party.Status = domain.PartyStatusWaiting
+	return &w.QueuedParty{Party: party}
+}
+
+func (q *stubQueue) GetQueuedParties(ctx context.Context, area domain.SeatingArea) (<-chan *w.QueuedParty, error) {
+	parties := make(chan *w.QueuedParty, len(q.parties))
+	for _, party := range q.parties {
+		parties <- party
+	}
+	close(parties)
+	return parties, nil
+}
+
 func setupTestDepdencies(t *testing.T, seats int) *testDeps {
+	return setupAreaTestDepdencies(t, map[domain.SeatingArea]int{domain.SeatingAreaTable: seats})
+}
+
+func setupAreaTestDepdencies(t *testing.T, seats map[domain.SeatingArea]int) *testDeps {
+	tables := []*hdd.Table{}
+	for area, seats := range seats {
+		tables = append(tables, hdd.NewSeatPool(area, seats)...)
+	}
+	return setupTableTestDepdencies(t, tables)
+}
+
+func setupTableTestDepdencies(t *testing.T, tables []*hdd.Table) *testDeps {
 	redisClient, cleanup := setupRedisContainer(t)
 	t.Cleanup(cleanup)
 	logger := log.NewZerologLogger(os.Stdout, true)
@@ -296,14 +720,16 @@ func setupTestDepdencies(t *testing.T, seats int) *testDeps {
 	eventbus := ebr.NewRedisEventBus(logger, redisClient, registry)
 	waitlist := waitlist.NewWaitlistService(
 		logger,
-		wr.NewRedisWaitlistRepository(logger, redisClient, 5*time.Second, 5),
+		domain.DefaultRestaurantID,
+		wr.NewRedisWaitlistRepository(logger, redisClient, domain.DefaultRestaurantID, 5*time.Second, 5),
 		st.NewFixedRateEstimator(1*time.Minute),
 		eventbus,
 	)
-	hostdesk := hd.NewInstantServeHostDesk(logger, seats, hdr.NewInMemoryHostDeskRepository(logger), eventbus, nil)
+	hostdesk := hd.NewInstantServeHostDesk(logger, domain.DefaultRestaurantID, tables, hdr.NewInMemoryHostDeskRepository(logger), eventbus, nil)
 	maxOptimisticRetries := 3
 
 	return &testDeps{
+		redis:                redisClient,
 		logger:               logger,
 		eventbus:             eventbus,
 		waitlist:             waitlist,
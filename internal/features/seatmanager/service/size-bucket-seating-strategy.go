@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	w "queue-bite/internal/features/waitlist/domain"
+	ws "queue-bite/internal/features/waitlist/service"
+)
+
+// partySizeBuckets are the smallest party size of each virtual line, from the largest parties down.
+var partySizeBuckets = []int{5, 3, 1}
+
+// SizeBucketSeatingStrategy splits the area queue into virtual lines of parties of 1-2, 3-4 and 5+,
+// each served in join order. Vacancy goes to the head of the line of largest parties it could seat,
+// so small parties do not eat up tables a large party is waiting for.
+type SizeBucketSeatingStrategy struct {
+	waitlist ws.QueuedPartyProvider
+}
+
+func NewSizeBucketSeatingStrategy(waitlist ws.QueuedPartyProvider) PartySelectionStrategy {
+	return &SizeBucketSeatingStrategy{
+		waitlist: waitlist,
+	}
+}
+
+func (s *SizeBucketSeatingStrategy) EvaluateNextParty(ctx context.Context, vacancy *hdd.SeatingVacancy) (*w.QueuedParty, error) {
+	// stop streaming the rest of queue once every line has its head
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queuedParties, err := s.waitlist.GetQueuedParties(ctx, vacancy.Area)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make([]*w.QueuedParty, len(partySizeBuckets))
+	found := 0
+	for party := range queuedParties {
+		if party.Status != d.PartyStatusWaiting {
+			continue
+		}
+		if bucket := partySizeBucket(party.Size); heads[bucket] == nil {
+			heads[bucket] = party
+			if found++; found == len(heads) {
+				break
+			}
+		}
+	}
+
+	for _, head := range heads {
+		if head != nil && vacancy.CanSeat(head.Size) {
+			return head, nil
+		}
+	}
+	return nil, nil
+}
+
+func partySizeBucket(size int) int {
+	for i, min := range partySizeBuckets {
+		if size >= min {
+			return i
+		}
+	}
+	return len(partySizeBuckets) - 1
+}
@@ -1,7 +1,35 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	d "queue-bite/internal/domain"
+)
 
 type ServiceTimeEstimate struct {
 	Duration time.Duration
 }
+
+// ServiceTimeSample is how long a party actually stayed from check-in to completion of its service.
+type ServiceTimeSample struct {
+	PartyID     d.PartyID
+	Size        int
+	CheckedInAt time.Time
+	Duration    time.Duration
+}
+
+// LargestSizeBucket is the party size from which parties are learned together, larger parties are too rare on their own.
+const LargestSizeBucket = 8
+
+// AnyHour buckets samples regardless of the hour of day their party checked in.
+const AnyHour = -1
+
+// SizeBucket is the party size samples of party are learned under.
+func SizeBucket(size int) int {
+	return max(min(size, LargestSizeBucket), 1)
+}
+
+// HourBucket is the local hour of day samples of party checked in at are learned under.
+func HourBucket(at time.Time) int {
+	return at.Local().Hour()
+}
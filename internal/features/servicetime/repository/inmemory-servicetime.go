@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/servicetime/domain"
+)
+
+type serviceTimeBucket struct {
+	size int
+	hour int
+}
+
+type InMemoryServiceTimeRepository struct {
+	logger  log.Logger
+	window  int
+	mu      sync.RWMutex
+	samples map[serviceTimeBucket]map[d.PartyID]domain.ServiceTimeSample
+}
+
+func NewInMemoryServiceTimeRepository(logger log.Logger, window int) ServiceTimeRepository {
+	return &InMemoryServiceTimeRepository{
+		logger:  logger,
+		window:  window,
+		samples: make(map[serviceTimeBucket]map[d.PartyID]domain.ServiceTimeSample),
+	}
+}
+
+func (r *InMemoryServiceTimeRepository) RecordServiceTime(ctx context.Context, sample *domain.ServiceTimeSample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size := domain.SizeBucket(sample.Size)
+	for _, hour := range []int{domain.HourBucket(sample.CheckedInAt), domain.AnyHour} {
+		bucket := serviceTimeBucket{size: size, hour: hour}
+		if r.samples[bucket] == nil {
+			r.samples[bucket] = make(map[d.PartyID]domain.ServiceTimeSample)
+		}
+		r.samples[bucket][sample.PartyID] = *sample
+
+		latest := r.latest(bucket)
+		for _, old := range latest[:max(len(latest)-r.window, 0)] {
+			delete(r.samples[bucket], old.PartyID)
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryServiceTimeRepository) GetServiceTimes(ctx context.Context, size int, hour int) ([]time.Duration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	latest := r.latest(serviceTimeBucket{size: size, hour: hour})
+	durations := make([]time.Duration, len(latest))
+	for i, sample := range latest {
+		durations[i] = sample.Duration
+	}
+	return durations, nil
+}
+
+// latest lists samples of bucket by end of service, oldest first.
+func (r *InMemoryServiceTimeRepository) latest(bucket serviceTimeBucket) []domain.ServiceTimeSample {
+	samples := make([]domain.ServiceTimeSample, 0, len(r.samples[bucket]))
+	for _, sample := range r.samples[bucket] {
+		samples = append(samples, sample)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].CheckedInAt.Add(samples[i].Duration).Before(samples[j].CheckedInAt.Add(samples[j].Duration))
+	})
+	return samples
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/servicetime/domain"
+)
+
+var REDIS_SERVICETIME = "servicetime/redis"
+
+// serviceTimeRedisKeys namespaces every service time key by restaurant.
+type serviceTimeRedisKeys struct {
+	restaurantID d.RestaurantID
+}
+
+// st:<restaurant>:samples:<size>:<hour|any>
+func (k *serviceTimeRedisKeys) getSamplesKey(size int, hour int) string {
+	if hour == domain.AnyHour {
+		return fmt.Sprintf("st:%s:samples:%d:any", k.restaurantID, size)
+	}
+	return fmt.Sprintf("st:%s:samples:%d:%d", k.restaurantID, size, hour)
+}
+
+// RedisServiceTimeRepository keeps samples in sorted sets scored by end of service.
+// Members are <party id>:<duration in milliseconds>, so the same sample recorded
+// by several instances is kept once.
+type RedisServiceTimeRepository struct {
+	logger log.Logger
+	client *redis.Client
+	keys   *serviceTimeRedisKeys
+	window int
+}
+
+// NewRedisServiceTimeRepository keeps up to window latest samples of every bucket.
+func NewRedisServiceTimeRepository(logger log.Logger, client *redis.Client, restaurantID d.RestaurantID, window int) ServiceTimeRepository {
+	return &RedisServiceTimeRepository{
+		logger: logger,
+		client: client,
+		keys:   &serviceTimeRedisKeys{restaurantID: restaurantID},
+		window: window,
+	}
+}
+
+func (r *RedisServiceTimeRepository) RecordServiceTime(ctx context.Context, sample *domain.ServiceTimeSample) error {
+	member := fmt.Sprintf("%s:%d", sample.PartyID, sample.Duration.Milliseconds())
+	score := float64(sample.CheckedInAt.Add(sample.Duration).UnixMilli())
+	size := domain.SizeBucket(sample.Size)
+
+	pipe := r.client.TxPipeline()
+	for _, hour := range []int{domain.HourBucket(sample.CheckedInAt), domain.AnyHour} {
+		key := r.keys.getSamplesKey(size, hour)
+		pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: member})
+		pipe.ZRemRangeByRank(ctx, key, 0, int64(-r.window-1))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.LogErr(REDIS_SERVICETIME, err, "could not record service time", "sample", sample)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisServiceTimeRepository) GetServiceTimes(ctx context.Context, size int, hour int) ([]time.Duration, error) {
+	members, err := r.client.ZRange(ctx, r.keys.getSamplesKey(size, hour), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	durations := make([]time.Duration, 0, len(members))
+	for _, member := range members {
+		ms, err := strconv.ParseInt(member[strings.LastIndex(member, ":")+1:], 10, 64)
+		if err != nil {
+			r.logger.LogErr(REDIS_SERVICETIME, err, "could not parse service time sample", "member", member)
+			continue
+		}
+		durations = append(durations, time.Duration(ms)*time.Millisecond)
+	}
+	return durations, nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"queue-bite/internal/features/servicetime/domain"
+)
+
+// ServiceTimeRepository keeps the latest service time samples by party size and hour of day.
+type ServiceTimeRepository interface {
+	// RecordServiceTime keeps sample both under the hour its party checked in at and under AnyHour,
+	// recording the same sample again has no effect.
+	RecordServiceTime(ctx context.Context, sample *domain.ServiceTimeSample) error
+
+	// GetServiceTimes returns durations of the latest samples of size bucket checked in at hour, oldest first.
+	GetServiceTimes(ctx context.Context, size int, hour int) ([]time.Duration, error)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	"queue-bite/internal/features/servicetime/domain"
+	"queue-bite/internal/features/servicetime/repository"
+	"queue-bite/internal/platform/eventbus"
+)
+
+var LEARNING_ESTIMATOR = "servicetime/learning"
+
+// LearningEstimator estimates service time as the rolling median of how long parties of the same size actually stayed.
+// Parties checked in around the same hour of day are looked at first, then parties of the size at any hour,
+// and the fallback estimator answers while neither has minSamples samples yet.
+type LearningEstimator struct {
+	logger       log.Logger
+	restaurantID d.RestaurantID
+	repo         repository.ServiceTimeRepository
+	eventbus     eventbus.EventBus
+	fallback     ServiceTimeEstimator
+	minSamples   int
+}
+
+func NewLearningEstimator(
+	logger log.Logger,
+	restaurantID d.RestaurantID,
+	repo repository.ServiceTimeRepository,
+	eventbus eventbus.EventBus,
+	fallback ServiceTimeEstimator,
+	minSamples int,
+) *LearningEstimator {
+	return &LearningEstimator{
+		logger:       logger,
+		restaurantID: restaurantID,
+		repo:         repo,
+		eventbus:     eventbus,
+		fallback:     fallback,
+		minSamples:   max(minSamples, 1),
+	}
+}
+
+func (e *LearningEstimator) EstimateServiceTime(ctx context.Context, party *d.Party) (*domain.ServiceTimeEstimate, error) {
+	size := domain.SizeBucket(party.Size)
+	for _, hour := range []int{domain.HourBucket(time.Now()), domain.AnyHour} {
+		durations, err := e.repo.GetServiceTimes(ctx, size, hour)
+		if err != nil {
+			e.logger.LogErr(LEARNING_ESTIMATOR, err, "could not get service times, fallback", "size", size, "hour", hour)
+			break
+		}
+		if len(durations) >= e.minSamples {
+			return &domain.ServiceTimeEstimate{Duration: median(durations)}, nil
+		}
+	}
+	return e.fallback.EstimateServiceTime(ctx, party)
+}
+
+// RecordServiceTime learns how long a party actually stayed.
+func (e *LearningEstimator) RecordServiceTime(ctx context.Context, sample *domain.ServiceTimeSample) error {
+	if sample.Duration <= 0 || sample.Size <= 0 {
+		return nil
+	}
+	return e.repo.RecordServiceTime(ctx, sample)
+}
+
+// WatchServiceCompletion learns from every party whose service was completed at the restaurant.
+// Parties ended by the service timer stayed at least as long as they were estimated to, so they are
+// learned at the time their seats were held, otherwise only early leavers would be learned and the estimate could only shrink.
+// A completion is recorded by a single instance.
+func (e *LearningEstimator) WatchServiceCompletion(ctx context.Context) error {
+	_, err := e.eventbus.Subscribe(hdd.TopicPartyServiceCompleted, e.handlePartyServiceCompleted,
+		eventbus.WithCompetingConsumers(LEARNING_ESTIMATOR+":"+string(e.restaurantID)))
+	return err
+}
+
+func (e *LearningEstimator) handlePartyServiceCompleted(ctx context.Context, event eventbus.Event) error {
+	completed := event.(*hdd.PartyServiceCompeletedEvent)
+	if completed.RestaurantID != e.restaurantID || completed.CheckedInAt.IsZero() {
+		return nil
+	}
+
+	sample := &domain.ServiceTimeSample{
+		PartyID:     completed.PartyID,
+		Size:        completed.PartySize,
+		CheckedInAt: completed.CheckedInAt,
+		Duration:    completed.CompletedAt.Sub(completed.CheckedInAt),
+	}
+	if err := e.RecordServiceTime(ctx, sample); err != nil {
+		e.logger.LogErr(LEARNING_ESTIMATOR, err, "could not record service time", "sample", sample)
+		return err
+	}
+	e.logger.LogDebug(LEARNING_ESTIMATOR, "service time recorded", "sample", sample)
+	return nil
+}
+
+func median(durations []time.Duration) time.Duration {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
+	"queue-bite/internal/features/servicetime/domain"
+	"queue-bite/internal/features/servicetime/repository"
+)
+
+func TestLearningEstimator(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNoopLogger()
+	fallback := NewFixedRateEstimator(time.Minute)
+	party := d.NewParty("party", "name", 2)
+
+	setup := func() *LearningEstimator {
+		return NewLearningEstimator(logger, d.DefaultRestaurantID, repository.NewInMemoryServiceTimeRepository(logger, 5), nil, fallback, 3)
+	}
+	record := func(t *testing.T, estimator *LearningEstimator, id string, size int, checkedInAt time.Time, duration time.Duration) {
+		err := estimator.RecordServiceTime(ctx, &domain.ServiceTimeSample{PartyID: d.PartyID(id), Size: size, CheckedInAt: checkedInAt, Duration: duration})
+		require.NoError(t, err)
+	}
+
+	t.Run("fixed rate while samples are sparse", func(t *testing.T) {
+		estimator := setup()
+		record(t, estimator, "party-1", 2, time.Now(), 40*time.Minute)
+		record(t, estimator, "party-2", 2, time.Now(), 40*time.Minute)
+
+		estimate, err := estimator.EstimateServiceTime(ctx, party)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, estimate.Duration)
+	})
+
+	t.Run("median of the latest samples", func(t *testing.T) {
+		estimator := setup()
+		start := time.Now().Add(-3 * time.Hour)
+		for i, minutes := range []int{90, 10, 30, 40, 20, 50} {
+			record(t, estimator, fmt.Sprintf("party-%d", i), 2, start.Add(time.Duration(i)*time.Minute), time.Duration(minutes)*time.Minute)
+		}
+
+		estimate, err := estimator.EstimateServiceTime(ctx, party)
+		require.NoError(t, err)
+		assert.Equal(t, 40*time.Minute, estimate.Duration, "earliest finished sample dropped out of window")
+
+		estimate, err = estimator.EstimateServiceTime(ctx, d.NewParty("party", "name", 4))
+		require.NoError(t, err)
+		assert.Equal(t, 4*time.Minute, estimate.Duration, "other party sizes are learned on their own")
+	})
+
+	t.Run("same hour of day goes first", func(t *testing.T) {
+		estimator := setup()
+		earlier := time.Now().Add(-3 * time.Hour)
+		for i := 0; i < 3; i++ {
+			record(t, estimator, fmt.Sprintf("earlier-%d", i), 2, earlier, 60*time.Minute)
+		}
+		estimate, err := estimator.EstimateServiceTime(ctx, party)
+		require.NoError(t, err)
+		assert.Equal(t, 60*time.Minute, estimate.Duration, "any hour once current hour is sparse")
+
+		now := time.Now().Add(-time.Minute)
+		for i := 0; i < 3; i++ {
+			record(t, estimator, fmt.Sprintf("now-%d", i), 2, now, 20*time.Minute)
+		}
+		estimate, err = estimator.EstimateServiceTime(ctx, party)
+		require.NoError(t, err)
+		assert.Equal(t, 20*time.Minute, estimate.Duration)
+	})
+
+	t.Run("learn from completed services", func(t *testing.T) {
+		estimator := setup()
+		checkedInAt := time.Now().Add(-30 * time.Minute)
+		completed := func(id string, byTimer bool) *hdd.PartyServiceCompeletedEvent {
+			return &hdd.PartyServiceCompeletedEvent{
+				RestaurantID: d.DefaultRestaurantID,
+				PartyID:      d.PartyID(id),
+				PartySize:    2,
+				CheckedInAt:  checkedInAt,
+				CompletedAt:  checkedInAt.Add(30 * time.Minute),
+				EndedByTimer: byTimer,
+			}
+		}
+
+		for _, event := range []*hdd.PartyServiceCompeletedEvent{
+			completed("party-1", false),
+			completed("party-1", false),
+			completed("party-2", false),
+		} {
+			require.NoError(t, estimator.handlePartyServiceCompleted(ctx, event))
+		}
+		estimate, err := estimator.EstimateServiceTime(ctx, party)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, estimate.Duration, "duplicate services are not counted")
+
+		require.NoError(t, estimator.handlePartyServiceCompleted(ctx, completed("party-3", true)))
+		estimate, err = estimator.EstimateServiceTime(ctx, party)
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Minute, estimate.Duration, "timer ended services are learned too")
+	})
+
+	t.Run("estimate does not drift down while services overrun", func(t *testing.T) {
+		estimator := setup()
+		start := time.Now().Add(-3 * time.Hour)
+		for i := 0; i < 3; i++ {
+			record(t, estimator, fmt.Sprintf("party-%d", i), 2, start, 30*time.Minute)
+		}
+
+		// every party would stay 45 minutes, but is ended by the timer at the estimate,
+		// only a few leave early by staff.
+		for round := 0; round < 10; round++ {
+			estimate, err := estimator.EstimateServiceTime(ctx, party)
+			require.NoError(t, err)
+			require.Equal(t, 30*time.Minute, estimate.Duration, "round %d", round)
+
+			checkedInAt := start.Add(time.Duration(round+1) * time.Minute)
+			for i, ended := range []time.Duration{estimate.Duration - 5*time.Minute, estimate.Duration, estimate.Duration} {
+				require.NoError(t, estimator.handlePartyServiceCompleted(ctx, &hdd.PartyServiceCompeletedEvent{
+					RestaurantID: d.DefaultRestaurantID,
+					PartyID:      d.PartyID(fmt.Sprintf("round-%d-%d", round, i)),
+					PartySize:    2,
+					CheckedInAt:  checkedInAt,
+					CompletedAt:  checkedInAt.Add(ended),
+					EndedByTimer: i > 0,
+				}))
+			}
+		}
+	})
+}
@@ -2,11 +2,15 @@ package sse
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	log "queue-bite/internal/config/logger"
 	d "queue-bite/internal/domain"
+	wld "queue-bite/internal/features/waitlist/domain"
 	"queue-bite/internal/platform/eventbus"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var SSE = "sse"
@@ -15,12 +19,21 @@ var SSE = "sse"
 // Handles SSE connections and routes events to appropriate clients.
 type ServerSentEvents interface {
 	// RegisterClient establishes SSE connection with client browser.
-	// Sets up required headers and begins streaming for specified party.
-	RegisterClient(w http.ResponseWriter, partyID d.PartyID)
+	// Sets up required headers and begins streaming for specified party of restaurant.
+	// A party could hold several connections, such as tabs or phones of its diners, each gets every update.
+	RegisterClient(w http.ResponseWriter, restaurantID d.RestaurantID, partyID d.PartyID) *Client
 
-	// UnregisterClient removes client connection and cleans up resources.
+	// UnregisterClient removes client connection and cleans up resources, other connections of its party stay.
 	// Called when client disconnects or connection times out.
-	UnregisterClient(partyID d.PartyID)
+	UnregisterClient(client *Client)
+
+	// ReplayPartyState streams the latest state of party to a client on connect,
+	// as events missed before connecting or while reconnecting are not kept.
+	ReplayPartyState(client *Client, party *wld.QueuedParty)
+
+	// KeepAlive writes a comment to client every heartbeat interval, so proxies keep idle connection open.
+	// Blocks until ctx is done.
+	KeepAlive(ctx context.Context, client *Client)
 
 	// HandleNotifyPartyReady processes ready status events.
 	// Streams notification to client when their party becomes ready.
@@ -29,64 +42,236 @@ type ServerSentEvents interface {
 	// HandleNotifyPartyQueueStatusUpdate processes queue updates.
 	// Streams queue position and wait time updates to connected clients.
 	HandleNotifyPartyQueueStatusUpdate(ctx context.Context, event eventbus.Event) error
+
+	// RegisterHostClient establishes SSE connection with a host dashboard of restaurant.
+	// Host clients receive every host desk update of their restaurant regardless of party.
+	RegisterHostClient(w http.ResponseWriter, restaurantID d.RestaurantID) *Client
+
+	// UnregisterHostClient removes the host dashboard connection.
+	UnregisterHostClient(client *Client)
+
+	// HandleNotifyHostDeskUpdate streams refresh signal to host dashboards.
+	HandleNotifyHostDeskUpdate(ctx context.Context, event eventbus.Event) error
+
+	// CountClients tells how many connections of parties and host dashboards of restaurant this instance holds.
+	CountClients(restaurantID d.RestaurantID) (parties int, hosts int)
 }
 
 type sse struct {
-	logger   log.Logger
-	eventbus eventbus.EventBus
-	clients  map[d.PartyID]*Client
-	mu       sync.RWMutex
+	logger    log.Logger
+	eventbus  eventbus.EventBus
+	clients   map[clientKey]map[*Client]struct{}
+	hosts     map[*Client]struct{}
+	mu        sync.RWMutex
+	heartbeat time.Duration
+	retry     time.Duration
+	// eventIDs numbers events written by this server, for browsers to send the last one back on reconnect
+	eventIDs atomic.Uint64
+}
+
+type ServerSentEventsOption func(*sse)
+
+// WithHeartbeat sets how often idle connections get a comment, defaults to 15 seconds.
+func WithHeartbeat(interval time.Duration) ServerSentEventsOption {
+	return func(s *sse) {
+		s.heartbeat = interval
+	}
+}
+
+// WithRetry sets how long browsers wait before reconnecting a dropped connection, defaults to 3 seconds.
+func WithRetry(retry time.Duration) ServerSentEventsOption {
+	return func(s *sse) {
+		s.retry = retry
+	}
+}
+
+type clientKey struct {
+	restaurantID d.RestaurantID
+	partyID      d.PartyID
 }
 
 type Client struct {
-	PartyID d.PartyID
-	Writer  http.ResponseWriter
-	Done    chan struct{}
+	RestaurantID d.RestaurantID
+	PartyID      d.PartyID
+	Writer       http.ResponseWriter
+	Done         chan struct{}
+
+	// mu serializes writes of events and heartbeats, none is written once client is closed
+	mu     sync.Mutex
+	closed bool
 }
 
-func NewServerSentEvent(logger log.Logger, eventbus eventbus.EventBus) ServerSentEvents {
+// NewServerSentEvent subscribes to events streamed to clients, it fails if any of them could not be subscribed.
+func NewServerSentEvent(logger log.Logger, eventbus eventbus.EventBus, opts ...ServerSentEventsOption) (ServerSentEvents, error) {
 	svc := &sse{
-		logger:   logger,
-		eventbus: eventbus,
-		clients:  make(map[d.PartyID]*Client),
-		mu:       sync.RWMutex{},
+		logger:    logger,
+		eventbus:  eventbus,
+		clients:   make(map[clientKey]map[*Client]struct{}),
+		hosts:     make(map[*Client]struct{}),
+		mu:        sync.RWMutex{},
+		heartbeat: 15 * time.Second,
+		retry:     3 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(svc)
 	}
 
-	svc.subscribeToEvents()
-	return svc
+	if err := svc.subscribeToEvents(); err != nil {
+		return nil, err
+	}
+	return svc, nil
 }
 
-func (s *sse) subscribeToEvents() {
-	s.eventbus.Subscribe(TopicNotifyPartyReady, s.HandleNotifyPartyReady)
-	s.eventbus.Subscribe(TopicNotifyPartyQueueStatusUpdate, s.HandleNotifyPartyQueueStatusUpdate)
+func (s *sse) subscribeToEvents() error {
+	handlers := map[string]eventbus.Handler{
+		TopicNotifyPartyReady:             s.HandleNotifyPartyReady,
+		TopicNotifyPartyQueueStatusUpdate: s.HandleNotifyPartyQueueStatusUpdate,
+		TopicNotifyHostDeskUpdate:         s.HandleNotifyHostDeskUpdate,
+	}
+	for topic, handler := range handlers {
+		if _, err := s.eventbus.Subscribe(topic, handler); err != nil {
+			s.logger.LogErr(SSE, err, "could not subscribe to events streamed to clients", "topic", topic)
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *sse) RegisterClient(w http.ResponseWriter, partyID d.PartyID) {
+func (s *sse) RegisterClient(w http.ResponseWriter, restaurantID d.RestaurantID, partyID d.PartyID) *Client {
 	client := &Client{
-		PartyID: partyID,
-		Writer:  w,
-		Done:    make(chan struct{}),
+		RestaurantID: restaurantID,
+		PartyID:      partyID,
+		Writer:       w,
+		Done:         make(chan struct{}),
 	}
+	s.writeRetry(client)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.clients[partyID] = client
+	key := clientKey{restaurantID, partyID}
+	if _, exists := s.clients[key]; !exists {
+		s.clients[key] = make(map[*Client]struct{})
+	}
+	s.clients[key][client] = struct{}{}
+	return client
 }
 
-func (s *sse) UnregisterClient(partyID d.PartyID) {
+func (s *sse) UnregisterClient(client *Client) {
+	client.close()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.clients, partyID)
+	key := clientKey{client.RestaurantID, client.PartyID}
+	delete(s.clients[key], client)
+	if len(s.clients[key]) == 0 {
+		delete(s.clients, key)
+	}
 }
 
-func (s *sse) getClient(partyID d.PartyID) *Client {
+// getClients lists every connection of party of restaurant, empty if none is on this server.
+func (s *sse) getClients(restaurantID d.RestaurantID, partyID d.PartyID) []*Client {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	client, exists := s.clients[partyID]
-	if !exists {
-		return nil
-	} else {
-		return client
+	connected := s.clients[clientKey{restaurantID, partyID}]
+	clients := make([]*Client, 0, len(connected))
+	for client := range connected {
+		clients = append(clients, client)
 	}
+	return clients
+}
+
+func (s *sse) RegisterHostClient(w http.ResponseWriter, restaurantID d.RestaurantID) *Client {
+	client := &Client{
+		RestaurantID: restaurantID,
+		Writer:       w,
+		Done:         make(chan struct{}),
+	}
+	s.writeRetry(client)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hosts[client] = struct{}{}
+	return client
+}
+
+func (s *sse) UnregisterHostClient(client *Client) {
+	client.close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.hosts, client)
+}
+
+func (s *sse) getHostClients(restaurantID d.RestaurantID) []*Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	clients := make([]*Client, 0, len(s.hosts))
+	for client := range s.hosts {
+		if client.RestaurantID == restaurantID {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+func (s *sse) CountClients(restaurantID d.RestaurantID) (parties int, hosts int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, clients := range s.clients {
+		if key.restaurantID == restaurantID {
+			parties += len(clients)
+		}
+	}
+	for client := range s.hosts {
+		if client.RestaurantID == restaurantID {
+			hosts++
+		}
+	}
+	return parties, hosts
+}
+
+func (s *sse) KeepAlive(ctx context.Context, client *Client) {
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			client.write(func() {
+				fmt.Fprintf(client.Writer, ": keepalive\n\n")
+			})
+		}
+	}
+}
+
+// writeRetry tells browser how long to wait before reconnecting, once connection drops.
+func (s *sse) writeRetry(client *Client) {
+	client.write(func() {
+		fmt.Fprintf(client.Writer, "retry: %d\n\n", s.retry.Milliseconds())
+	})
+}
+
+// write runs write against writer of client and flushes it, unless client is closed.
+func (c *Client) write(write func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	write()
+	c.Writer.(http.Flusher).Flush()
+}
+
+// close stops writes to client, its writer is not to be used once the connection handler returns.
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
 }
@@ -0,0 +1,127 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	wld "queue-bite/internal/features/waitlist/domain"
+	"queue-bite/internal/platform/eventbus"
+	"queue-bite/internal/platform/eventbus/inmemory"
+)
+
+func TestPartyConnections(t *testing.T) {
+	ctx := context.Background()
+	registry := eventbus.NewEventRegistry()
+	registry.Register(TopicNotifyPartyReady, &NotifyPartyReadyEvent{})
+	bus := inmemory.NewInMemoryEventBus(log.NewNoopLogger(), registry, inmemory.WithSynchronousDelivery())
+	svc, err := NewServerSentEvent(log.NewNoopLogger(), bus)
+	require.NoError(t, err)
+
+	partyID := d.PartyID("test-party-1")
+	tab, phone, other := httptest.NewRecorder(), httptest.NewRecorder(), httptest.NewRecorder()
+	tabClient := svc.RegisterClient(tab, d.DefaultRestaurantID, partyID)
+	svc.RegisterClient(phone, d.DefaultRestaurantID, partyID)
+	svc.RegisterClient(other, d.DefaultRestaurantID, "test-party-2")
+
+	t.Run("every connection of party gets notified", func(t *testing.T) {
+		require.NoError(t, bus.Publish(ctx, &NotifyPartyReadyEvent{RestaurantID: d.DefaultRestaurantID, PartyID: partyID}))
+
+		assert.Contains(t, tab.Body.String(), "event: "+TopicNotifyPartyReady)
+		assert.Contains(t, phone.Body.String(), "event: "+TopicNotifyPartyReady)
+		assert.NotContains(t, other.Body.String(), "event: ")
+
+		parties, hosts := svc.CountClients(d.DefaultRestaurantID)
+		assert.Equal(t, 3, parties)
+		assert.Equal(t, 0, hosts)
+	})
+
+	t.Run("closed connection leaves others of party connected", func(t *testing.T) {
+		svc.UnregisterClient(tabClient)
+		tab.Body.Reset()
+		phone.Body.Reset()
+
+		require.NoError(t, bus.Publish(ctx, &NotifyPartyReadyEvent{RestaurantID: d.DefaultRestaurantID, PartyID: partyID}))
+
+		assert.Empty(t, tab.Body.String(), "nothing is written once connection is closed")
+		assert.Contains(t, phone.Body.String(), "event: "+TopicNotifyPartyReady)
+
+		parties, _ := svc.CountClients(d.DefaultRestaurantID)
+		assert.Equal(t, 2, parties)
+	})
+}
+
+func TestConnectionResilience(t *testing.T) {
+	ctx := context.Background()
+	registry := eventbus.NewEventRegistry()
+	registry.Register(TopicNotifyPartyQueueStatusUpdate, &NotifyPartyQueueStatusUpdateEvent{})
+	bus := inmemory.NewInMemoryEventBus(log.NewNoopLogger(), registry, inmemory.WithSynchronousDelivery())
+	svc, err := NewServerSentEvent(log.NewNoopLogger(), bus, WithHeartbeat(10*time.Millisecond), WithRetry(2*time.Second))
+	require.NoError(t, err)
+
+	party := &wld.QueuedParty{Party: &d.Party{ID: "test-party-1", Name: "test-party-name", Status: d.PartyStatusWaiting, Size: 2}, Position: 1}
+
+	t.Run("tell browser when to reconnect and number every event", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		client := svc.RegisterClient(w, d.DefaultRestaurantID, party.ID)
+		defer svc.UnregisterClient(client)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, bus.Publish(ctx, &NotifyPartyQueueStatusUpdateEvent{RestaurantID: d.DefaultRestaurantID, QueuedParty: party}))
+		}
+
+		events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		require.Len(t, events, 3)
+		assert.Equal(t, "retry: 2000", events[0])
+		assert.True(t, strings.HasPrefix(events[1], "id: "))
+		assert.True(t, strings.HasPrefix(events[2], "id: "))
+		assert.NotEqual(t, strings.SplitN(events[1], "\n", 2)[0], strings.SplitN(events[2], "\n", 2)[0])
+	})
+
+	t.Run("keep idle connection alive until it closes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		client := svc.RegisterClient(w, d.DefaultRestaurantID, party.ID)
+		defer svc.UnregisterClient(client)
+
+		keepAliveCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		svc.KeepAlive(keepAliveCtx, client)
+
+		assert.Contains(t, w.Body.String(), ": keepalive\n\n")
+	})
+
+	t.Run("replay latest state of party", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		client := svc.RegisterClient(w, d.DefaultRestaurantID, party.ID)
+		defer svc.UnregisterClient(client)
+
+		svc.ReplayPartyState(client, party)
+		assert.Contains(t, w.Body.String(), "event: "+TopicNotifyPartyQueueStatusUpdate)
+
+		ready := &wld.QueuedParty{Party: &d.Party{ID: party.ID, Status: d.PartyStatusReady}}
+		svc.ReplayPartyState(client, ready)
+		assert.Contains(t, w.Body.String(), "event: "+TopicNotifyPartyReady)
+	})
+}
+
+// unsubscribableBus fails every subscription, like a stream bus whose consumer group could not be created.
+type unsubscribableBus struct {
+	eventbus.EventBus
+}
+
+func (unsubscribableBus) Subscribe(string, eventbus.Handler, ...eventbus.SubscribeOption) (eventbus.SubscriptionID, error) {
+	return eventbus.SubscriptionID{}, errors.New("could not create consumer group")
+}
+
+func TestSubscriptionFailure(t *testing.T) {
+	_, err := NewServerSentEvent(log.NewNoopLogger(), unsubscribableBus{})
+	require.Error(t, err, "clients would never receive events streamed to them")
+}
@@ -3,44 +3,79 @@ package sse
 import (
 	"context"
 	"fmt"
-	"net/http"
 
 	"github.com/a-h/templ"
 
+	d "queue-bite/internal/domain"
 	"queue-bite/internal/features/seatmanager/handler/view"
+	wld "queue-bite/internal/features/waitlist/domain"
 	"queue-bite/internal/platform/eventbus"
 )
 
 func (s *sse) HandleNotifyPartyReady(ctx context.Context, event eventbus.Event) error {
 	e := event.(*NotifyPartyReadyEvent)
-	client := s.getClient(e.PartyID)
-	if client == nil {
+	clients := s.getClients(e.RestaurantID, e.PartyID)
+	if len(clients) == 0 {
 		s.logger.LogDebug(SSE, "no registered client found on this server", "party id", e.PartyID)
 		return nil
 	}
 
-	notifyClient(client, TopicNotifyPartyReady, view.QueueStatusView(view.NewReadyPartyProps(e.PartyID)))
-	s.logger.LogDebug(SSE, "write seat ready button for next party", "party id", e.PartyID)
+	props := view.NewReadyPartyProps(e.PartyID)
+	for _, client := range clients {
+		s.notifyClient(client, TopicNotifyPartyReady, view.QueueStatus(props))
+	}
+	s.logger.LogDebug(SSE, "write seat ready button for next party", "party id", e.PartyID, "clients", len(clients))
 	return nil
 }
 
 func (s *sse) HandleNotifyPartyQueueStatusUpdate(ctx context.Context, event eventbus.Event) error {
 	e := event.(*NotifyPartyQueueStatusUpdateEvent)
-	client := s.getClient(e.QueuedParty.ID)
-	if client == nil {
+	clients := s.getClients(e.RestaurantID, e.QueuedParty.ID)
+	if len(clients) == 0 {
 		s.logger.LogDebug(SSE, "no registered client found on this server", "party id", e.QueuedParty.ID)
 		return nil
 	}
 
-	notifyClient(client, TopicNotifyPartyQueueStatusUpdate, view.QueueStatusView(view.NewQueuedPartyProps(e.QueuedParty)))
-	s.logger.LogDebug(SSE, "update queue status for waiting party", "party id", e.QueuedParty.ID)
+	props := view.NewQueuedPartyProps(e.QueuedParty)
+	for _, client := range clients {
+		s.notifyClient(client, TopicNotifyPartyQueueStatusUpdate, view.QueueStatus(props))
+	}
+	s.logger.LogDebug(SSE, "update queue status for waiting party", "party id", e.QueuedParty.ID, "clients", len(clients))
+	return nil
+}
+
+func (s *sse) HandleNotifyHostDeskUpdate(ctx context.Context, event eventbus.Event) error {
+	e := event.(*NotifyHostDeskUpdateEvent)
+	for _, client := range s.getHostClients(e.RestaurantID) {
+		id := s.eventIDs.Add(1)
+		client.write(func() {
+			fmt.Fprintf(client.Writer, "id: %d\n", id)
+			fmt.Fprintf(client.Writer, "event: %s\n", TopicNotifyHostDeskUpdate)
+			fmt.Fprintf(client.Writer, "data: refresh\n\n")
+		})
+	}
+	s.logger.LogDebug(SSE, "notify host dashboards for refresh", "restaurant id", e.RestaurantID)
 	return nil
 }
 
-func notifyClient(client *Client, eventName string, comp templ.Component) {
-	fmt.Fprintf(client.Writer, "event: %s\n", eventName)
-	fmt.Fprintf(client.Writer, "data: ")
-	comp.Render(context.Background(), client.Writer)
-	fmt.Fprintf(client.Writer, "\n\n")
-	client.Writer.(http.Flusher).Flush()
+func (s *sse) ReplayPartyState(client *Client, party *wld.QueuedParty) {
+	props := view.NewQueuedPartyProps(party)
+	eventName := TopicNotifyPartyQueueStatusUpdate
+	if props.ReadyForSeating {
+		eventName = TopicNotifyPartyReady
+	}
+
+	s.notifyClient(client, eventName, view.QueueStatus(props))
+	s.logger.LogDebug(SSE, "replay latest state for connected party", "party id", party.ID, "ready", props.ReadyForSeating)
+}
+
+func (s *sse) notifyClient(client *Client, eventName string, comp templ.Component) {
+	id := s.eventIDs.Add(1)
+	client.write(func() {
+		fmt.Fprintf(client.Writer, "id: %d\n", id)
+		fmt.Fprintf(client.Writer, "event: %s\n", eventName)
+		fmt.Fprintf(client.Writer, "data: ")
+		comp.Render(d.WithRestaurantID(context.Background(), client.RestaurantID), client.Writer)
+		fmt.Fprintf(client.Writer, "\n\n")
+	})
 }
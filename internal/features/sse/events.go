@@ -7,7 +7,8 @@ import (
 )
 
 type NotifyPartyReadyEvent struct {
-	PartyID d.PartyID
+	RestaurantID d.RestaurantID
+	PartyID      d.PartyID
 }
 
 func (e NotifyPartyReadyEvent) Topic() string {
@@ -21,10 +22,12 @@ func (e NotifyPartyReadyEvent) NewEvent() eventbus.Event {
 const (
 	TopicNotifyPartyReady             = "notify:party:ready"
 	TopicNotifyPartyQueueStatusUpdate = "notify:party:queue_update"
+	TopicNotifyHostDeskUpdate         = "notify:host:update"
 )
 
 type NotifyPartyQueueStatusUpdateEvent struct {
-	QueuedParty *wld.QueuedParty
+	RestaurantID d.RestaurantID
+	QueuedParty  *wld.QueuedParty
 }
 
 func (e NotifyPartyQueueStatusUpdateEvent) Topic() string {
@@ -34,3 +37,17 @@ func (e NotifyPartyQueueStatusUpdateEvent) Topic() string {
 func (e NotifyPartyQueueStatusUpdateEvent) NewEvent() eventbus.Event {
 	return &NotifyPartyQueueStatusUpdateEvent{}
 }
+
+// NotifyHostDeskUpdateEvent signals host dashboards that the waitlist or
+// seating state has changed and their view should be refreshed.
+type NotifyHostDeskUpdateEvent struct {
+	RestaurantID d.RestaurantID
+}
+
+func (e NotifyHostDeskUpdateEvent) Topic() string {
+	return TopicNotifyHostDeskUpdate
+}
+
+func (e NotifyHostDeskUpdateEvent) NewEvent() eventbus.Event {
+	return &NotifyHostDeskUpdateEvent{}
+}
@@ -7,7 +7,9 @@ import (
 
 	log "queue-bite/internal/config/logger"
 	"queue-bite/internal/domain"
+	smd "queue-bite/internal/features/seatmanager/domain"
 	sse "queue-bite/internal/features/sse"
+	"queue-bite/pkg/session"
 
 	waitlist "queue-bite/internal/features/waitlist/service"
 )
@@ -15,6 +17,9 @@ import (
 func HandleQueuedPartyServerSentEventConn(
 	logger log.Logger,
 	sse sse.ServerSentEvents,
+	streamTokens *session.TokenSigner,
+	cookieManager *session.CookieManager,
+	cookieQueuedParty *session.CookieConfig,
 	waitlist waitlist.Waitlist,
 ) http.HandlerFunc {
 
@@ -22,6 +27,18 @@ func HandleQueuedPartyServerSentEventConn(
 		id := chi.URLParam(r, "partyID")
 		partyID := domain.PartyID(id)
 
+		// party id alone is no proof of being the party, the stream link carries a token signed for it.
+		// The link is not re-issued while the page stays open, so a browser reconnecting after the token
+		// expired proves it is the party by its session cookie instead.
+		if err := streamTokens.Verify(r.URL.Query().Get("token"), id); err != nil {
+			var partySession smd.PartySession
+			if cookieErr := cookieManager.GetCookie(w, r, cookieQueuedParty, &partySession); cookieErr != nil || partySession.ID != partyID {
+				logger.LogDebug("sse/conn", "reject party server sent event connection", "party_id", partyID, "err", err)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+		}
+
 		if !waitlist.HasPartyExists(r.Context(), partyID) {
 			logger.LogDebug("sse/conn", "could not find party in waitlist queue", "party_id", partyID)
 			return
@@ -31,10 +48,38 @@ func HandleQueuedPartyServerSentEventConn(
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "keep-alive")
 
-		sse.RegisterClient(w, partyID)
-		defer sse.UnregisterClient(partyID)
+		restaurantID := domain.RestaurantIDFromContext(r.Context())
+		client := sse.RegisterClient(w, restaurantID, partyID)
+		defer sse.UnregisterClient(client)
 
-		<-r.Context().Done()
+		// events are not kept for clients away, so whether the page just loaded or the browser reconnected,
+		// the latest state is sent in case the party moved or became ready meanwhile
+		party, err := waitlist.GetQueuedParty(r.Context(), partyID)
+		if err != nil {
+			logger.LogErr("sse/conn", err, "could not get party to replay its state", "party_id", partyID)
+		} else if party != nil {
+			sse.ReplayPartyState(client, party)
+		}
+
+		sse.KeepAlive(r.Context(), client)
 		logger.LogDebug("sse/conn", "party server sent event disconnected", "party_id", partyID)
 	}
 }
+
+func HandleHostDeskServerSentEventConn(
+	logger log.Logger,
+	sse sse.ServerSentEvents,
+) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		client := sse.RegisterHostClient(w, domain.RestaurantIDFromContext(r.Context()))
+		defer sse.UnregisterHostClient(client)
+
+		sse.KeepAlive(r.Context(), client)
+		logger.LogDebug("sse/conn", "host desk server sent event disconnected")
+	}
+}
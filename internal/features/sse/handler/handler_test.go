@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/domain"
+	smd "queue-bite/internal/features/seatmanager/domain"
+	servicetime "queue-bite/internal/features/servicetime/service"
+	"queue-bite/internal/features/sse"
+	"queue-bite/internal/features/waitlist/repository"
+	waitlist "queue-bite/internal/features/waitlist/service"
+	"queue-bite/internal/platform/eventbus"
+	"queue-bite/internal/platform/eventbus/inmemory"
+	"queue-bite/pkg/session"
+)
+
+// partyCookie is the session cookie of party set by a cookie manager, as sent back by the browser.
+func partyCookie(t *testing.T, cookieManager *session.CookieManager, conf *session.CookieConfig, partyID domain.PartyID) *http.Cookie {
+	w := httptest.NewRecorder()
+	require.NoError(t, cookieManager.SetCookie(w, conf, &smd.PartySession{ID: partyID, Name: "Alice", Size: 2}))
+	return w.Result().Cookies()[0]
+}
+
+func TestQueuedPartyStreamAccess(t *testing.T) {
+	signer, err := session.NewTokenSigner("12345678901234567890123456789012", time.Hour)
+	require.NoError(t, err)
+	signer.Fixed = time.Now()
+	cookieManager, err := session.NewCookieManager("12345678901234567890123456789012")
+	require.NoError(t, err)
+	cookieConfig := session.NewCookieConfig("qb_qp", "localhost")
+
+	// rejected connections never reach sse nor waitlist
+	r := chi.NewRouter()
+	r.Get("/sse/waitlist/{partyID}", HandleQueuedPartyServerSentEventConn(log.NewNoopLogger(), nil, signer, cookieManager, cookieConfig, nil))
+
+	expired := *signer
+	expired.Fixed = signer.Fixed.Add(-2 * time.Hour)
+
+	token := signer.Sign("party-1")
+	cases := map[string]string{
+		"missing token":          "/sse/waitlist/party-1",
+		"token of another party": "/sse/waitlist/party-2?token=" + token,
+		"tampered token":         "/sse/waitlist/party-1?token=" + token + "A",
+		"expired token":          "/sse/waitlist/party-1?token=" + expired.Sign("party-1"),
+	}
+	for name, target := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+
+	t.Run("expired token with session cookie of another party", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/sse/waitlist/party-1?token="+expired.Sign("party-1"), nil)
+		req.AddCookie(partyCookie(t, cookieManager, cookieConfig, "party-2"))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestQueuedPartyStreamReplay(t *testing.T) {
+	signer, err := session.NewTokenSigner("12345678901234567890123456789012", time.Hour)
+	require.NoError(t, err)
+
+	logger := log.NewNoopLogger()
+	bus := inmemory.NewInMemoryEventBus(logger, eventbus.NewEventRegistry(), inmemory.WithSynchronousDelivery())
+	wl := waitlist.NewWaitlistService(logger,
+		domain.DefaultRestaurantID,
+		repository.NewInMemoryWaitlistRepository(logger, time.Hour, 5),
+		servicetime.NewFixedRateEstimator(time.Minute),
+		bus)
+	party, err := wl.JoinQueue(context.Background(), &domain.Party{ID: "party-1", Name: "Alice", Size: 2})
+	require.NoError(t, err)
+
+	cookieManager, err := session.NewCookieManager("12345678901234567890123456789012")
+	require.NoError(t, err)
+	cookieConfig := session.NewCookieConfig("qb_qp", "localhost")
+
+	events, err := sse.NewServerSentEvent(logger, bus)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/sse/waitlist/{partyID}", HandleQueuedPartyServerSentEventConn(logger, events, signer, cookieManager, cookieConfig, wl))
+
+	// a page loading its status stream has no Last-Event-ID, it still gets the latest state
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sse/waitlist/party-1?token="+signer.Sign(string(party.ID)), nil).WithContext(ctx))
+
+	assert.Contains(t, w.Body.String(), "event: "+sse.TopicNotifyPartyQueueStatusUpdate)
+	// swapping in the replayed state must not connect the stream again, or every connect would replay once more
+	assert.NotContains(t, w.Body.String(), "sse-connect")
+
+	t.Run("reconnect after stream token expired", func(t *testing.T) {
+		expired := *signer
+		expired.Fixed = time.Now().Add(-2 * time.Hour)
+
+		// the browser reconnects to the link the page was rendered with, along with the session cookie
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, "/sse/waitlist/party-1?token="+expired.Sign(string(party.ID)), nil).WithContext(ctx)
+		req.AddCookie(partyCookie(t, cookieManager, cookieConfig, party.ID))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "event: "+sse.TopicNotifyPartyQueueStatusUpdate)
+	})
+}
@@ -14,9 +14,18 @@ type QueuedParty struct {
 	// Total time this party expects to wait before being served.
 	EstimatedEndOfServiceTime time.Duration
 	JoinedAt                  time.Time
+	// SkipCount is how many times vacancy went to parties behind this one.
+	SkipCount int
+	// EstimatedSeatingAt is when party is forecast to be seated against tables in use, zero if not forecast.
+	EstimatedSeatingAt time.Time
 }
 
+// RemainingWaitTime is how long party is expected to wait from now on, by its seating forecast if any,
+// otherwise by service time of parties ahead of it summed up.
 func (p *QueuedParty) RemainingWaitTime() time.Duration {
+	if !p.EstimatedSeatingAt.IsZero() {
+		return max(time.Until(p.EstimatedSeatingAt), 0).Round(time.Second)
+	}
 	return p.EstimatedEndOfServiceTime - p.EstimatedServiceTime
 }
 
@@ -26,4 +35,6 @@ type QueueStatus struct {
 	WaitingParties int
 	// Estimated wait time for a new party joining now
 	CurrentWaitTime time.Duration
+	// EstimatedSeatingAt is when a new party joining now is forecast to be seated, zero if not forecast.
+	EstimatedSeatingAt time.Time
 }
@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/features/waitlist/domain"
+)
+
+var INMEMORY_WAITLIST = "waitlist/in-memory"
+
+// inMemoryQueue keeps parties of an area queue in join order, like the redis sorted set
+// parties joined within the same second are ordered by their id.
+type inMemoryQueue struct {
+	entries []queueEntry
+	// expiresAt is ttl after the latest join, the queue is emptied once passed
+	expiresAt time.Time
+	// totalWait is the prefix sum of service time of every party joined, in seconds
+	totalWait int64
+	// totalService is service time of parties left from the head of queue, in seconds
+	totalService int64
+	waiting      int
+	// forecast is the stored queue forecast in unix seconds, zero if not forecast
+	forecast int64
+}
+
+type queueEntry struct {
+	id    d.PartyID
+	score int64
+}
+
+// partyWait is service time of parties ahead summed up with the party's own, in seconds.
+type partyWait struct {
+	prefix    int64
+	expiresAt time.Time
+}
+
+type inMemoryParty struct {
+	details *domain.QueuedParty
+	// forecast is the stored seating forecast in unix seconds, zero if not forecast
+	forecast int64
+}
+
+// InMemoryWaitlistRepository keeps waitlist queues in process, for a single instance deployment and tests.
+// It follows the redis repository step by step: positions, prefix sum wait times, the waiting party counter
+// and expiry of the queue and wait times ttl after joining, durations kept at second precision.
+type InMemoryWaitlistRepository struct {
+	logger    log.Logger
+	ttl       time.Duration
+	scanRange int
+
+	mu      sync.Mutex
+	queues  map[d.SeatingArea]*inMemoryQueue
+	parties map[d.PartyID]*inMemoryParty
+	waits   map[d.PartyID]*partyWait
+	codes   map[string]*recoveryCode
+}
+
+// recoveryCode is the party a recovery code was saved for, until it expires ttl after.
+type recoveryCode struct {
+	partyID   d.PartyID
+	expiresAt time.Time
+}
+
+func NewInMemoryWaitlistRepository(logger log.Logger, ttl time.Duration, scanRange int) *InMemoryWaitlistRepository {
+	return &InMemoryWaitlistRepository{
+		logger:    logger,
+		ttl:       ttl,
+		scanRange: scanRange,
+		queues:    make(map[d.SeatingArea]*inMemoryQueue),
+		parties:   make(map[d.PartyID]*inMemoryParty),
+		waits:     make(map[d.PartyID]*partyWait),
+		codes:     make(map[string]*recoveryCode),
+	}
+}
+
+func (r *InMemoryWaitlistRepository) HasParty(ctx context.Context, partyID d.PartyID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.parties[partyID]
+	return exists
+}
+
+func (r *InMemoryWaitlistRepository) AddParty(ctx context.Context, party *domain.QueuedParty) (*domain.QueuedParty, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	return r.addParty(party, now, now.Unix())
+}
+
+func (r *InMemoryWaitlistRepository) addParty(party *domain.QueuedParty, now time.Time, score int64) (*domain.QueuedParty, error) {
+	// like the redis repository, details are saved before joining the queue
+	r.parties[party.ID] = &inMemoryParty{details: partyDetails(party)}
+
+	queue := r.queue(party.Preference.QueueArea(), now)
+	queue.expiresAt = now.Add(r.ttl)
+	if queue.rank(party.ID) >= 0 {
+		return nil, domain.ErrPartyAlreadyQueued
+	}
+
+	entriesAhead := len(queue.entries)
+	queue.insert(queueEntry{id: party.ID, score: score})
+	queue.totalWait += int64(party.EstimatedServiceTime.Seconds())
+	r.waits[party.ID] = &partyWait{prefix: queue.totalWait, expiresAt: now.Add(r.ttl)}
+	if party.Status == d.PartyStatusWaiting {
+		queue.waiting++
+	}
+
+	party.Position = entriesAhead
+	party.EstimatedEndOfServiceTime = time.Duration(queue.totalWait-queue.totalService) * time.Second
+	return party, nil
+}
+
+// RemoveParty removes party from queue and updates wait time of parties behind it, see the redis repository
+// for how service time of the head of queue is counted instead of updating every party behind.
+// Returns ErrPartyNotFound if party doesn't exist in queue.
+func (r *InMemoryWaitlistRepository) RemoveParty(ctx context.Context, partyID d.PartyID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.removeParty(partyID, time.Now())
+}
+
+func (r *InMemoryWaitlistRepository) removeParty(partyID d.PartyID, now time.Time) error {
+	queue := r.queue(r.queueAreaOf(partyID), now)
+	rank := queue.rank(partyID)
+	party, exists := r.parties[partyID]
+	if rank < 0 || !exists {
+		err := domain.ErrPartyNotFound
+		r.logger.LogErr(INMEMORY_WAITLIST, err, "could not find the party in the queue list", "party id", partyID)
+		return err
+	}
+
+	est := int64(party.details.EstimatedServiceTime.Seconds())
+	if party.details.Status == d.PartyStatusWaiting {
+		queue.waiting--
+	}
+	if rank == 0 {
+		queue.totalService += est
+	} else {
+		for _, entry := range queue.entries[rank+1:] {
+			if wait, exists := r.waits[entry.id]; exists {
+				wait.prefix -= est
+			}
+		}
+	}
+
+	queue.entries = slices.Delete(queue.entries, rank, rank+1)
+	delete(r.parties, partyID)
+	delete(r.waits, partyID)
+	if len(queue.entries) == 0 {
+		queue.totalService = 0
+		queue.totalWait = 0
+	}
+
+	r.logger.LogDebug(INMEMORY_WAITLIST, "party left the waitlist", "position", rank, "estimated service time of party", est, "party id", partyID)
+	return nil
+}
+
+// MoveToBack leaves and joins again under the same lock, behind the latest party joined even within the same second.
+func (r *InMemoryWaitlistRepository) MoveToBack(ctx context.Context, party *domain.QueuedParty) (*domain.QueuedParty, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.queueAreaOf(party.ID) != party.Preference.QueueArea() {
+		r.logger.LogErr(INMEMORY_WAITLIST, domain.ErrPartyNotFound, "could not move party across area queues", "party", party)
+		return nil, domain.ErrPartyNotFound
+	}
+
+	now := time.Now()
+	if err := r.removeParty(party.ID, now); err != nil {
+		return nil, err
+	}
+
+	score := now.Unix()
+	entries := r.queue(party.Preference.QueueArea(), now).entries
+	if len(entries) > 0 && entries[len(entries)-1].score >= score {
+		score = entries[len(entries)-1].score + 1
+	}
+	return r.addParty(party, now, score)
+}
+
+func (r *InMemoryWaitlistRepository) GetParty(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	party, exists := r.parties[partyID]
+	if !exists {
+		return nil, nil
+	}
+	wait, exists := r.waits[partyID]
+	if !exists || !now.Before(wait.expiresAt) {
+		delete(r.waits, partyID)
+		return nil, nil
+	}
+	queue := r.queue(party.details.Preference.QueueArea(), now)
+	rank := queue.rank(partyID)
+	if rank < 0 {
+		return nil, nil
+	}
+
+	queuedParty := partyDetails(party.details)
+	queuedParty.Position = rank
+	if party.forecast != 0 {
+		queuedParty.EstimatedSeatingAt = time.Unix(party.forecast, 0)
+	}
+	queuedParty.EstimatedEndOfServiceTime = time.Duration(wait.prefix-queue.totalService) * time.Second
+	return queuedParty, nil
+}
+
+func (r *InMemoryWaitlistRepository) GetPartyDetails(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	party, exists := r.parties[partyID]
+	if !exists {
+		return nil, nil
+	}
+	return partyDetails(party.details), nil
+}
+
+func (r *InMemoryWaitlistRepository) GetQueueStatus(ctx context.Context, area d.SeatingArea) (*domain.QueueStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.queue(area, time.Now())
+	status := &domain.QueueStatus{
+		TotalParties:    len(queue.entries),
+		WaitingParties:  queue.waiting,
+		CurrentWaitTime: time.Duration(queue.totalWait-queue.totalService) * time.Second,
+	}
+	if queue.forecast != 0 {
+		status.EstimatedSeatingAt = time.Unix(queue.forecast, 0)
+	}
+	return status, nil
+}
+
+func (r *InMemoryWaitlistRepository) ScanParties(ctx context.Context, area d.SeatingArea) (<-chan *domain.QueuedParty, error) {
+	queuedParties := make(chan *domain.QueuedParty)
+
+	go func() {
+		defer close(queuedParties)
+
+		offset := 0
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			ids := r.scanRangeOf(area, offset)
+			if len(ids) == 0 {
+				return
+			}
+
+			for _, partyID := range ids {
+				queuedParty, err := r.GetParty(ctx, partyID)
+				if err != nil {
+					r.logger.LogErr(INMEMORY_WAITLIST, err, "error getting party", "party id", partyID)
+					continue
+				}
+
+				select {
+				case queuedParties <- queuedParty:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			offset += len(ids)
+		}
+	}()
+
+	return queuedParties, nil
+}
+
+func (r *InMemoryWaitlistRepository) UpdatePartyStatus(ctx context.Context, partyID d.PartyID, status d.PartyStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	party, exists := r.parties[partyID]
+	if !exists {
+		r.logger.LogDebug(INMEMORY_WAITLIST, "could not found party in waitlist queue for status update", "party id", partyID)
+		return nil
+	}
+
+	originalStatus := party.details.Status
+	party.details.Status = status
+	r.logger.LogDebug(INMEMORY_WAITLIST, "update party status", "party id", partyID, "status", status)
+
+	if status == d.PartyStatusReady && originalStatus == d.PartyStatusWaiting {
+		r.queue(party.details.Preference.QueueArea(), time.Now()).waiting--
+	}
+	return nil
+}
+
+func (r *InMemoryWaitlistRepository) IncrementSkipCount(ctx context.Context, partyIDs []d.PartyID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, partyID := range partyIDs {
+		if party, exists := r.parties[partyID]; exists {
+			party.details.SkipCount++
+		}
+	}
+
+	r.logger.LogDebug(INMEMORY_WAITLIST, "parties skipped", "party ids", partyIDs)
+	return nil
+}
+
+func (r *InMemoryWaitlistRepository) SaveSeatingForecast(ctx context.Context, partyID d.PartyID, seatingAt time.Time, threshold time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	party, exists := r.parties[partyID]
+	if !exists {
+		return false, nil
+	}
+
+	forecast := seatingAt.Unix()
+	if party.forecast != 0 && abs(forecast-party.forecast) < int64(threshold.Seconds()) {
+		return false, nil
+	}
+	party.forecast = forecast
+	return true, nil
+}
+
+func (r *InMemoryWaitlistRepository) SaveQueueForecast(ctx context.Context, area d.SeatingArea, seatingAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.queue(area, time.Now())
+	queue.forecast = seatingAt.Unix()
+	return nil
+}
+
+// SaveRecoveryCode drops expired codes on the way, as codes are not removed along with their party.
+func (r *InMemoryWaitlistRepository) SaveRecoveryCode(ctx context.Context, code string, partyID d.PartyID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for saved, recovery := range r.codes {
+		if !now.Before(recovery.expiresAt) {
+			delete(r.codes, saved)
+		}
+	}
+	r.codes[code] = &recoveryCode{partyID: partyID, expiresAt: now.Add(r.ttl)}
+	return nil
+}
+
+func (r *InMemoryWaitlistRepository) GetPartyIDByRecoveryCode(ctx context.Context, code string) (d.PartyID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recovery, exists := r.codes[code]
+	if !exists || !time.Now().Before(recovery.expiresAt) {
+		return "", nil
+	}
+	return recovery.partyID, nil
+}
+
+// queue gets queue of area, emptied if it expired by now.
+func (r *InMemoryWaitlistRepository) queue(area d.SeatingArea, now time.Time) *inMemoryQueue {
+	queue, exists := r.queues[area]
+	if !exists {
+		queue = &inMemoryQueue{}
+		r.queues[area] = queue
+	}
+	if !queue.expiresAt.IsZero() && !now.Before(queue.expiresAt) {
+		queue.entries = nil
+		queue.expiresAt = time.Time{}
+		queue.forecast = 0
+	}
+	return queue
+}
+
+// queueAreaOf finds which area queue party is kept in from its seating preference.
+func (r *InMemoryWaitlistRepository) queueAreaOf(partyID d.PartyID) d.SeatingArea {
+	var preference d.SeatingPreference
+	if party, exists := r.parties[partyID]; exists {
+		preference = party.details.Preference
+	}
+	return preference.QueueArea()
+}
+
+// scanRangeOf lists ids of up to scanRange parties of area queue from offset.
+func (r *InMemoryWaitlistRepository) scanRangeOf(area d.SeatingArea, offset int) []d.PartyID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.queue(area, time.Now()).entries
+	if offset >= len(entries) {
+		return nil
+	}
+	ids := []d.PartyID{}
+	for _, entry := range entries[offset:min(offset+r.scanRange, len(entries))] {
+		ids = append(ids, entry.id)
+	}
+	return ids
+}
+
+func (q *inMemoryQueue) rank(partyID d.PartyID) int {
+	return slices.IndexFunc(q.entries, func(entry queueEntry) bool {
+		return entry.id == partyID
+	})
+}
+
+func (q *inMemoryQueue) insert(entry queueEntry) {
+	i, _ := slices.BinarySearchFunc(q.entries, entry, func(a, b queueEntry) int {
+		if a.score != b.score {
+			return int(a.score - b.score)
+		}
+		return strings.Compare(string(a.id), string(b.id))
+	})
+	q.entries = slices.Insert(q.entries, i, entry)
+}
+
+// partyDetails copies details of party kept apart from queue information, service time at second precision.
+func partyDetails(party *domain.QueuedParty) *domain.QueuedParty {
+	details := *party.Party
+	details.EstimatedServiceTime = time.Duration(party.EstimatedServiceTime.Seconds()) * time.Second
+	return &domain.QueuedParty{
+		Party:     &details,
+		JoinedAt:  party.JoinedAt,
+		SkipCount: party.SkipCount,
+	}
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,16 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/features/waitlist/repository"
+	"queue-bite/internal/features/waitlist/repository/repositorytest"
+)
+
+func TestInMemoryWaitlistRepository(t *testing.T) {
+	repositorytest.RunWaitlistRepositorySuite(t, func(t *testing.T, ttl time.Duration, scanRange int) repository.WaitlistRepositoy {
+		return repository.NewInMemoryWaitlistRepository(log.NewNoopLogger(), ttl, scanRange)
+	})
+}
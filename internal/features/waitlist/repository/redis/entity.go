@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"reflect"
+
 	d "queue-bite/internal/domain"
 	"queue-bite/internal/features/waitlist/domain"
 	"time"
@@ -15,16 +17,27 @@ type redisQueuedParty struct {
 	Size     int           `redis:"size"`
 	JoinedAt time.Time     `redis:"joined_at"`
 	Status   d.PartyStatus `redis:"status"`
+	// Preference decides which area queue the party is kept in
+	Preference d.SeatingPreference `redis:"preference"`
+	// SkipCount is how many times party was passed over for parties behind it
+	SkipCount int `redis:"skips"`
+	// ReservationID is set for party arrived for its reservation
+	ReservationID string `redis:"reservation"`
 
 	// Queue-specific fields
 	Position             int `redis:"-"` // Computed from ZRANK
 	EstimatedServiceTime int `redis:"est"`
+	// SeatingForecast is the stored seating forecast in unix seconds, zero if not forecast
+	SeatingForecast int64 `redis:"eta"`
 }
 
 func (r *redisQueuedParty) asQueuedParty() *domain.QueuedParty {
 	party := &domain.QueuedParty{}
 	copier.Copy(party, r)
 	party.EstimatedServiceTime = time.Duration(r.EstimatedServiceTime) * time.Second
+	if r.SeatingForecast != 0 {
+		party.EstimatedSeatingAt = time.Unix(r.SeatingForecast, 0)
+	}
 	return party
 }
 
@@ -34,3 +47,17 @@ func newRedisQueuedParty(party *domain.QueuedParty) *redisQueuedParty {
 	entity.EstimatedServiceTime = int(party.EstimatedServiceTime.Seconds())
 	return entity
 }
+
+// fields flattens entity into field value pairs by their redis tags, the way HSet stores a struct.
+func (r *redisQueuedParty) fields() []interface{} {
+	value := reflect.ValueOf(r).Elem()
+	fields := []interface{}{}
+	for i := 0; i < value.NumField(); i++ {
+		tag := value.Type().Field(i).Tag.Get("redis")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fields = append(fields, tag, value.Field(i).Interface())
+	}
+	return fields
+}
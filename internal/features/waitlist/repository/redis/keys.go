@@ -5,39 +5,52 @@ import (
 	"queue-bite/internal/domain"
 )
 
-type queueKeys struct{}
+// queueKeys namespaces every waitlist key by restaurant.
+type queueKeys struct {
+	restaurantID domain.RestaurantID
+}
+
+// queue:<restaurant>:<area>:waiting
+func (k *queueKeys) waitingQueue(area domain.SeatingArea) string {
+	return fmt.Sprintf("queue:%s:%s:waiting", k.restaurantID, area)
+}
 
-// queue:waiting
-func (k *queueKeys) waitingQueue() string {
-	return "queue:waiting"
+// queue:<restaurant>:<area>:eta
+func (k *queueKeys) queueForecast(area domain.SeatingArea) string {
+	return fmt.Sprintf("queue:%s:%s:eta", k.restaurantID, area)
 }
 
-// queue:party:<id>
+// queue:<restaurant>:party:<id>
 func (k *queueKeys) partyDetails(id domain.PartyID) string {
-	return fmt.Sprintf("queue:party:%s", id)
+	return fmt.Sprintf("queue:%s:party:%s", k.restaurantID, id)
 }
 
-// queue:waiting:count
-func (k *queueKeys) waitingPartyCounter() string {
-	return "queue:waiting:count"
+// queue:<restaurant>:<area>:waiting:count
+func (k *queueKeys) waitingPartyCounter(area domain.SeatingArea) string {
+	return fmt.Sprintf("queue:%s:%s:waiting:count", k.restaurantID, area)
 }
 
-// "queue:wait:sum"
-func (k *queueKeys) waitTimePrefixsum() string {
-	return "queue:wait:sum"
+// queue:<restaurant>:<area>:wait:sum
+func (k *queueKeys) waitTimePrefixsum(area domain.SeatingArea) string {
+	return fmt.Sprintf("queue:%s:%s:wait:sum", k.restaurantID, area)
 }
 
-// queue:wait:<id>
+// queue:<restaurant>:wait:<id>
 func (k *queueKeys) partyWaitTime(id domain.PartyID) string {
-	return fmt.Sprintf("queue:wait:%s", id)
+	return fmt.Sprintf("%s%s", k.partyWaitTimePrefix(), id)
 }
 
-// queue:wait:
+// queue:<restaurant>:wait:
 func (k *queueKeys) partyWaitTimePrefix() string {
-	return "queue:wait:"
+	return fmt.Sprintf("queue:%s:wait:", k.restaurantID)
+}
+
+// queue:<restaurant>:<area>:service
+func (k *queueKeys) totalServiceTime(area domain.SeatingArea) string {
+	return fmt.Sprintf("queue:%s:%s:service", k.restaurantID, area)
 }
 
-// queue:service
-func (k *queueKeys) totalServiceTime() string {
-	return "queue:service"
+// queue:<restaurant>:recovery:<code>
+func (k *queueKeys) recoveryCode(code string) string {
+	return fmt.Sprintf("queue:%s:recovery:%s", k.restaurantID, code)
 }
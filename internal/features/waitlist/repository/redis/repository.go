@@ -28,20 +28,26 @@ type redisWaitlistRepository struct {
 	// preloaded Lua scripts
 	joinScript     *redis.Script
 	leaveScript    *redis.Script
+	moveScript     *redis.Script
 	getPartyScript *redis.Script
+	skipScript     *redis.Script
+	forecastScript *redis.Script
 }
 
-func NewRedisWaitlistRepository(logger log.Logger, client *redis.Client, ttl time.Duration, scanRange int) *redisWaitlistRepository {
+func NewRedisWaitlistRepository(logger log.Logger, client *redis.Client, restaurantID d.RestaurantID, ttl time.Duration, scanRange int) *redisWaitlistRepository {
 	return &redisWaitlistRepository{
 		logger:    logger,
 		client:    client,
-		keys:      &queueKeys{},
+		keys:      &queueKeys{restaurantID: restaurantID},
 		ttl:       ttl,
 		scanRange: scanRange,
 
 		joinScript:     redis.NewScript(joinScript),
 		leaveScript:    redis.NewScript(leaveScript),
+		moveScript:     redis.NewScript(moveToBackScript),
 		getPartyScript: redis.NewScript(getPartyScript),
+		skipScript:     redis.NewScript(skipScript),
+		forecastScript: redis.NewScript(forecastScript),
 	}
 }
 
@@ -51,6 +57,7 @@ func (r *redisWaitlistRepository) HasParty(ctx context.Context, partyID d.PartyI
 
 func (r *redisWaitlistRepository) AddParty(ctx context.Context, party *domain.QueuedParty) (*domain.QueuedParty, error) {
 	id := party.ID
+	area := party.Preference.QueueArea()
 	redisParty := newRedisQueuedParty(party)
 
 	err := r.client.HSet(ctx, r.keys.partyDetails(id), redisParty).Err()
@@ -60,18 +67,18 @@ func (r *redisWaitlistRepository) AddParty(ctx context.Context, party *domain.Qu
 	}
 
 	joinKeys := []string{
-		r.keys.waitingQueue(),
+		r.keys.waitingQueue(area),
 		r.keys.partyDetails(id),
-		r.keys.waitTimePrefixsum(),
+		r.keys.waitTimePrefixsum(area),
 		r.keys.partyWaitTime(id),
-		r.keys.totalServiceTime(),
-		r.keys.waitingPartyCounter(),
+		r.keys.totalServiceTime(area),
+		r.keys.waitingPartyCounter(area),
 	}
 	joinArgs := []interface{}{
 		id,
 		int(party.EstimatedServiceTime.Seconds()),
 		time.Now().Unix(),
-		r.ttl,
+		int(r.ttl.Seconds()),
 		party.Status == d.PartyStatusWaiting,
 	}
 	results, err := r.joinScript.Run(ctx, r.client, joinKeys, joinArgs...).Slice()
@@ -106,13 +113,14 @@ func (r *redisWaitlistRepository) AddParty(ctx context.Context, party *domain.Qu
 //
 // Returns ErrPartyNotFound if party doesn't exist in queue.
 func (r *redisWaitlistRepository) RemoveParty(ctx context.Context, partyID d.PartyID) error {
+	area := r.queueAreaOf(ctx, partyID)
 	leaveKeys := []string{
-		r.keys.waitingQueue(),
+		r.keys.waitingQueue(area),
 		r.keys.partyDetails(partyID),
-		r.keys.totalServiceTime(),
+		r.keys.totalServiceTime(area),
 		r.keys.partyWaitTimePrefix(),
-		r.keys.waitTimePrefixsum(),
-		r.keys.waitingPartyCounter(),
+		r.keys.waitTimePrefixsum(area),
+		r.keys.waitingPartyCounter(area),
 	}
 
 	leaveArgs := []interface{}{partyID, "est", "status", d.PartyStatusWaiting}
@@ -136,14 +144,53 @@ func (r *redisWaitlistRepository) RemoveParty(ctx context.Context, partyID d.Par
 	return nil
 }
 
+// MoveToBack runs leave and join steps of RemoveParty and AddParty in a single script.
+// Party is placed behind the latest party even if it joined within the same second.
+func (r *redisWaitlistRepository) MoveToBack(ctx context.Context, party *domain.QueuedParty) (*domain.QueuedParty, error) {
+	id := party.ID
+	area := r.queueAreaOf(ctx, id)
+	if area != party.Preference.QueueArea() {
+		r.logger.LogErr(REDIS_WAITLIST, domain.ErrPartyNotFound, "could not move party across area queues", "party", party, "area", area)
+		return nil, domain.ErrPartyNotFound
+	}
+
+	moveKeys := []string{
+		r.keys.waitingQueue(area),
+		r.keys.partyDetails(id),
+		r.keys.totalServiceTime(area),
+		r.keys.partyWaitTimePrefix(),
+		r.keys.waitTimePrefixsum(area),
+		r.keys.waitingPartyCounter(area),
+		r.keys.partyWaitTime(id),
+	}
+	moveArgs := []interface{}{id, "est", "status", d.PartyStatusWaiting, time.Now().Unix(), int(r.ttl.Seconds())}
+	moveArgs = append(moveArgs, newRedisQueuedParty(party).fields()...)
+
+	results, err := r.moveScript.Run(ctx, r.client, moveKeys, moveArgs...).Slice()
+	if err != nil && err != redis.Nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not run move to back script", "party id", id, "keys", moveKeys)
+		return nil, fmt.Errorf("could not run move to back script: %w", err)
+	}
+	if results == nil {
+		err := domain.ErrPartyNotFound
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not find the party in the queue list to move", "party id", id)
+		return nil, err
+	}
+
+	party.Position = int(results[0].(int64))
+	party.EstimatedEndOfServiceTime = deserializeTime(results[1])
+	return party, nil
+}
+
 func (r *redisWaitlistRepository) GetParty(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error) {
 	redisParty := &redisQueuedParty{}
+	area := r.queueAreaOf(ctx, partyID)
 
 	getPartyKeys := []string{
 		r.keys.partyDetails(partyID),
-		r.keys.waitingQueue(),
+		r.keys.waitingQueue(area),
 		r.keys.partyWaitTime(partyID),
-		r.keys.totalServiceTime(),
+		r.keys.totalServiceTime(area),
 	}
 	getPartyArgs := []interface{}{partyID}
 	results, err := r.getPartyScript.Run(ctx, r.client, getPartyKeys, getPartyArgs...).Slice()
@@ -182,7 +229,7 @@ func (r *redisWaitlistRepository) GetPartyDetails(ctx context.Context, partyID d
 		return nil, res.Err()
 	}
 
-	if res.Val() == nil {
+	if len(res.Val()) == 0 {
 		return nil, nil
 	}
 
@@ -196,15 +243,15 @@ func (r *redisWaitlistRepository) GetPartyDetails(ctx context.Context, partyID d
 	return queuedParty.asQueuedParty(), nil
 }
 
-func (r *redisWaitlistRepository) GetQueueStatus(ctx context.Context) (*domain.QueueStatus, error) {
-	waitSecs, err := r.client.Get(ctx, r.keys.waitTimePrefixsum()).Result()
+func (r *redisWaitlistRepository) GetQueueStatus(ctx context.Context, area d.SeatingArea) (*domain.QueueStatus, error) {
+	waitSecs, err := r.client.Get(ctx, r.keys.waitTimePrefixsum(area)).Result()
 	if err != nil && err != redis.Nil {
 		r.logger.LogErr(REDIS_WAITLIST, err, "could not get the total wait from redis")
 		return nil, fmt.Errorf("could not get the total wait from redis: %w", err)
 	}
 	totalWait := deserializeTime(waitSecs)
 
-	totalServiceTime, err := r.client.Get(ctx, r.keys.totalServiceTime()).Result()
+	totalServiceTime, err := r.client.Get(ctx, r.keys.totalServiceTime(area)).Result()
 	if err != nil && err != redis.Nil {
 		r.logger.LogErr(REDIS_WAITLIST, err, "could not get the total service time for those checked-in parties from redis")
 		return nil, fmt.Errorf("could not get the total service time for those checked-in parties from redis: %w", err)
@@ -212,26 +259,36 @@ func (r *redisWaitlistRepository) GetQueueStatus(ctx context.Context) (*domain.Q
 
 	totalServiceSecs := deserializeTime(totalServiceTime)
 
-	amount, err := r.client.ZCard(ctx, r.keys.waitingQueue()).Result()
+	amount, err := r.client.ZCard(ctx, r.keys.waitingQueue(area)).Result()
 	if err != nil && err != redis.Nil {
 		r.logger.LogErr(REDIS_WAITLIST, err, "could not find how many entities in the waitlist queue")
 		return nil, fmt.Errorf("could not find how many entities in the waitlist queue: %w", err)
 	}
 
-	waiting, err := r.client.Get(ctx, r.keys.waitingPartyCounter()).Int64()
+	waiting, err := r.client.Get(ctx, r.keys.waitingPartyCounter(area)).Int64()
 	if err != nil && err != redis.Nil {
 		r.logger.LogErr(REDIS_WAITLIST, err, "could not find how many party were waiting")
 		return nil, fmt.Errorf("could not find how many party were waiting: %w", err)
 	}
 
-	return &domain.QueueStatus{
+	forecast, err := r.client.Get(ctx, r.keys.queueForecast(area)).Int64()
+	if err != nil && err != redis.Nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not get the queue forecast")
+		return nil, fmt.Errorf("could not get the queue forecast: %w", err)
+	}
+
+	status := &domain.QueueStatus{
 		TotalParties:    int(amount),
 		WaitingParties:  int(waiting),
 		CurrentWaitTime: totalWait - totalServiceSecs,
-	}, nil
+	}
+	if forecast != 0 {
+		status.EstimatedSeatingAt = time.Unix(forecast, 0)
+	}
+	return status, nil
 }
 
-func (r *redisWaitlistRepository) ScanParties(ctx context.Context) (<-chan *domain.QueuedParty, error) {
+func (r *redisWaitlistRepository) ScanParties(ctx context.Context, area d.SeatingArea) (<-chan *domain.QueuedParty, error) {
 	queuedParties := make(chan *domain.QueuedParty)
 
 	// Start streaming in background
@@ -247,7 +304,7 @@ func (r *redisWaitlistRepository) ScanParties(ctx context.Context) (<-chan *doma
 			default:
 			}
 
-			ids, err := r.client.ZRange(ctx, r.keys.waitingQueue(), int64(offset), int64(offset+r.scanRange-1)).Result()
+			ids, err := r.client.ZRange(ctx, r.keys.waitingQueue(area), int64(offset), int64(offset+r.scanRange-1)).Result()
 			if err != nil {
 				r.logger.LogErr(REDIS_WAITLIST, err, "could not scan the waitlist", "from range", offset, "to", offset+r.scanRange-1)
 				return
@@ -288,11 +345,72 @@ func (r *redisWaitlistRepository) UpdatePartyStatus(ctx context.Context, partyID
 	}
 
 	if status == d.PartyStatusReady && originalStatus == string(d.PartyStatusWaiting) {
-		r.client.IncrBy(ctx, r.keys.waitingPartyCounter(), -1)
+		r.client.IncrBy(ctx, r.keys.waitingPartyCounter(r.queueAreaOf(ctx, partyID)), -1)
 	}
 	return nil
 }
 
+func (r *redisWaitlistRepository) IncrementSkipCount(ctx context.Context, partyIDs []d.PartyID) error {
+	pipe := r.client.Pipeline()
+	for _, partyID := range partyIDs {
+		r.skipScript.Run(ctx, pipe, []string{r.keys.partyDetails(partyID)}, "skips")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not count skipped parties", "party ids", partyIDs)
+		return err
+	}
+
+	r.logger.LogDebug(REDIS_WAITLIST, "parties skipped", "party ids", partyIDs)
+	return nil
+}
+
+func (r *redisWaitlistRepository) SaveSeatingForecast(ctx context.Context, partyID d.PartyID, seatingAt time.Time, threshold time.Duration) (bool, error) {
+	forecastArgs := []interface{}{"eta", seatingAt.Unix(), int64(threshold.Seconds())}
+	saved, err := r.forecastScript.Run(ctx, r.client, []string{r.keys.partyDetails(partyID)}, forecastArgs...).Int()
+	if err != nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not save seating forecast", "party id", partyID, "args", forecastArgs)
+		return false, err
+	}
+	return saved == 1, nil
+}
+
+func (r *redisWaitlistRepository) SaveQueueForecast(ctx context.Context, area d.SeatingArea, seatingAt time.Time) error {
+	if err := r.client.Set(ctx, r.keys.queueForecast(area), seatingAt.Unix(), r.ttl).Err(); err != nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not save queue forecast", "area", area)
+		return err
+	}
+	return nil
+}
+
+func (r *redisWaitlistRepository) SaveRecoveryCode(ctx context.Context, code string, partyID d.PartyID) error {
+	if err := r.client.Set(ctx, r.keys.recoveryCode(code), string(partyID), r.ttl).Err(); err != nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not save recovery code of party", "party id", partyID)
+		return err
+	}
+	return nil
+}
+
+func (r *redisWaitlistRepository) GetPartyIDByRecoveryCode(ctx context.Context, code string) (d.PartyID, error) {
+	partyID, err := r.client.Get(ctx, r.keys.recoveryCode(code)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not get party of recovery code")
+		return "", err
+	}
+	return d.PartyID(partyID), nil
+}
+
+// queueAreaOf finds which area queue party is kept in from its seating preference.
+func (r *redisWaitlistRepository) queueAreaOf(ctx context.Context, partyID d.PartyID) d.SeatingArea {
+	preference, err := r.client.HGet(ctx, r.keys.partyDetails(partyID), "preference").Result()
+	if err != nil && err != redis.Nil {
+		r.logger.LogErr(REDIS_WAITLIST, err, "could not get seating preference of party", "party id", partyID)
+	}
+	return d.SeatingPreference(preference).QueueArea()
+}
+
 // deserializeTime converts seconds value to time.Duration.
 // Handles both integer and string formats from Redis, returns 0 for invalid formats.
 func deserializeTime(val interface{}) time.Duration {
@@ -181,3 +181,145 @@ end
 redis.call('DEL', unpack(del_keys))
 return {rank, est, has_wait}
 `
+
+// skipScript counts party as passed over once more, unless it already left the queue
+//
+// Keys:
+//
+//	party_detail_key - Party details hash
+//
+// Args:
+//
+//	skip_count_field - Field name for skip count
+//
+// Returns: skip count of party, or 0 if party not found
+const skipScript = `
+local party_detail_key = KEYS[1]
+local skip_count_field = ARGV[1]
+
+if redis.call('EXISTS', party_detail_key) == 0 then
+    return 0
+end
+return redis.call('HINCRBY', party_detail_key, skip_count_field, 1)
+`
+
+// forecastScript stores when party is forecast to be seated, unless it moved less than threshold
+// from the stored forecast or party already left the queue
+//
+// Keys:
+//
+//	party_detail_key - Party details hash
+//
+// Args:
+//
+//	forecast_field - Field name for forecast seating time
+//	seating_at     - Forecast seating time in unix seconds
+//	threshold      - Least move of forecast in seconds to be stored
+//
+// Returns: 1 if forecast was stored, otherwise 0
+const forecastScript = `
+local party_detail_key = KEYS[1]
+local forecast_field = ARGV[1]
+local seating_at = tonumber(ARGV[2])
+local threshold = tonumber(ARGV[3])
+
+if redis.call('EXISTS', party_detail_key) == 0 then
+    return 0
+end
+
+local stored = tonumber(redis.call('HGET', party_detail_key, forecast_field))
+if stored and math.abs(seating_at - stored) < threshold then
+    return 0
+end
+redis.call('HSET', party_detail_key, forecast_field, seating_at)
+return 1
+`
+
+// moveToBackScript atomically re-queues party at the back of its queue, following leaveScript
+// then joinScript, so party never drops out of the queue halfway
+//
+// Keys:
+//
+//	waitlist_key                - Queue ordered set
+//	party_detail_key            - Party details hash
+//	total_service_time          - Service time counter
+//	party_wait_prefixsum_prefix - Prefix for wait time keys
+//	total_wait_prefixsum        - Total wait counter
+//	waiting_party_counter       - Waiting status counter
+//	party_wait_prefixsum        - Party's own wait time prefixsum
+//
+// Args:
+//
+//	party_id                     - Party to move
+//	estimated_service_time_field - Field name for service time
+//	status_field                 - Field name for status
+//	status_party_wait_val        - Status value for waiting
+//	join_score                   - Score for queue ordering (timestamp), raised behind the latest party if needed
+//	ttl                          - TTL for keys in seconds
+//	details...                   - Field value pairs replacing party details
+//
+// Returns: [position, wait_time] or nil if not found
+const moveToBackScript = `
+local waitlist_key = KEYS[1]
+local party_detail_key = KEYS[2]
+local total_service_time_key = KEYS[3]
+local party_wait_prefixsum_key_prefix = KEYS[4]
+local total_wait_prefixsum_key = KEYS[5]
+local waiting_party_counter_key = KEYS[6]
+local party_wait_prefixsum_key = KEYS[7]
+local party_id = ARGV[1]
+local estimated_service_time_field = ARGV[2]
+local status_field = ARGV[3]
+local status_party_wait_val = ARGV[4]
+local join_score = tonumber(ARGV[5])
+local ttl = ARGV[6]
+
+local rank = redis.call('ZRANK', waitlist_key, party_id)
+if not rank then
+    return nil
+end
+
+local party = redis.call('HMGET', party_detail_key, estimated_service_time_field, status_field)
+local est = tonumber(party[1])
+if not est then
+    return nil
+end
+
+-- leave
+if party[2] == status_party_wait_val then
+    redis.call('INCRBY', waiting_party_counter_key, -1)
+end
+if rank == 0 then
+    redis.call('INCRBY', total_service_time_key, est)
+else
+    local affected = redis.call('ZRANGE', waitlist_key, rank + 1, -1)
+    for _, party in ipairs(affected) do
+        local prefixsum_key = party_wait_prefixsum_key_prefix .. party
+        redis.call('INCRBY', prefixsum_key, -est)
+    end
+end
+redis.call('ZREM', waitlist_key, party_id)
+if redis.call('EXISTS', waitlist_key) == 0 then
+    redis.call('DEL', total_service_time_key, total_wait_prefixsum_key)
+end
+
+-- join
+redis.call('DEL', party_detail_key)
+redis.call('HSET', party_detail_key, unpack(ARGV, 7))
+local last = redis.call('ZRANGE', waitlist_key, -1, -1, 'WITHSCORES')
+if #last > 0 and tonumber(last[2]) >= join_score then
+    join_score = tonumber(last[2]) + 1
+end
+local wait_entries_ahead = redis.call('ZCARD', waitlist_key)
+redis.call('ZADD', waitlist_key, join_score, party_id)
+redis.call('EXPIRE', waitlist_key, ttl)
+
+local next_wait = redis.call('INCRBY', total_wait_prefixsum_key, est)
+local total_service_time = redis.call('GET', total_service_time_key) or 0
+redis.call('SET', party_wait_prefixsum_key, next_wait, 'EX', ttl)
+if redis.call('HGET', party_detail_key, status_field) == status_party_wait_val then
+    redis.call('INCR', waiting_party_counter_key)
+end
+
+return {wait_entries_ahead, next_wait - total_service_time}
+`
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	d "queue-bite/internal/domain"
 	"queue-bite/internal/features/waitlist/domain"
 )
@@ -20,7 +22,13 @@ type WaitlistRepositoy interface {
 	// for parties behind them in the queue.
 	RemoveParty(ctx context.Context, partyID d.PartyID) error
 
-	// GetParty retrieves a party's current queue information.
+	// MoveToBack re-queues party at the back of its area queue as a newcomer, with details replaced by party,
+	// parties around its old and new positions follow the usual leave and join rules.
+	// It is done in a single step, so party never drops out of the queue if it fails halfway.
+	// Returns ErrPartyNotFound if party doesn't exist in queue.
+	MoveToBack(ctx context.Context, party *domain.QueuedParty) (*domain.QueuedParty, error)
+
+	// GetParty retrieves a party's current queue information, with the stored seating forecast if any.
 	// Returns nil, nil if party is not found.
 	GetParty(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error)
 
@@ -29,15 +37,15 @@ type WaitlistRepositoy interface {
 	// Returns nil, nil if party is not found.
 	GetPartyDetails(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error)
 
-	// GetQueueStatus retrieves current queue metrics.
-	GetQueueStatus(ctx context.Context) (*domain.QueueStatus, error)
+	// GetQueueStatus retrieves current queue metrics of the area queue, with the stored queue forecast if any.
+	GetQueueStatus(ctx context.Context, area d.SeatingArea) (*domain.QueueStatus, error)
 
-	// ScanParties streams queued parties in order through a channel.
+	// ScanParties streams queued parties of the area queue in order through a channel.
 	// Uses batched retrieval (ZRANGE) with configured scanRange to manage memory usage.
 	// Streaming provides efficient iteration over large queues without loading all at once.
 	//
 	// Usage:
-	//   parties, err := repo.ScanParties(ctx)
+	//   parties, err := repo.ScanParties(ctx, area)
 	//   if err != nil {
 	//       return err
 	//   }
@@ -49,9 +57,28 @@ type WaitlistRepositoy interface {
 	//  - All parties have been streamed
 	//  - Context is cancelled
 	//  - Error occurs during scanning
-	ScanParties(ctx context.Context) (<-chan *domain.QueuedParty, error)
+	ScanParties(ctx context.Context, area d.SeatingArea) (<-chan *domain.QueuedParty, error)
 
 	// UpdatePartyStatus update a party's current state in the queue.
 	// Returns nil, nil if party is not found.
 	UpdatePartyStatus(ctx context.Context, partyID d.PartyID, status d.PartyStatus) error
+
+	// IncrementSkipCount counts each party as passed over once more, parties no longer queued are ignored.
+	IncrementSkipCount(ctx context.Context, partyIDs []d.PartyID) error
+
+	// SaveRecoveryCode maps code to party, so party is found by it in a single lookup. It is kept for ttl
+	// like the queue, a code saved again replaces the party it maps to.
+	SaveRecoveryCode(ctx context.Context, code string, partyID d.PartyID) error
+
+	// GetPartyIDByRecoveryCode retrieves the party code was saved for, empty if none.
+	// The party may have left the queue since.
+	GetPartyIDByRecoveryCode(ctx context.Context, code string) (d.PartyID, error)
+
+	// SaveSeatingForecast stores when party is forecast to be seated, unless it moved less than threshold
+	// from the stored forecast. Returns whether forecast was stored, parties no longer queued are ignored.
+	SaveSeatingForecast(ctx context.Context, partyID d.PartyID, seatingAt time.Time, threshold time.Duration) (bool, error)
+
+	// SaveQueueForecast stores when a new party joining the area queue now is forecast to be seated,
+	// it is kept for ttl like the queue.
+	SaveQueueForecast(ctx context.Context, area d.SeatingArea, seatingAt time.Time) error
 }
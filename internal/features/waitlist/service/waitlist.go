@@ -8,6 +8,7 @@ import (
 
 	log "queue-bite/internal/config/logger"
 	d "queue-bite/internal/domain"
+	hdd "queue-bite/internal/features/hostdesk/domain"
 	servicetime "queue-bite/internal/features/servicetime/service"
 	"queue-bite/internal/features/sse"
 	"queue-bite/internal/features/waitlist/domain"
@@ -17,12 +18,15 @@ import (
 
 var WAITLIST = "waitlist"
 
+// FORECAST_PARTY_SIZE is the party queue status forecasts wait of, the party size coming in most.
+var FORECAST_PARTY_SIZE = 2
+
 // QueuedPartyProvider defines interface for streaming parties in queue.
 // Useful for iterating through large queues efficiently.
 type QueuedPartyProvider interface {
-	// GetQueuedParties returns a channel that yields parties in queue order.
+	// GetQueuedParties returns a channel that yields parties of the area queue in queue order.
 	// Channel is closed when iteration completes or context is cancelled.
-	GetQueuedParties(ctx context.Context) (<-chan *domain.QueuedParty, error)
+	GetQueuedParties(ctx context.Context, area d.SeatingArea) (<-chan *domain.QueuedParty, error)
 }
 
 // Waitlist manages the restaurant's waiting queue operations.
@@ -35,35 +39,80 @@ type Waitlist interface {
 
 	LeaveQueue(ctx context.Context, partyID d.PartyID) error
 
-	// GetQueueStatus returns current queue metrics like total parties and wait times
-	GetQueueStatus(ctx context.Context) (*domain.QueueStatus, error)
+	// SendToBack moves party behind everyone currently in queue and turns it back to waiting.
+	SendToBack(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error)
+
+	// GetQueueStatus returns current metrics of the area queue like total parties and wait times
+	GetQueueStatus(ctx context.Context, area d.SeatingArea) (*domain.QueueStatus, error)
 
 	// GetQueuedParty retrieves a specific party's queue information with its position in the queue
 	GetQueuedParty(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error)
 
+	// SaveRecoveryCode lets party be found by code, for resuming its session on another device.
+	SaveRecoveryCode(ctx context.Context, partyID d.PartyID, code string) error
+
+	// GetQueuedPartyByRecoveryCode retrieves the party code was saved for, nil if it is no longer in queue.
+	GetQueuedPartyByRecoveryCode(ctx context.Context, code string) (*domain.QueuedParty, error)
+
 	// HandlePartyReady processes a party becoming ready for seating
 	HandlePartyReady(ctx context.Context, partyID d.PartyID) error
+
+	// RecordSkips counts parties as passed over once more by a party behind them.
+	RecordSkips(ctx context.Context, partyIDs []d.PartyID) error
+
+	// RefreshWaitForecasts forecasts seating of waiting parties of area again and stores the forecasts
+	// read by GetQueueStatus and GetQueuedParty, returns parties whose forecast moved materially since they were last told.
+	RefreshWaitForecasts(ctx context.Context, area d.SeatingArea) ([]*domain.QueuedParty, error)
+}
+
+// SeatingForecaster simulates tables of an area freeing up, such as the host desk.
+type SeatingForecaster interface {
+	// ForecastSeating returns simulation of tables of area from now on, nil if seating could not be forecast.
+	ForecastSeating(ctx context.Context, area d.SeatingArea) (*hdd.SeatingForecast, error)
 }
 
 type waitlistService struct {
 	logger           log.Logger
+	restaurantID     d.RestaurantID
 	repo             repository.WaitlistRepositoy
 	eventbus         eventbus.EventBus
 	serviceEstimator servicetime.ServiceTimeEstimator
+
+	forecaster        SeatingForecaster
+	forecastThreshold time.Duration
+}
+
+type WaitlistOption func(*waitlistService)
+
+// WithSeatingForecast estimates wait of parties by simulating tables of their area freeing up and being taken
+// in queue order, instead of summing up service time of every party ahead. Party is told its forecast again
+// once it moved by threshold or more from the one it was last told.
+func WithSeatingForecast(forecaster SeatingForecaster, threshold time.Duration) WaitlistOption {
+	return func(s *waitlistService) {
+		s.forecaster = forecaster
+		s.forecastThreshold = threshold
+	}
 }
 
 func NewWaitlistService(
 	logger log.Logger,
+	restaurantID d.RestaurantID,
 	repo repository.WaitlistRepositoy,
 	estimator servicetime.ServiceTimeEstimator,
 	eventbus eventbus.EventBus,
+	opts ...WaitlistOption,
 ) Waitlist {
-	return &waitlistService{
+	s := &waitlistService{
 		logger:           logger,
+		restaurantID:     restaurantID,
 		repo:             repo,
 		eventbus:         eventbus,
 		serviceEstimator: estimator,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *waitlistService) HasPartyExists(ctx context.Context, partyID d.PartyID) bool {
@@ -86,6 +135,12 @@ func (s *waitlistService) JoinQueue(ctx context.Context, party *d.Party) (*domai
 		return nil, err
 	}
 
+	s.forecastParty(ctx, queuedParty)
+	if !queuedParty.EstimatedSeatingAt.IsZero() {
+		if _, err := s.repo.SaveSeatingForecast(ctx, queuedParty.ID, queuedParty.EstimatedSeatingAt, 0); err != nil {
+			s.logger.LogErr(WAITLIST, err, "could not save seating forecast of joined party", "party", queuedParty)
+		}
+	}
 	return queuedParty, nil
 }
 
@@ -93,16 +148,83 @@ func (s *waitlistService) LeaveQueue(ctx context.Context, partyID d.PartyID) err
 	return s.repo.RemoveParty(ctx, partyID)
 }
 
-func (s *waitlistService) GetQueueStatus(ctx context.Context) (*domain.QueueStatus, error) {
-	return s.repo.GetQueueStatus(ctx)
+// SendToBack re-queues party as a newcomer, so wait time bookkeeping of parties
+// around its old and new positions follows the usual leave and join rules.
+func (s *waitlistService) SendToBack(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error) {
+	queuedParty, err := s.repo.GetPartyDetails(ctx, partyID)
+	if err != nil {
+		return nil, err
+	}
+	if queuedParty == nil {
+		return nil, domain.ErrPartyNotFound
+	}
+
+	queuedParty.Status = d.PartyStatusWaiting
+	queuedParty.JoinedAt = time.Now()
+	queuedParty.SkipCount = 0
+	queuedParty, err = s.repo.MoveToBack(ctx, queuedParty)
+	if err != nil {
+		s.logger.LogErr(WAITLIST, err, "could not re-join party at the back of queue", "party id", partyID)
+		return nil, err
+	}
+
+	s.logger.LogDebug(WAITLIST, "party sent to the back of queue", "party", queuedParty)
+	return queuedParty, nil
+}
+
+// GetQueueStatus tells current wait time by the forecast stored on the latest refresh, see RefreshWaitForecasts.
+func (s *waitlistService) GetQueueStatus(ctx context.Context, area d.SeatingArea) (*domain.QueueStatus, error) {
+	status, err := s.repo.GetQueueStatus(ctx, area)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.forecaster != nil && !status.EstimatedSeatingAt.IsZero() {
+		status.CurrentWaitTime = max(time.Until(status.EstimatedSeatingAt), 0).Round(time.Second)
+	}
+	return status, nil
 }
 
+// GetQueuedParty tells the seating forecast party was last told, instead of forecasting its whole queue again.
 func (s *waitlistService) GetQueuedParty(ctx context.Context, partyID d.PartyID) (*domain.QueuedParty, error) {
 	return s.repo.GetParty(ctx, partyID)
 }
 
-func (s *waitlistService) GetQueuedParties(ctx context.Context) (<-chan *domain.QueuedParty, error) {
-	return s.repo.ScanParties(ctx)
+func (s *waitlistService) SaveRecoveryCode(ctx context.Context, partyID d.PartyID, code string) error {
+	return s.repo.SaveRecoveryCode(ctx, code, partyID)
+}
+
+func (s *waitlistService) GetQueuedPartyByRecoveryCode(ctx context.Context, code string) (*domain.QueuedParty, error) {
+	partyID, err := s.repo.GetPartyIDByRecoveryCode(ctx, code)
+	if err != nil || partyID == "" {
+		return nil, err
+	}
+	return s.GetQueuedParty(ctx, partyID)
+}
+
+func (s *waitlistService) GetQueuedParties(ctx context.Context, area d.SeatingArea) (<-chan *domain.QueuedParty, error) {
+	queuedParties, err := s.repo.ScanParties(ctx, area)
+	if err != nil || s.forecaster == nil {
+		return queuedParties, err
+	}
+
+	forecast := s.newForecast(ctx, area)
+	if forecast == nil {
+		return queuedParties, nil
+	}
+	forecasted := make(chan *domain.QueuedParty)
+	go func() {
+		defer close(forecasted)
+		for party := range queuedParties {
+			forecastSeating(forecast, party)
+			select {
+			case forecasted <- party:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return forecasted, nil
 }
 
 func (s *waitlistService) HandlePartyReady(ctx context.Context, partyID d.PartyID) error {
@@ -127,6 +249,117 @@ func (s *waitlistService) HandlePartyReady(ctx context.Context, partyID d.PartyI
 		s.logger.LogErr("waitlist", err, "failed to make party ready", "party id", partyID)
 	}
 
-	s.eventbus.Publish(ctx, &sse.NotifyPartyReadyEvent{PartyID: partyID})
+	s.eventbus.Publish(ctx, &sse.NotifyPartyReadyEvent{RestaurantID: s.restaurantID, PartyID: partyID})
 	return nil
 }
+
+func (s *waitlistService) RecordSkips(ctx context.Context, partyIDs []d.PartyID) error {
+	if len(partyIDs) == 0 {
+		return nil
+	}
+	return s.repo.IncrementSkipCount(ctx, partyIDs)
+}
+
+func (s *waitlistService) RefreshWaitForecasts(ctx context.Context, area d.SeatingArea) ([]*domain.QueuedParty, error) {
+	moved := []*domain.QueuedParty{}
+	if s.forecaster == nil {
+		return moved, nil
+	}
+
+	parties := []*domain.QueuedParty{}
+	forecast := s.forecastQueue(ctx, area, func(party *domain.QueuedParty) bool {
+		parties = append(parties, party)
+		return true
+	})
+	if forecast == nil {
+		return moved, nil
+	}
+
+	// a party of FORECAST_PARTY_SIZE joining behind everyone in queue
+	if seatingAt, ok := forecast.Seat(FORECAST_PARTY_SIZE, 0); ok {
+		if err := s.repo.SaveQueueForecast(ctx, area, seatingAt); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, party := range parties {
+		if party.Status != d.PartyStatusWaiting || party.EstimatedSeatingAt.IsZero() {
+			continue
+		}
+		saved, err := s.repo.SaveSeatingForecast(ctx, party.ID, party.EstimatedSeatingAt, s.forecastThreshold)
+		if err != nil {
+			return nil, err
+		}
+		if saved {
+			moved = append(moved, party)
+		}
+	}
+	s.logger.LogDebug(WAITLIST, "seating forecasts refreshed", "area", area, "moved", len(moved))
+	return moved, nil
+}
+
+// forecastParty forecasts seating of party after every party ahead of it in its area queue.
+func (s *waitlistService) forecastParty(ctx context.Context, party *domain.QueuedParty) {
+	s.forecastQueue(ctx, party.Preference.QueueArea(), func(queued *domain.QueuedParty) bool {
+		if queued.ID != party.ID {
+			return true
+		}
+		party.EstimatedSeatingAt = queued.EstimatedSeatingAt
+		return false
+	})
+}
+
+// forecastQueue forecasts seating of parties in area queue one after another, visit is called with each of them
+// until it returns false. Returns the forecast with parties visited seated, nil if seating could not be forecast.
+func (s *waitlistService) forecastQueue(ctx context.Context, area d.SeatingArea, visit func(*domain.QueuedParty) bool) *hdd.SeatingForecast {
+	forecast := s.newForecast(ctx, area)
+	if forecast == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	queuedParties, err := s.repo.ScanParties(ctx, area)
+	if err != nil {
+		s.logger.LogErr(WAITLIST, err, "could not scan parties to forecast seating", "area", area)
+		return nil
+	}
+	for party := range queuedParties {
+		if party == nil {
+			continue
+		}
+		forecastSeating(forecast, party)
+		if visit != nil && !visit(party) {
+			break
+		}
+	}
+	return forecast
+}
+
+func (s *waitlistService) newForecast(ctx context.Context, area d.SeatingArea) *hdd.SeatingForecast {
+	if s.forecaster == nil {
+		return nil
+	}
+
+	forecast, err := s.forecaster.ForecastSeating(ctx, area)
+	if err != nil {
+		s.logger.LogErr(WAITLIST, err, "could not forecast seating, fallback to sum of service time ahead", "area", area)
+		return nil
+	}
+	return forecast
+}
+
+// forecastSeating seats party in forecast, parties not waiting already hold their tables.
+// Party too large for tables to ever fit keeps no forecast.
+func forecastSeating(forecast *hdd.SeatingForecast, party *domain.QueuedParty) {
+	if party == nil {
+		return
+	}
+	if party.Status != d.PartyStatusWaiting {
+		party.EstimatedSeatingAt = forecast.Start()
+		return
+	}
+	if seatingAt, ok := forecast.Seat(party.Size, party.EstimatedServiceTime); ok {
+		party.EstimatedSeatingAt = seatingAt
+	}
+}
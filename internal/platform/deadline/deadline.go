@@ -0,0 +1,222 @@
+package deadline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+)
+
+var DEADLINE = "deadline"
+
+var DEFAULT_LEASE = 30 * time.Second
+var DEFAULT_RETRY_BACKOFF = time.Second
+var DEFAULT_MAX_RETRY_BACKOFF = time.Minute
+
+// Claim is an expired entry leased to a single caller until Until.
+// Entry stays in the queue while leased, so it is handed out again once the lease lapses,
+// e.g. its claimer died before the entry was handled.
+type Claim struct {
+	ID    string
+	Until time.Time
+}
+
+// Queue keeps deadlines of entries shared by every server instance.
+// Expired entries are leased atomically so each of them is handled by one caller at a time,
+// and they are dropped only once handled.
+type Queue interface {
+	// Schedule sets or replaces the deadline of entry.
+	Schedule(ctx context.Context, id string, at time.Time) error
+
+	// Cancel drops entry from the queue, it is a no-op if entry is not scheduled.
+	Cancel(ctx context.Context, id string) error
+
+	// ClaimExpired leases up to limit entries whose deadline is not after now, until now plus lease.
+	ClaimExpired(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Claim, error)
+
+	// Ack drops entry of claim once it is handled.
+	// It is a no-op if entry was rescheduled, cancelled or claimed again since.
+	Ack(ctx context.Context, claim Claim) error
+
+	// Retry moves deadline of entry of claim to at, so it is claimed again then.
+	// It is a no-op if entry was rescheduled, cancelled or claimed again since.
+	Retry(ctx context.Context, claim Claim, at time.Time) error
+}
+
+type ExpiredHandler func(ctx context.Context, id string) error
+
+// Poller periodically claims expired entries from queue and hands them to handler.
+// Entry is acknowledged once handler returns nil, failed ones are retried with exponential backoff.
+type Poller struct {
+	logger          log.Logger
+	queue           Queue
+	interval        time.Duration
+	batch           int
+	handler         ExpiredHandler
+	lease           time.Duration
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+
+	// attempts counts failed handling of entries claimed by this poller, to back off their retries
+	attempts map[string]*failedAttempts
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// failedAttempts is how many times handling of entry failed in a row, and when it is due to be claimed again.
+type failedAttempts struct {
+	count   int
+	retryAt time.Time
+}
+
+type PollerOption func(*Poller)
+
+// WithLease lets handler take up to lease to handle an entry before it is handed out again.
+func WithLease(lease time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.lease = lease
+	}
+}
+
+// WithRetryBackoff retries entries whose handler failed after backoff, doubled on each failure up to maxBackoff.
+func WithRetryBackoff(backoff, maxBackoff time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.retryBackoff = backoff
+		p.maxRetryBackoff = maxBackoff
+	}
+}
+
+func NewPoller(logger log.Logger, queue Queue, interval time.Duration, batch int, handler ExpiredHandler, opts ...PollerOption) *Poller {
+	p := &Poller{
+		logger:          logger,
+		queue:           queue,
+		interval:        interval,
+		batch:           batch,
+		handler:         handler,
+		lease:           DEFAULT_LEASE,
+		retryBackoff:    DEFAULT_RETRY_BACKOFF,
+		maxRetryBackoff: DEFAULT_MAX_RETRY_BACKOFF,
+		attempts:        make(map[string]*failedAttempts),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Start begins polling in background until ctx is done or Stop is called,
+// calling it on a running poller does nothing.
+func (p *Poller) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.loop(ctx, p.stop, p.done)
+}
+
+// Stop ends polling and waits for the in-flight batch to be handled.
+func (p *Poller) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop, p.done = nil, nil
+}
+
+func (p *Poller) loop(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+// poll drains every expired entry, batch by batch, so a backlog does not wait for further ticks.
+// Claimed entries are leased into the future, so the next batch never hands them out again.
+func (p *Poller) poll(ctx context.Context) {
+	defer p.forgetAttempts(time.Now())
+
+	for {
+		claims, err := p.queue.ClaimExpired(ctx, time.Now(), p.lease, p.batch)
+		if err != nil {
+			p.logger.LogErr(DEADLINE, err, "could not claim expired deadlines")
+			return
+		}
+
+		for _, claim := range claims {
+			p.handle(ctx, claim)
+		}
+
+		if len(claims) < p.batch {
+			return
+		}
+	}
+}
+
+// forgetAttempts drops failed attempts of entries not claimed again well after their retry was due,
+// they were cancelled, rescheduled or handled by another instance meanwhile.
+// Entry claimed again after all starts its backoff over.
+func (p *Poller) forgetAttempts(now time.Time) {
+	for id, attempts := range p.attempts {
+		if now.After(attempts.retryAt.Add(p.lease + p.interval)) {
+			delete(p.attempts, id)
+		}
+	}
+}
+
+// handle acknowledges entry of claim once handler succeeds, otherwise schedules its retry.
+// Entry which could be neither acknowledged nor retried is handed out again once its lease lapses.
+func (p *Poller) handle(ctx context.Context, claim Claim) {
+	if err := p.handler(ctx, claim.ID); err != nil {
+		attempts, ok := p.attempts[claim.ID]
+		if !ok {
+			attempts = &failedAttempts{}
+			p.attempts[claim.ID] = attempts
+		}
+		attempts.count++
+		backoff := p.backoff(attempts.count)
+		attempts.retryAt = time.Now().Add(backoff)
+		p.logger.LogErr(DEADLINE, err, "failed to handle expired deadline, retry later", "id", claim.ID, "backoff", backoff)
+		if err := p.queue.Retry(ctx, claim, attempts.retryAt); err != nil {
+			p.logger.LogErr(DEADLINE, err, "could not retry expired deadline", "id", claim.ID, "lease until", claim.Until)
+		}
+		return
+	}
+
+	delete(p.attempts, claim.ID)
+	if err := p.queue.Ack(ctx, claim); err != nil {
+		p.logger.LogErr(DEADLINE, err, "could not acknowledge handled deadline", "id", claim.ID, "lease until", claim.Until)
+	}
+}
+
+func (p *Poller) backoff(attempt int) time.Duration {
+	backoff := p.retryBackoff
+	for i := 1; i < attempt && backoff < p.maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.maxRetryBackoff {
+		return p.maxRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,85 @@
+package deadline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/deadline"
+	"queue-bite/internal/platform/deadline/inmemory"
+)
+
+func TestPoller(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("failed entry is retried until handled, then dropped", func(t *testing.T) {
+		queue := inmemory.NewInMemoryDeadlineQueue(log.NewNoopLogger())
+		require.NoError(t, queue.Schedule(ctx, "party-1", time.Now()))
+
+		var mu sync.Mutex
+		calls := 0
+		poller := deadline.NewPoller(log.NewNoopLogger(), queue, 5*time.Millisecond, 10, func(ctx context.Context, id string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			if calls == 1 {
+				return errors.New("store unavailable")
+			}
+			return nil
+		}, deadline.WithRetryBackoff(10*time.Millisecond, 10*time.Millisecond))
+		poller.Start(ctx)
+		defer poller.Stop()
+
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return calls == 2
+		}, time.Second, 5*time.Millisecond)
+		poller.Stop()
+
+		claims, err := queue.ClaimExpired(ctx, time.Now().Add(time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claims, "handled entry is acknowledged")
+	})
+
+	t.Run("entry of a claimer which died is handed out again once lease lapses", func(t *testing.T) {
+		queue := inmemory.NewInMemoryDeadlineQueue(log.NewNoopLogger())
+		now := time.Now()
+		require.NoError(t, queue.Schedule(ctx, "party-1", now))
+
+		claims, err := queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claims, 1)
+
+		claims, err = queue.ClaimExpired(ctx, now.Add(time.Second), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claims, "leased entry is not handed out twice")
+
+		claims, err = queue.ClaimExpired(ctx, now.Add(time.Minute), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Len(t, claims, 1)
+	})
+
+	t.Run("acknowledging an outdated claim keeps the rescheduled deadline", func(t *testing.T) {
+		queue := inmemory.NewInMemoryDeadlineQueue(log.NewNoopLogger())
+		now := time.Now()
+		require.NoError(t, queue.Schedule(ctx, "party-1", now))
+
+		claims, err := queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claims, 1)
+
+		require.NoError(t, queue.Schedule(ctx, "party-1", now.Add(time.Hour)))
+		require.NoError(t, queue.Ack(ctx, claims[0]))
+
+		claims, err = queue.ClaimExpired(ctx, now.Add(time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Len(t, claims, 1)
+	})
+}
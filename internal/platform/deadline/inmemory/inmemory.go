@@ -0,0 +1,92 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/deadline"
+)
+
+var INMEMORY_DEADLINE = "deadline/inmemory"
+
+// InMemoryDeadlineQueue keeps deadlines in process memory, for a single instance deployment and tests.
+// Like the redis queue, leased entries stay in the queue with their deadline pushed to the end of the lease.
+type InMemoryDeadlineQueue struct {
+	logger log.Logger
+
+	mu        sync.Mutex
+	deadlines map[string]time.Time
+}
+
+func NewInMemoryDeadlineQueue(logger log.Logger) *InMemoryDeadlineQueue {
+	return &InMemoryDeadlineQueue{
+		logger:    logger,
+		deadlines: make(map[string]time.Time),
+	}
+}
+
+func (q *InMemoryDeadlineQueue) Schedule(ctx context.Context, id string, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.deadlines[id] = at
+	q.logger.LogDebug(INMEMORY_DEADLINE, "schedule deadline", "id", id, "at", at)
+	return nil
+}
+
+func (q *InMemoryDeadlineQueue) Cancel(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.deadlines, id)
+	return nil
+}
+
+func (q *InMemoryDeadlineQueue) ClaimExpired(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]deadline.Claim, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	expired := []string{}
+	for id, at := range q.deadlines {
+		if !at.After(now) {
+			expired = append(expired, id)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return q.deadlines[expired[i]].Before(q.deadlines[expired[j]])
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	until := now.Add(lease)
+	claims := make([]deadline.Claim, 0, len(expired))
+	for _, id := range expired {
+		q.deadlines[id] = until
+		claims = append(claims, deadline.Claim{ID: id, Until: until})
+	}
+	return claims, nil
+}
+
+func (q *InMemoryDeadlineQueue) Ack(ctx context.Context, claim deadline.Claim) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if at, exists := q.deadlines[claim.ID]; exists && at.Equal(claim.Until) {
+		delete(q.deadlines, claim.ID)
+	}
+	return nil
+}
+
+func (q *InMemoryDeadlineQueue) Retry(ctx context.Context, claim deadline.Claim, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if current, exists := q.deadlines[claim.ID]; exists && current.Equal(claim.Until) {
+		q.deadlines[claim.ID] = at
+	}
+	return nil
+}
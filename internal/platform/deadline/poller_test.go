@@ -0,0 +1,46 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	log "queue-bite/internal/config/logger"
+)
+
+// claimOnceQueue hands out its entries on the first claim only, like entries cancelled after their retry was scheduled.
+type claimOnceQueue struct {
+	Queue
+	ids []string
+}
+
+func (q *claimOnceQueue) ClaimExpired(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Claim, error) {
+	claims := []Claim{}
+	for _, id := range q.ids {
+		claims = append(claims, Claim{ID: id, Until: now.Add(lease)})
+	}
+	q.ids = nil
+	return claims, nil
+}
+
+func (q *claimOnceQueue) Retry(ctx context.Context, claim Claim, at time.Time) error {
+	return nil
+}
+
+func TestPollerAttempts(t *testing.T) {
+	ctx := context.Background()
+	queue := &claimOnceQueue{ids: []string{"party-1", "party-2"}}
+	poller := NewPoller(log.NewNoopLogger(), queue, time.Millisecond, 10, func(ctx context.Context, id string) error {
+		return errors.New("store unavailable")
+	}, WithLease(time.Millisecond), WithRetryBackoff(time.Millisecond, time.Millisecond))
+
+	poller.poll(ctx)
+	assert.Len(t, poller.attempts, 2, "failed entries keep their attempts until claimed again")
+
+	time.Sleep(5 * time.Millisecond)
+	poller.poll(ctx)
+	assert.Empty(t, poller.attempts, "entries not claimed again after their retry was due are forgotten")
+}
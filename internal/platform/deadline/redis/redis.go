@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/deadline"
+)
+
+var REDIS_DEADLINE = "deadline/redis"
+
+// claimExpiredScript leases expired entries in one step by pushing their deadline to the end of the lease,
+// so concurrent pollers on different instances never claim the same entry while it is leased.
+// KEYS[1]: deadline queue
+// ARGV[1]: now in unix milliseconds
+// ARGV[2]: end of lease in unix milliseconds
+// ARGV[3]: max entries to claim
+const claimExpiredScript = `
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[3]))
+for _, id in ipairs(ids) do
+    redis.call('ZADD', KEYS[1], 'XX', ARGV[2], id)
+end
+return ids
+`
+
+// settleClaimScript drops or reschedules a claimed entry, unless its deadline moved since it was claimed,
+// which means it was rescheduled or claimed again after its lease lapsed.
+// KEYS[1]: deadline queue
+// ARGV[1]: entry id
+// ARGV[2]: end of lease in unix milliseconds
+// ARGV[3]: next deadline in unix milliseconds, entry is dropped if empty
+const settleClaimScript = `
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score or tonumber(score) ~= tonumber(ARGV[2]) then
+    return 0
+end
+if ARGV[3] == '' then
+    redis.call('ZREM', KEYS[1], ARGV[1])
+else
+    redis.call('ZADD', KEYS[1], 'XX', ARGV[3], ARGV[1])
+end
+return 1
+`
+
+// redisDeadlineQueue stores deadlines in a sorted set scored by unix milliseconds.
+// Leased entries are kept in the same set, scored by the end of their lease.
+type redisDeadlineQueue struct {
+	logger log.Logger
+	client *redis.Client
+	key    string
+
+	claimScript  *redis.Script
+	settleScript *redis.Script
+}
+
+func NewRedisDeadlineQueue(logger log.Logger, client *redis.Client, name string) deadline.Queue {
+	return &redisDeadlineQueue{
+		logger: logger,
+		client: client,
+		key:    fmt.Sprintf("deadline:%s", name),
+
+		claimScript:  redis.NewScript(claimExpiredScript),
+		settleScript: redis.NewScript(settleClaimScript),
+	}
+}
+
+func (q *redisDeadlineQueue) Schedule(ctx context.Context, id string, at time.Time) error {
+	if err := q.client.ZAdd(ctx, q.key, redis.Z{Score: float64(at.UnixMilli()), Member: id}).Err(); err != nil {
+		q.logger.LogErr(REDIS_DEADLINE, err, "could not schedule deadline", "queue", q.key, "id", id, "at", at)
+		return err
+	}
+	q.logger.LogDebug(REDIS_DEADLINE, "schedule deadline", "queue", q.key, "id", id, "at", at)
+	return nil
+}
+
+func (q *redisDeadlineQueue) Cancel(ctx context.Context, id string) error {
+	if err := q.client.ZRem(ctx, q.key, id).Err(); err != nil {
+		q.logger.LogErr(REDIS_DEADLINE, err, "could not cancel deadline", "queue", q.key, "id", id)
+		return err
+	}
+	return nil
+}
+
+func (q *redisDeadlineQueue) ClaimExpired(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]deadline.Claim, error) {
+	until := now.Add(lease).UnixMilli()
+	ids, err := q.claimScript.Run(ctx, q.client, []string{q.key}, now.UnixMilli(), until, limit).StringSlice()
+	if err != nil && err != redis.Nil {
+		q.logger.LogErr(REDIS_DEADLINE, err, "could not run claim expired deadlines script", "queue", q.key)
+		return nil, fmt.Errorf("could not run claim expired deadlines script: %w", err)
+	}
+
+	claims := make([]deadline.Claim, 0, len(ids))
+	for _, id := range ids {
+		claims = append(claims, deadline.Claim{ID: id, Until: time.UnixMilli(until)})
+	}
+	return claims, nil
+}
+
+func (q *redisDeadlineQueue) Ack(ctx context.Context, claim deadline.Claim) error {
+	return q.settle(ctx, claim, "")
+}
+
+func (q *redisDeadlineQueue) Retry(ctx context.Context, claim deadline.Claim, at time.Time) error {
+	return q.settle(ctx, claim, at.UnixMilli())
+}
+
+func (q *redisDeadlineQueue) settle(ctx context.Context, claim deadline.Claim, next interface{}) error {
+	settled, err := q.settleScript.Run(ctx, q.client, []string{q.key}, claim.ID, claim.Until.UnixMilli(), next).Int()
+	if err != nil {
+		q.logger.LogErr(REDIS_DEADLINE, err, "could not run settle claim script", "queue", q.key, "id", claim.ID)
+		return fmt.Errorf("could not run settle claim script: %w", err)
+	}
+	if settled == 0 {
+		q.logger.LogDebug(REDIS_DEADLINE, "claim outdated, deadline moved since", "queue", q.key, "id", claim.ID)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/deadline"
+)
+
+func TestRedisDeadlineQueue(t *testing.T) {
+	endpoint, cleanup := setupRedisContainer(t)
+	defer cleanup()
+
+	client := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer client.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("claim only expired entries in deadline order", func(t *testing.T) {
+		queue := NewRedisDeadlineQueue(log.NewNoopLogger(), client, "order")
+		require.NoError(t, queue.Schedule(ctx, "late", now.Add(-time.Second)))
+		require.NoError(t, queue.Schedule(ctx, "early", now.Add(-time.Minute)))
+		require.NoError(t, queue.Schedule(ctx, "future", now.Add(time.Minute)))
+
+		claims, err := queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"early", "late"}, idsOf(claims))
+
+		claims, err = queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claims)
+	})
+
+	t.Run("cancelled entry is never claimed", func(t *testing.T) {
+		queue := NewRedisDeadlineQueue(log.NewNoopLogger(), client, "cancel")
+		require.NoError(t, queue.Schedule(ctx, "party-1", now.Add(-time.Second)))
+		require.NoError(t, queue.Cancel(ctx, "party-1"))
+
+		claims, err := queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claims)
+	})
+
+	t.Run("leased entry is handed out again once lease lapses unless acknowledged", func(t *testing.T) {
+		queue := NewRedisDeadlineQueue(log.NewNoopLogger(), client, "lease")
+		require.NoError(t, queue.Schedule(ctx, "died", now.Add(-time.Second)))
+		require.NoError(t, queue.Schedule(ctx, "handled", now.Add(-time.Second)))
+
+		claims, err := queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claims, 2)
+		for _, claim := range claims {
+			if claim.ID == "handled" {
+				require.NoError(t, queue.Ack(ctx, claim))
+			}
+		}
+
+		claims, err = queue.ClaimExpired(ctx, now.Add(time.Minute), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"died"}, idsOf(claims))
+	})
+
+	t.Run("retried entry is claimed again at its next deadline", func(t *testing.T) {
+		queue := NewRedisDeadlineQueue(log.NewNoopLogger(), client, "retry")
+		require.NoError(t, queue.Schedule(ctx, "failed", now.Add(-time.Second)))
+
+		claims, err := queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claims, 1)
+		require.NoError(t, queue.Retry(ctx, claims[0], now.Add(time.Second)))
+
+		claims, err = queue.ClaimExpired(ctx, now.Add(time.Second), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"failed"}, idsOf(claims))
+	})
+
+	t.Run("outdated claim leaves rescheduled deadline as is", func(t *testing.T) {
+		queue := NewRedisDeadlineQueue(log.NewNoopLogger(), client, "outdated")
+		require.NoError(t, queue.Schedule(ctx, "party-1", now.Add(-time.Second)))
+
+		claims, err := queue.ClaimExpired(ctx, now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, claims, 1)
+		require.NoError(t, queue.Schedule(ctx, "party-1", now.Add(time.Hour)))
+		require.NoError(t, queue.Ack(ctx, claims[0]))
+
+		claims, err = queue.ClaimExpired(ctx, now.Add(time.Hour), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"party-1"}, idsOf(claims))
+	})
+
+	t.Run("concurrent claims hand each entry out once", func(t *testing.T) {
+		queue := NewRedisDeadlineQueue(log.NewNoopLogger(), client, "concurrent")
+		total := 50
+		for i := 0; i < total; i++ {
+			require.NoError(t, queue.Schedule(ctx, time.Duration(i).String(), now.Add(-time.Second)))
+		}
+
+		var mu sync.Mutex
+		claimed := map[string]int{}
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					claims, err := queue.ClaimExpired(ctx, now, time.Minute, 3)
+					assert.NoError(t, err)
+					if len(claims) == 0 {
+						return
+					}
+					mu.Lock()
+					for _, claim := range claims {
+						claimed[claim.ID]++
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, claimed, total)
+		for id, count := range claimed {
+			assert.Equal(t, 1, count, "entry %s claimed more than once", id)
+		}
+	})
+}
+
+func idsOf(claims []deadline.Claim) []string {
+	ids := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		ids = append(ids, claim.ID)
+	}
+	return ids
+}
+
+func setupRedisContainer(t *testing.T) (string, func()) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7-alpine",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("Ready to accept connections"),
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+
+	endpoint, err := container.Endpoint(ctx, "")
+	require.NoError(t, err)
+
+	cleanup := func() {
+		require.NoError(t, container.Terminate(ctx))
+	}
+
+	return endpoint, cleanup
+}
@@ -1,6 +1,8 @@
 package eventbus
 
-import "context"
+import (
+	"context"
+)
 
 type Event interface {
 	Topic() string
@@ -9,10 +11,75 @@ type Event interface {
 
 type Handler func(ctx context.Context, event Event) error
 
+// SubscriptionID identifies a handler subscribed to a topic, Subscribe returns it to unsubscribe the handler later.
+// Seq tells apart handlers of the same topic and is only meaningful to the bus which issued it.
+type SubscriptionID struct {
+	Topic string
+	Seq   uint64
+}
+
+// SubscribedHandler is a handler kept by a bus along with the id it was subscribed under.
+type SubscribedHandler struct {
+	ID      SubscriptionID
+	Handler Handler
+}
+
+// RemoveSubscription returns handlers without the one subscribed under id, and whether it was found.
+func RemoveSubscription(handlers []SubscribedHandler, id SubscriptionID) ([]SubscribedHandler, bool) {
+	for i, h := range handlers {
+		if h.ID == id {
+			return append(handlers[:i:i], handlers[i+1:]...), true
+		}
+	}
+	return handlers, false
+}
+
 type EventBus interface {
 	Publish(ctx context.Context, event Event) error
 
-	Subscribe(topic string, handler Handler) error
+	// Subscribe hands events of topic to handler, on every instance unless options ask for competing delivery.
+	// Returns the id to unsubscribe handler with.
+	Subscribe(topic string, handler Handler, opts ...SubscribeOption) (SubscriptionID, error)
+
+	// Unsubscribe stops handing events to the handler subscribed under id, unknown ids are ignored.
+	Unsubscribe(id SubscriptionID) error
+}
+
+// Delivery tells which instances hand an event to a subscriber.
+type Delivery int
+
+const (
+	// Broadcast hands every event to the subscriber on every instance,
+	// for state kept by each instance such as connected clients.
+	Broadcast Delivery = iota
+	// Competing hands every event to a single instance among those subscribed with the same group,
+	// for domain handlers which change shared state and must run once per event.
+	Competing
+)
+
+// Subscription is how a subscriber asked for events to be delivered.
+type Subscription struct {
+	Delivery Delivery
+	// Group names subscribers competing for the same events, it is empty for broadcast.
+	Group string
+}
+
+type SubscribeOption func(*Subscription)
+
+// WithCompetingConsumers hands an event to only one of the instances subscribed with group,
+// handlers of different services or restaurants should not share a group.
+func WithCompetingConsumers(group string) SubscribeOption {
+	return func(s *Subscription) {
+		s.Delivery = Competing
+		s.Group = group
+	}
+}
 
-	Unsubscribe(topic string, handler Handler) error
+// NewSubscription applies opts over broadcast delivery.
+func NewSubscription(opts ...SubscribeOption) Subscription {
+	subscription := Subscription{Delivery: Broadcast}
+	for _, opt := range opts {
+		opt(&subscription)
+	}
+	return subscription
 }
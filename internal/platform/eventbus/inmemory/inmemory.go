@@ -0,0 +1,167 @@
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/eventbus"
+)
+
+var INMEMORY_EVENTBUS = "eventbus/inmemory"
+
+// InMemoryEventBus hands events to handlers of the same process, for a single instance deployment and tests.
+// Like the redis buses, events are decoded from JSON through the registry, so handlers get their own copy,
+// and every handler runs in its own goroutine with errors only logged.
+// With a single instance, broadcast and competing subscribers both get every event.
+type InMemoryEventBus struct {
+	logger      log.Logger
+	registry    *eventbus.EventRegistry
+	synchronous bool
+
+	mu       sync.RWMutex
+	handlers map[string][]eventbus.SubscribedHandler
+	seq      uint64
+
+	idle     sync.Mutex
+	inflight int
+	waiters  []chan struct{}
+}
+
+type InMemoryEventBusOption func(*InMemoryEventBus)
+
+// WithSynchronousDelivery runs handlers one after another before Publish returns, so tests see their effects right away.
+// Events published by a handler are handled before it returns as well.
+func WithSynchronousDelivery() InMemoryEventBusOption {
+	return func(bus *InMemoryEventBus) {
+		bus.synchronous = true
+	}
+}
+
+func NewInMemoryEventBus(logger log.Logger, registry *eventbus.EventRegistry, opts ...InMemoryEventBusOption) *InMemoryEventBus {
+	bus := &InMemoryEventBus{
+		logger:   logger,
+		registry: registry,
+		handlers: make(map[string][]eventbus.SubscribedHandler),
+	}
+	for _, opt := range opts {
+		opt(bus)
+	}
+	return bus
+}
+
+func (bus *InMemoryEventBus) Publish(ctx context.Context, event eventbus.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		bus.logger.LogErr(INMEMORY_EVENTBUS, err, "failed to parse event payload", "event", event)
+		return err
+	}
+	bus.logger.LogDebug(INMEMORY_EVENTBUS, "publish event to topic", "topic", event.Topic(), "event", event)
+
+	bus.mu.RLock()
+	handlers := bus.handlers[event.Topic()]
+	bus.mu.RUnlock()
+
+	for _, handler := range handlers {
+		received, err := bus.decode(event.Topic(), data)
+		if err != nil {
+			bus.logger.LogErr(INMEMORY_EVENTBUS, err, "failed to parse the payload of event", "topic", event.Topic())
+			return nil
+		}
+		if received == nil {
+			bus.logger.LogDebug(INMEMORY_EVENTBUS, "unknown event, check event registry configuration", "topic", event.Topic())
+			return nil
+		}
+
+		bus.begin()
+		if bus.synchronous {
+			bus.handle(handler.Handler, received)
+			continue
+		}
+		go bus.handle(handler.Handler, received)
+	}
+	return nil
+}
+
+func (bus *InMemoryEventBus) Subscribe(topic string, handler eventbus.Handler, opts ...eventbus.SubscribeOption) (eventbus.SubscriptionID, error) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.seq++
+	id := eventbus.SubscriptionID{Topic: topic, Seq: bus.seq}
+	bus.handlers[topic] = append(bus.handlers[topic], eventbus.SubscribedHandler{ID: id, Handler: handler})
+	return id, nil
+}
+
+func (bus *InMemoryEventBus) Unsubscribe(id eventbus.SubscriptionID) error {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.handlers[id.Topic], _ = eventbus.RemoveSubscription(bus.handlers[id.Topic], id)
+	if len(bus.handlers[id.Topic]) == 0 {
+		delete(bus.handlers, id.Topic)
+	}
+	return nil
+}
+
+// WaitIdle blocks until no handler is running, including handlers of events published by other handlers meanwhile.
+func (bus *InMemoryEventBus) WaitIdle(ctx context.Context) error {
+	bus.idle.Lock()
+	if bus.inflight == 0 {
+		bus.idle.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	bus.waiters = append(bus.waiters, idle)
+	bus.idle.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (bus *InMemoryEventBus) handle(handler eventbus.Handler, event eventbus.Event) {
+	defer bus.done()
+
+	if err := handler(context.Background(), event); err != nil {
+		bus.logger.LogErr(INMEMORY_EVENTBUS, err, "failed to handle event", "topic", event.Topic())
+	}
+}
+
+// decode copies event of topic from its payload, nil if topic is not registered.
+func (bus *InMemoryEventBus) decode(topic string, data []byte) (eventbus.Event, error) {
+	eventType, ok := bus.registry.GetEventType(topic)
+	if !ok {
+		return nil, nil
+	}
+
+	event := eventType.NewEvent()
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (bus *InMemoryEventBus) begin() {
+	bus.idle.Lock()
+	defer bus.idle.Unlock()
+	bus.inflight++
+}
+
+func (bus *InMemoryEventBus) done() {
+	bus.idle.Lock()
+	defer bus.idle.Unlock()
+
+	bus.inflight--
+	if bus.inflight > 0 {
+		return
+	}
+	for _, idle := range bus.waiters {
+		close(idle)
+	}
+	bus.waiters = nil
+}
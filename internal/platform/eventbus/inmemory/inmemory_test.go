@@ -0,0 +1,176 @@
+package inmemory
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/eventbus"
+)
+
+type TestEvent struct {
+	ID string `json:"id"`
+}
+
+func (e *TestEvent) Topic() string {
+	return "test.event"
+}
+
+func (e *TestEvent) NewEvent() eventbus.Event {
+	return &TestEvent{}
+}
+
+type FollowUpEvent struct {
+	ID string `json:"id"`
+}
+
+func (e *FollowUpEvent) Topic() string {
+	return "test.followup"
+}
+
+func (e *FollowUpEvent) NewEvent() eventbus.Event {
+	return &FollowUpEvent{}
+}
+
+type counter struct {
+	handled atomic.Int32
+}
+
+func (c *counter) handle(ctx context.Context, event eventbus.Event) error {
+	c.handled.Add(1)
+	return nil
+}
+
+func newRegistry() *eventbus.EventRegistry {
+	registry := eventbus.NewEventRegistry()
+	registry.Register("test.event", &TestEvent{})
+	registry.Register("test.followup", &FollowUpEvent{})
+	return registry
+}
+
+func TestInMemoryEventBus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("handlers get their own copy of event", func(t *testing.T) {
+		bus := NewInMemoryEventBus(log.NewNoopLogger(), newRegistry())
+		original := &TestEvent{ID: "test-123"}
+
+		received := make(chan eventbus.Event, 2)
+		handler := func(ctx context.Context, event eventbus.Event) error {
+			received <- event
+			return nil
+		}
+		_, err := bus.Subscribe("test.event", handler)
+		require.NoError(t, err)
+		_, err = bus.Subscribe("test.event", handler, eventbus.WithCompetingConsumers("group"))
+		require.NoError(t, err)
+
+		require.NoError(t, bus.Publish(ctx, original))
+		require.NoError(t, bus.WaitIdle(ctx))
+		require.Len(t, received, 2)
+
+		first, second := <-received, <-received
+		assert.Equal(t, original, first)
+		assert.NotSame(t, original, first)
+		assert.NotSame(t, first, second)
+	})
+
+	t.Run("wait for events published by handlers", func(t *testing.T) {
+		bus := NewInMemoryEventBus(log.NewNoopLogger(), newRegistry())
+
+		var followUps atomic.Int32
+		bus.Subscribe("test.event", func(ctx context.Context, event eventbus.Event) error {
+			time.Sleep(10 * time.Millisecond)
+			return bus.Publish(ctx, &FollowUpEvent{ID: event.(*TestEvent).ID})
+		})
+		bus.Subscribe("test.followup", func(ctx context.Context, event eventbus.Event) error {
+			time.Sleep(10 * time.Millisecond)
+			followUps.Add(1)
+			return nil
+		})
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, bus.Publish(ctx, &TestEvent{ID: "test-123"}))
+		}
+		require.NoError(t, bus.WaitIdle(ctx))
+		assert.Equal(t, int32(5), followUps.Load())
+	})
+
+	t.Run("wait gives up with context", func(t *testing.T) {
+		bus := NewInMemoryEventBus(log.NewNoopLogger(), newRegistry())
+		release := make(chan struct{})
+		defer close(release)
+		bus.Subscribe("test.event", func(ctx context.Context, event eventbus.Event) error {
+			<-release
+			return nil
+		})
+
+		require.NoError(t, bus.Publish(ctx, &TestEvent{ID: "test-123"}))
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, bus.WaitIdle(waitCtx), context.DeadlineExceeded)
+	})
+
+	t.Run("synchronous delivery handles event before publish returns", func(t *testing.T) {
+		bus := NewInMemoryEventBus(log.NewNoopLogger(), newRegistry(), WithSynchronousDelivery())
+
+		handled := []string{}
+		bus.Subscribe("test.event", func(ctx context.Context, event eventbus.Event) error {
+			handled = append(handled, "event")
+			return bus.Publish(ctx, &FollowUpEvent{})
+		})
+		bus.Subscribe("test.followup", func(ctx context.Context, event eventbus.Event) error {
+			handled = append(handled, "followup")
+			return nil
+		})
+
+		require.NoError(t, bus.Publish(ctx, &TestEvent{}))
+		assert.Equal(t, []string{"event", "followup"}, handled)
+	})
+
+	t.Run("unsubscribe handler by subscription id", func(t *testing.T) {
+		bus := NewInMemoryEventBus(log.NewNoopLogger(), newRegistry(), WithSynchronousDelivery())
+
+		downtown, uptown := &counter{}, &counter{}
+		downtownID, err := bus.Subscribe("test.event", downtown.handle)
+		require.NoError(t, err)
+		_, err = bus.Subscribe("test.event", uptown.handle)
+		require.NoError(t, err)
+
+		require.NoError(t, bus.Unsubscribe(downtownID))
+		require.NoError(t, bus.Publish(ctx, &TestEvent{}))
+
+		assert.Equal(t, int32(0), downtown.handled.Load())
+		assert.Equal(t, int32(1), uptown.handled.Load())
+	})
+
+	t.Run("unsubscribe one of the same handler subscribed twice", func(t *testing.T) {
+		bus := NewInMemoryEventBus(log.NewNoopLogger(), newRegistry(), WithSynchronousDelivery())
+
+		c := &counter{}
+		first, err := bus.Subscribe("test.event", c.handle)
+		require.NoError(t, err)
+		_, err = bus.Subscribe("test.event", c.handle)
+		require.NoError(t, err)
+
+		require.NoError(t, bus.Unsubscribe(first))
+		require.NoError(t, bus.Unsubscribe(first))
+		require.NoError(t, bus.Publish(ctx, &TestEvent{}))
+
+		assert.Equal(t, int32(1), c.handled.Load())
+	})
+
+	t.Run("skip unregistered topic", func(t *testing.T) {
+		bus := NewInMemoryEventBus(log.NewNoopLogger(), eventbus.NewEventRegistry(), WithSynchronousDelivery())
+
+		c := &counter{}
+		bus.Subscribe("test.event", c.handle)
+		require.NoError(t, bus.Publish(ctx, &TestEvent{}))
+		assert.Equal(t, int32(0), c.handled.Load())
+	})
+}
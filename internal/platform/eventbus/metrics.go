@@ -0,0 +1,60 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+
+	"queue-bite/internal/platform/metrics"
+)
+
+type instrumentedEventBus struct {
+	bus             EventBus
+	publishLatency  *metrics.HistogramVec
+	publishFailures *metrics.CounterVec
+	handleLatency   *metrics.HistogramVec
+	handleFailures  *metrics.CounterVec
+}
+
+// WithMetrics measures latency and failures of publishing and handling events of bus per topic.
+// Handling covers handlers subscribed through the returned bus only.
+func WithMetrics(bus EventBus, registry *metrics.Registry) EventBus {
+	return &instrumentedEventBus{
+		bus: bus,
+		publishLatency: registry.Histogram("queuebite_eventbus_publish_seconds",
+			"Time taken to publish an event.", metrics.DefaultLatencyBuckets, "topic"),
+		publishFailures: registry.Counter("queuebite_eventbus_publish_failures_total",
+			"Events which could not be published.", "topic"),
+		handleLatency: registry.Histogram("queuebite_eventbus_handle_seconds",
+			"Time taken by a handler to handle an event.", metrics.DefaultLatencyBuckets, "topic"),
+		handleFailures: registry.Counter("queuebite_eventbus_handle_failures_total",
+			"Events a handler failed to handle.", "topic"),
+	}
+}
+
+func (b *instrumentedEventBus) Publish(ctx context.Context, event Event) error {
+	start := time.Now()
+	err := b.bus.Publish(ctx, event)
+	b.publishLatency.ObserveSince(start, event.Topic())
+	if err != nil {
+		b.publishFailures.Inc(event.Topic())
+	}
+	return err
+}
+
+// Subscribe subscribes a measuring handler wrapping handler, the id it returns unsubscribes the wrapper.
+func (b *instrumentedEventBus) Subscribe(topic string, handler Handler, opts ...SubscribeOption) (SubscriptionID, error) {
+	wrapped := func(ctx context.Context, event Event) error {
+		start := time.Now()
+		err := handler(ctx, event)
+		b.handleLatency.ObserveSince(start, topic)
+		if err != nil {
+			b.handleFailures.Inc(topic)
+		}
+		return err
+	}
+	return b.bus.Subscribe(topic, wrapped, opts...)
+}
+
+func (b *instrumentedEventBus) Unsubscribe(id SubscriptionID) error {
+	return b.bus.Unsubscribe(id)
+}
@@ -3,7 +3,12 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
@@ -13,23 +18,86 @@ import (
 
 var REDIS_EVENTBUS = "eventbus/redis"
 
+// REDIS_EVENTBUS_QUEUE_MAX_LEN bounds events waiting for a competing group, oldest ones are dropped first.
+var REDIS_EVENTBUS_QUEUE_MAX_LEN = 10000
+
+// REDIS_EVENTBUS_GROUP_TTL is how long a competing group stays registered while no instance of it pops its queue,
+// publishers stop queueing events for it afterwards and its queue expires.
+var REDIS_EVENTBUS_GROUP_TTL = 10 * time.Minute
+
+// REDIS_EVENTBUS_PUBLISH_ATTEMPTS bounds how many times publishing retries when competing groups changed
+// between reading them and publishing.
+var REDIS_EVENTBUS_PUBLISH_ATTEMPTS = 3
+
+// errStaleGroups is replied by publishScript when competing groups are not the ones the caller read.
+const errStaleGroups = "STALEGROUPS"
+
+// publishScript broadcasts event and pushes it to the queue of every competing group of its topic in one step,
+// so a group subscribing meanwhile either gets the event queued or does not know of it at all.
+// Groups are read by the caller to declare their queues, they are checked again here and the caller retries on errStaleGroups.
+// KEYS[1]: competing groups of topic, scored by when an instance of the group last popped its queue
+// KEYS[2..]: queue of each group of ARGV[6..], in the same order
+// ARGV[1]: topic
+// ARGV[2]: event payload
+// ARGV[3]: max events queued per group
+// ARGV[4]: groups last seen before this time in unix milliseconds are expired
+// ARGV[5]: group ttl in milliseconds, queues expire after it unless pushed again
+// ARGV[6..]: competing groups read by the caller
+const publishScript = `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[4])
+if redis.call('ZCARD', KEYS[1]) ~= #KEYS - 1 then
+    return redis.error_reply('STALEGROUPS')
+end
+for i = 2, #KEYS do
+    if not redis.call('ZSCORE', KEYS[1], ARGV[i + 4]) then
+        return redis.error_reply('STALEGROUPS')
+    end
+end
+for i = 2, #KEYS do
+    redis.call('LPUSH', KEYS[i], ARGV[2])
+    redis.call('LTRIM', KEYS[i], 0, tonumber(ARGV[3]) - 1)
+    redis.call('PEXPIRE', KEYS[i], ARGV[5])
+end
+return redis.call('PUBLISH', ARGV[1], ARGV[2])
+`
+
+// redisEventBus broadcasts events through redis pub/sub, and queues them in a redis list per competing group,
+// popped by one instance of the group. Events are not acknowledged, an instance which fails or dies while handling
+// an event loses it.
 type redisEventBus struct {
-	logger   log.Logger
-	client   *redis.Client
-	sub      *redis.PubSub
-	mu       *sync.RWMutex
-	handlers map[string][]eventbus.Handler
-	registry *eventbus.EventRegistry
+	logger    log.Logger
+	client    *redis.Client
+	sub       *redis.PubSub
+	mu        *sync.RWMutex
+	handlers  map[string][]eventbus.SubscribedHandler
+	competing map[competingKey]*competingGroup
+	registry  *eventbus.EventRegistry
+	seq       uint64
+
+	publishScript *redis.Script
+}
+
+type competingKey struct {
+	topic string
+	group string
+}
+
+type competingGroup struct {
+	handlers []eventbus.SubscribedHandler
+	stop     context.CancelFunc
 }
 
 func NewRedisEventBus(logger log.Logger, client *redis.Client, registry *eventbus.EventRegistry) eventbus.EventBus {
 	bus := &redisEventBus{
-		logger:   logger,
-		client:   client,
-		sub:      client.Subscribe(context.Background()),
-		mu:       &sync.RWMutex{},
-		registry: registry,
-		handlers: make(map[string][]eventbus.Handler),
+		logger:    logger,
+		client:    client,
+		sub:       client.Subscribe(context.Background()),
+		mu:        &sync.RWMutex{},
+		registry:  registry,
+		handlers:  make(map[string][]eventbus.SubscribedHandler),
+		competing: make(map[competingKey]*competingGroup),
+
+		publishScript: redis.NewScript(publishScript),
 	}
 	go bus.startSubscriptionLoop()
 	return bus
@@ -42,7 +110,13 @@ func (bus *redisEventBus) Publish(ctx context.Context, event eventbus.Event) err
 		return err
 	}
 
-	if err := bus.client.Publish(ctx, event.Topic(), data).Err(); err != nil {
+	for attempt := 1; ; attempt++ {
+		err = bus.publish(ctx, event.Topic(), data)
+		if err == nil || !strings.HasPrefix(err.Error(), errStaleGroups) || attempt >= REDIS_EVENTBUS_PUBLISH_ATTEMPTS {
+			break
+		}
+	}
+	if err != nil {
 		bus.logger.LogErr(REDIS_EVENTBUS, err, "failed to publish event to topic", "topic", event.Topic(), "event", event)
 		return err
 	}
@@ -50,42 +124,101 @@ func (bus *redisEventBus) Publish(ctx context.Context, event eventbus.Event) err
 	return nil
 }
 
-func (bus *redisEventBus) Subscribe(topic string, handler eventbus.Handler) error {
+// publish reads competing groups of topic still alive, then publishes data to them through publishScript.
+func (bus *redisEventBus) publish(ctx context.Context, topic string, data []byte) error {
+	expiredBefore := strconv.FormatInt(time.Now().Add(-REDIS_EVENTBUS_GROUP_TTL).UnixMilli(), 10)
+	groups, err := bus.client.ZRangeByScore(ctx, groupsKey(topic), &redis.ZRangeBy{Min: expiredBefore, Max: "+inf"}).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{groupsKey(topic)}
+	args := []interface{}{topic, data, REDIS_EVENTBUS_QUEUE_MAX_LEN, expiredBefore, REDIS_EVENTBUS_GROUP_TTL.Milliseconds()}
+	for _, group := range groups {
+		keys = append(keys, queueKey(topic, group))
+		args = append(args, group)
+	}
+	return bus.publishScript.Run(ctx, bus.client, keys, args...).Err()
+}
+
+func (bus *redisEventBus) Subscribe(topic string, handler eventbus.Handler, opts ...eventbus.SubscribeOption) (eventbus.SubscriptionID, error) {
+	subscription := eventbus.NewSubscription(opts...)
+
 	bus.mu.Lock()
 	defer bus.mu.Unlock()
 
-	bus.handlers[topic] = append(bus.handlers[topic], handler)
+	bus.seq++
+	subscribed := eventbus.SubscribedHandler{ID: eventbus.SubscriptionID{Topic: topic, Seq: bus.seq}, Handler: handler}
+	if subscription.Delivery == eventbus.Competing {
+		if err := bus.subscribeCompeting(topic, subscription.Group, subscribed); err != nil {
+			return eventbus.SubscriptionID{}, err
+		}
+		return subscribed.ID, nil
+	}
 
-	if len(bus.handlers[topic]) == 1 {
+	if len(bus.handlers[topic]) == 0 {
 		if err := bus.sub.Subscribe(context.Background(), topic); err != nil {
 			bus.logger.LogErr(REDIS_EVENTBUS, err, "subscribe to topic of event bus", "topic", topic)
-			return err
+			return eventbus.SubscriptionID{}, err
 		}
 	}
+	bus.handlers[topic] = append(bus.handlers[topic], subscribed)
+
+	return subscribed.ID, nil
+}
+
+// subscribeCompeting registers group of topic so publishers start queueing events for it, and pops them with the first handler.
+// Groups stay registered after unsubscribing, events keep queueing for instances of the group to come back,
+// until no instance of the group popped its queue for REDIS_EVENTBUS_GROUP_TTL.
+// It is called with bus.mu held.
+func (bus *redisEventBus) subscribeCompeting(topic, group string, subscribed eventbus.SubscribedHandler) error {
+	key := competingKey{topic, group}
+	if competing, ok := bus.competing[key]; ok {
+		competing.handlers = append(competing.handlers, subscribed)
+		return nil
+	}
 
+	if err := bus.registerGroup(context.Background(), key); err != nil {
+		bus.logger.LogErr(REDIS_EVENTBUS, err, "register competing group of topic", "topic", topic, "group", group)
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.competing[key] = &competingGroup{handlers: []eventbus.SubscribedHandler{subscribed}, stop: cancel}
+	go bus.startQueueLoop(ctx, key)
 	return nil
 }
 
-func (bus *redisEventBus) Unsubscribe(topic string, handler eventbus.Handler) error {
+func (bus *redisEventBus) Unsubscribe(id eventbus.SubscriptionID) error {
 	bus.mu.Lock()
 	defer bus.mu.Unlock()
 
-	handlers := bus.handlers[topic]
-	for i, h := range handlers {
-		if &h == &handler {
-			bus.handlers[topic] = append(handlers[:i], handlers[i+1:]...)
-			break
+	if handlers, found := eventbus.RemoveSubscription(bus.handlers[id.Topic], id); found {
+		bus.handlers[id.Topic] = handlers
+		if len(handlers) == 0 {
+			delete(bus.handlers, id.Topic)
+			if err := bus.sub.Unsubscribe(context.Background(), id.Topic); err != nil {
+				bus.logger.LogErr(REDIS_EVENTBUS, err, "unsubscribe to topic of event bus", "topic", id.Topic)
+				return err
+			}
 		}
+		return nil
 	}
 
-	if len(bus.handlers[topic]) == 0 {
-		if err := bus.sub.Unsubscribe(context.Background(), topic); err != nil {
-			bus.logger.LogErr(REDIS_EVENTBUS, err, "unsubscribe to topic of event bus", "topic", topic)
-			return err
+	for key, competing := range bus.competing {
+		if key.topic != id.Topic {
+			continue
+		}
+		handlers, found := eventbus.RemoveSubscription(competing.handlers, id)
+		if !found {
+			continue
 		}
-		delete(bus.handlers, topic)
+		competing.handlers = handlers
+		if len(competing.handlers) == 0 {
+			competing.stop()
+			delete(bus.competing, key)
+		}
+		return nil
 	}
-
 	return nil
 }
 
@@ -100,25 +233,74 @@ func (bus *redisEventBus) startSubscriptionLoop() {
 		if !exists {
 			continue
 		}
+		bus.dispatch(msg.Channel, msg.Payload, handlers)
+	}
+}
 
-		eventType, ok := bus.registry.GetEventType(msg.Channel)
-		if !ok {
-			bus.logger.LogDebug(REDIS_EVENTBUS, "unknown event, check event registry configuration", "topic", msg.Channel)
+// registerGroup marks competing group as seen now, publishers queue events for it until it has not been seen for REDIS_EVENTBUS_GROUP_TTL.
+func (bus *redisEventBus) registerGroup(ctx context.Context, key competingKey) error {
+	return bus.client.ZAdd(ctx, groupsKey(key.topic), redis.Z{Score: float64(time.Now().UnixMilli()), Member: key.group}).Err()
+}
+
+// startQueueLoop pops events queued for competing group until ctx is done, keeping the group registered meanwhile.
+func (bus *redisEventBus) startQueueLoop(ctx context.Context, key competingKey) {
+	queue := queueKey(key.topic, key.group)
+	for ctx.Err() == nil {
+		if err := bus.registerGroup(ctx, key); err != nil && ctx.Err() == nil {
+			bus.logger.LogErr(REDIS_EVENTBUS, err, "failed to refresh competing group", "topic", key.topic, "group", key.group)
+		}
+		result, err := bus.client.BRPop(ctx, 5*time.Second, queue).Result()
+		if errors.Is(err, redis.Nil) {
 			continue
 		}
-
-		event := eventType.NewEvent()
-		if err := json.Unmarshal([]byte(msg.Payload), event); err != nil {
-			bus.logger.LogErr(REDIS_EVENTBUS, err, "failed to parse the payload of event", "topic", msg.Channel)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, redis.ErrClosed) {
+				return
+			}
+			bus.logger.LogErr(REDIS_EVENTBUS, err, "failed to pop event of competing group", "topic", key.topic, "group", key.group)
+			if !sleep(ctx, time.Second) {
+				return
+			}
 			continue
 		}
 
-		for _, handler := range handlers {
-			go func(h eventbus.Handler) {
-				if err := h(context.Background(), event); err != nil {
-					bus.logger.LogErr(REDIS_EVENTBUS, err, "failed to handle event")
-				}
-			}(handler)
+		bus.mu.RLock()
+		var handlers []eventbus.SubscribedHandler
+		if competing, ok := bus.competing[key]; ok {
+			handlers = competing.handlers
 		}
+		bus.mu.RUnlock()
+		bus.dispatch(key.topic, result[1], handlers)
+	}
+}
+
+func (bus *redisEventBus) dispatch(topic string, payload string, handlers []eventbus.SubscribedHandler) {
+	eventType, ok := bus.registry.GetEventType(topic)
+	if !ok {
+		bus.logger.LogDebug(REDIS_EVENTBUS, "unknown event, check event registry configuration", "topic", topic)
+		return
+	}
+
+	event := eventType.NewEvent()
+	if err := json.Unmarshal([]byte(payload), event); err != nil {
+		bus.logger.LogErr(REDIS_EVENTBUS, err, "failed to parse the payload of event", "topic", topic)
+		return
+	}
+
+	for _, handler := range handlers {
+		go func(h eventbus.Handler) {
+			if err := h(context.Background(), event); err != nil {
+				bus.logger.LogErr(REDIS_EVENTBUS, err, "failed to handle event")
+			}
+		}(handler.Handler)
 	}
 }
+
+// groupsKey and queueKey share the topic as hash tag, so publishScript touches a single slot on a cluster.
+func groupsKey(topic string) string {
+	return fmt.Sprintf("eventbus:{%s}:groups", topic)
+}
+
+func queueKey(topic, group string) string {
+	return fmt.Sprintf("eventbus:{%s}:queue:%s", topic, group)
+}
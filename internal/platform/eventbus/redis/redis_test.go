@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -72,6 +73,95 @@ func TestRedisEventBusSerializeDeserialize(t *testing.T) {
 	})
 }
 
+func TestRedisEventBusCompetingConsumers(t *testing.T) {
+	endpoint, cleanup := setupRedisContainer(t)
+	defer cleanup()
+
+	client := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer client.Close()
+
+	registry := eventbus.NewEventRegistry()
+	registry.Register("test.event", &TestEvent{})
+	ctx := context.Background()
+
+	first := NewRedisEventBus(log.NewNoopLogger(), client, registry)
+	second := NewRedisEventBus(log.NewNoopLogger(), client, registry)
+
+	var broadcast, competing atomic.Int32
+	for _, bus := range []eventbus.EventBus{first, second} {
+		bus.Subscribe("test.event", func(ctx context.Context, event eventbus.Event) error {
+			broadcast.Add(1)
+			return nil
+		})
+		bus.Subscribe("test.event", func(ctx context.Context, event eventbus.Event) error {
+			competing.Add(1)
+			return nil
+		}, eventbus.WithCompetingConsumers("seatmanager"))
+	}
+	// pub/sub drops events published before the subscription is confirmed
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, first.Publish(ctx, &TestEvent{ID: "test-123"}))
+	}
+
+	assert.Eventually(t, func() bool {
+		return broadcast.Load() == 20 && competing.Load() == 10
+	}, 5*time.Second, 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(10), competing.Load())
+	assert.Equal(t, int64(0), client.LLen(ctx, queueKey("test.event", "seatmanager")).Val())
+
+	t.Cleanup(func() {
+		require.NoError(t, first.(*redisEventBus).sub.Close())
+		require.NoError(t, second.(*redisEventBus).sub.Close())
+	})
+}
+
+func TestRedisEventBusCompetingGroups(t *testing.T) {
+	endpoint, cleanup := setupRedisContainer(t)
+	defer cleanup()
+
+	client := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer client.Close()
+
+	registry := eventbus.NewEventRegistry()
+	registry.Register("test.event", &TestEvent{})
+	ctx := context.Background()
+
+	bus := NewRedisEventBus(log.NewNoopLogger(), client, registry)
+	t.Cleanup(func() {
+		require.NoError(t, bus.(*redisEventBus).sub.Close())
+	})
+
+	t.Run("expire group no instance popped for too long", func(t *testing.T) {
+		require.NoError(t, client.FlushAll(ctx).Err())
+		idle := time.Now().Add(-2 * REDIS_EVENTBUS_GROUP_TTL)
+		require.NoError(t, client.ZAdd(ctx, groupsKey("test.event"), redis.Z{Score: float64(idle.UnixMilli()), Member: "gone"}).Err())
+		require.NoError(t, client.ZAdd(ctx, groupsKey("test.event"), redis.Z{Score: float64(time.Now().UnixMilli()), Member: "alive"}).Err())
+
+		require.NoError(t, bus.Publish(ctx, &TestEvent{ID: "test-123"}))
+
+		assert.Equal(t, []string{"alive"}, client.ZRange(ctx, groupsKey("test.event"), 0, -1).Val())
+		assert.Equal(t, int64(0), client.Exists(ctx, queueKey("test.event", "gone")).Val())
+		assert.Equal(t, int64(1), client.LLen(ctx, queueKey("test.event", "alive")).Val())
+		assert.Greater(t, client.PTTL(ctx, queueKey("test.event", "alive")).Val(), time.Duration(0))
+	})
+
+	t.Run("reject groups changed since they were read", func(t *testing.T) {
+		require.NoError(t, client.FlushAll(ctx).Err())
+		require.NoError(t, client.ZAdd(ctx, groupsKey("test.event"), redis.Z{Score: float64(time.Now().UnixMilli()), Member: "joined"}).Err())
+
+		expiredBefore := time.Now().Add(-REDIS_EVENTBUS_GROUP_TTL).UnixMilli()
+		err := bus.(*redisEventBus).publishScript.Run(ctx, client, []string{groupsKey("test.event")},
+			"test.event", "{}", REDIS_EVENTBUS_QUEUE_MAX_LEN, expiredBefore, REDIS_EVENTBUS_GROUP_TTL.Milliseconds(),
+		).Err()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), errStaleGroups)
+		assert.Equal(t, int64(0), client.Exists(ctx, queueKey("test.event", "joined")).Val())
+	})
+}
+
 func setupRedisContainer(t *testing.T) (string, func()) {
 	ctx := context.Background()
 
@@ -0,0 +1,527 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/eventbus"
+)
+
+var REDIS_STREAM_EVENTBUS = "eventbus/redis-stream"
+
+// redisStreamEventBus keeps events of every topic in a redis stream, so events published while
+// an instance is down or reconnecting wait in the stream until its consumer group reads them.
+// An event is acknowledged once every handler of its topic returned nil, otherwise it stays pending
+// and is delivered again with exponential backoff, up to maxDeliveries, after which it moves to
+// the dead-letter stream of its topic. Entries left pending by a crashed consumer are claimed
+// the same way by the next consumer of the group.
+//
+// Broadcast subscribers of an instance read through the consumer group of the instance, competing subscribers
+// through the group they subscribed with, shared by instances. Every consumer group receives every event while
+// consumers of the same group split events among themselves.
+// Events of a subscription are handled by at most workers at once, a single worker handles them in the order read.
+// A handler which succeeded is not run again when its event is delivered again by the same instance for another
+// handler failing. Delivery is still at least once, as an event left pending by a crashed consumer or unacknowledged
+// for long is handled again from scratch, handlers have to tolerate seeing an event again.
+type redisStreamEventBus struct {
+	logger   log.Logger
+	client   *redis.Client
+	registry *eventbus.EventRegistry
+	group    string
+	consumer string
+
+	maxLen          int64
+	maxDeliveries   int64
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+	block           time.Duration
+	batch           int64
+	workers         int
+
+	mu            sync.RWMutex
+	subscriptions map[subscriptionKey]*streamSubscription
+	seq           uint64
+}
+
+// subscriptionKey is a topic read through a consumer group.
+type subscriptionKey struct {
+	topic string
+	group string
+}
+
+type streamSubscription struct {
+	handlers []eventbus.SubscribedHandler
+	stop     context.CancelFunc
+	// workers holds a slot per event being handled
+	workers chan struct{}
+	// handled keeps handlers which succeeded on events still pending, by event id
+	handled map[string]*handledEvent
+}
+
+// handledEvent is which handlers succeeded on an event, since it was first delivered at.
+type handledEvent struct {
+	handlers map[eventbus.SubscriptionID]bool
+	at       time.Time
+}
+
+type StreamEventBusOption func(*redisStreamEventBus)
+
+// WithStreamMaxLen caps every topic stream to about maxLen latest entries, pending entries trimmed away are lost.
+func WithStreamMaxLen(maxLen int64) StreamEventBusOption {
+	return func(bus *redisStreamEventBus) {
+		bus.maxLen = maxLen
+	}
+}
+
+// WithRetries delivers a failed event up to maxDeliveries times in total, waiting backoff after
+// the first delivery and doubling it after each further one up to maxBackoff.
+// Backoff also bounds how long a handler could run before its event is delivered again.
+func WithRetries(maxDeliveries int64, backoff, maxBackoff time.Duration) StreamEventBusOption {
+	return func(bus *redisStreamEventBus) {
+		bus.maxDeliveries = maxDeliveries
+		bus.retryBackoff = backoff
+		bus.maxRetryBackoff = maxBackoff
+	}
+}
+
+// WithWorkers handles at most workers events of every subscription at once, reading stops while all of them are busy.
+func WithWorkers(workers int) StreamEventBusOption {
+	return func(bus *redisStreamEventBus) {
+		bus.workers = max(workers, 1)
+	}
+}
+
+// NewRedisStreamEventBus consumes broadcast topics through group of this instance, and every topic as consumer,
+// consumer names must be unique among instances.
+func NewRedisStreamEventBus(
+	logger log.Logger,
+	client *redis.Client,
+	registry *eventbus.EventRegistry,
+	group string,
+	consumer string,
+	opts ...StreamEventBusOption,
+) eventbus.EventBus {
+	bus := &redisStreamEventBus{
+		logger:   logger,
+		client:   client,
+		registry: registry,
+		group:    group,
+		consumer: consumer,
+
+		maxLen:          10000,
+		maxDeliveries:   5,
+		retryBackoff:    5 * time.Second,
+		maxRetryBackoff: time.Minute,
+		block:           5 * time.Second,
+		batch:           10,
+		workers:         10,
+
+		subscriptions: make(map[subscriptionKey]*streamSubscription),
+	}
+	for _, opt := range opts {
+		opt(bus)
+	}
+	return bus
+}
+
+func (bus *redisStreamEventBus) Publish(ctx context.Context, event eventbus.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to parse event payload", "event", event)
+		return err
+	}
+
+	if err := bus.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(event.Topic()),
+		MaxLen: bus.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": data},
+	}).Err(); err != nil {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to publish event to topic", "topic", event.Topic(), "event", event)
+		return err
+	}
+	bus.logger.LogDebug(REDIS_STREAM_EVENTBUS, "publish event to topic", "topic", event.Topic(), "event", event)
+	return nil
+}
+
+// Subscribe starts consuming topic through group of the subscription with its first handler,
+// the group created on the way starts from events published after it.
+func (bus *redisStreamEventBus) Subscribe(topic string, handler eventbus.Handler, opts ...eventbus.SubscribeOption) (eventbus.SubscriptionID, error) {
+	options := eventbus.NewSubscription(opts...)
+	key := subscriptionKey{topic: topic, group: bus.group}
+	if options.Delivery == eventbus.Competing {
+		key.group = options.Group
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.seq++
+	subscribed := eventbus.SubscribedHandler{ID: eventbus.SubscriptionID{Topic: topic, Seq: bus.seq}, Handler: handler}
+	if existing, ok := bus.subscriptions[key]; ok {
+		existing.handlers = append(existing.handlers, subscribed)
+		return subscribed.ID, nil
+	}
+
+	if err := bus.createGroup(context.Background(), key); err != nil {
+		return eventbus.SubscriptionID{}, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	subscription := &streamSubscription{
+		handlers: []eventbus.SubscribedHandler{subscribed},
+		stop:     cancel,
+		workers:  make(chan struct{}, bus.workers),
+		handled:  make(map[string]*handledEvent),
+	}
+	bus.subscriptions[key] = subscription
+	go bus.consume(ctx, key, subscription)
+	go bus.reclaim(ctx, key, subscription)
+	return subscribed.ID, nil
+}
+
+// Unsubscribe stops consuming through a group with its last handler, events published meanwhile wait for the group in the stream.
+func (bus *redisStreamEventBus) Unsubscribe(id eventbus.SubscriptionID) error {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	for key, subscription := range bus.subscriptions {
+		if key.topic != id.Topic {
+			continue
+		}
+		handlers, found := eventbus.RemoveSubscription(subscription.handlers, id)
+		if !found {
+			continue
+		}
+		subscription.handlers = handlers
+		if len(subscription.handlers) == 0 {
+			subscription.stop()
+			delete(bus.subscriptions, key)
+		}
+		return nil
+	}
+	return nil
+}
+
+func (bus *redisStreamEventBus) createGroup(ctx context.Context, key subscriptionKey) error {
+	err := bus.client.XGroupCreateMkStream(ctx, streamKey(key.topic), key.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "could not create consumer group of topic", "topic", key.topic, "group", key.group)
+		return err
+	}
+	return nil
+}
+
+// consume reads new events of subscription until ctx is done, reconnecting after failures.
+func (bus *redisStreamEventBus) consume(ctx context.Context, key subscriptionKey, subscription *streamSubscription) {
+	for ctx.Err() == nil {
+		streams, err := bus.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    key.group,
+			Consumer: bus.consumer,
+			Streams:  []string{streamKey(key.topic), ">"},
+			Count:    bus.batch,
+			Block:    bus.block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, redis.ErrClosed) {
+				return
+			}
+			bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to read events of topic", "topic", key.topic, "group", key.group)
+			// redis restarted without persistence forgets the group along with the stream
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				bus.createGroup(ctx, key)
+			}
+			if !sleep(ctx, bus.retryBackoff) {
+				return
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				if !bus.dispatch(ctx, key, subscription, msg) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// reclaim periodically claims events of subscription pending longer than their backoff, failed by a handler
+// or left behind by a crashed consumer of the group, and delivers them again or moves them to the dead-letter stream.
+func (bus *redisStreamEventBus) reclaim(ctx context.Context, key subscriptionKey, subscription *streamSubscription) {
+	ticker := time.NewTicker(bus.retryBackoff)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			bus.forgetHandled(subscription)
+			bus.reclaimPending(ctx, key, subscription)
+		}
+	}
+}
+
+func (bus *redisStreamEventBus) reclaimPending(ctx context.Context, key subscriptionKey, subscription *streamSubscription) {
+	pending, err := bus.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: streamKey(key.topic),
+		Group:  key.group,
+		Idle:   bus.retryBackoff,
+		Start:  "-",
+		End:    "+",
+		Count:  bus.batch,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil && !errors.Is(err, redis.ErrClosed) {
+			bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to list pending events of topic", "topic", key.topic, "group", key.group)
+		}
+		return
+	}
+
+	for _, entry := range pending {
+		backoff := bus.backoff(entry.RetryCount)
+		if entry.Idle < backoff {
+			continue
+		}
+
+		// claiming checks idle time again, so only one consumer of the group wins the entry
+		msgs, err := bus.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   streamKey(key.topic),
+			Group:    key.group,
+			Consumer: bus.consumer,
+			MinIdle:  backoff,
+			Messages: []string{entry.ID},
+		}).Result()
+		if err != nil {
+			bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to claim pending event", "topic", key.topic, "group", key.group, "id", entry.ID)
+			continue
+		}
+
+		for _, msg := range msgs {
+			if entry.RetryCount >= bus.maxDeliveries {
+				bus.deadLetter(key, subscription, msg, entry.RetryCount, "too many deliveries")
+				continue
+			}
+			bus.logger.LogDebug(REDIS_STREAM_EVENTBUS, "deliver pending event again", "topic", key.topic, "group", key.group, "id", msg.ID,
+				"deliveries", entry.RetryCount, "previous consumer", entry.Consumer)
+			if !bus.dispatch(ctx, key, subscription, msg) {
+				return
+			}
+		}
+	}
+}
+
+// dispatch delivers event on a worker of subscription once one is free, it tells false if ctx is done first.
+func (bus *redisStreamEventBus) dispatch(ctx context.Context, key subscriptionKey, subscription *streamSubscription, msg redis.XMessage) bool {
+	select {
+	case subscription.workers <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+	go func() {
+		defer func() { <-subscription.workers }()
+		bus.deliver(key, subscription, msg)
+	}()
+	return true
+}
+
+// deliver hands event to every handler of subscription which did not succeed on it yet,
+// and acknowledges it once all of them succeeded.
+func (bus *redisStreamEventBus) deliver(key subscriptionKey, subscription *streamSubscription, msg redis.XMessage) {
+	event, err := bus.decode(key.topic, msg)
+	if err != nil {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to parse the payload of event", "topic", key.topic, "id", msg.ID)
+		bus.deadLetter(key, subscription, msg, 1, err.Error())
+		return
+	}
+
+	bus.mu.Lock()
+	if len(subscription.handlers) == 0 {
+		// unsubscribed meanwhile, leave event pending for the group
+		bus.mu.Unlock()
+		return
+	}
+	handled, ok := subscription.handled[msg.ID]
+	if !ok {
+		handled = &handledEvent{handlers: make(map[eventbus.SubscriptionID]bool), at: time.Now()}
+		subscription.handled[msg.ID] = handled
+	}
+	pending := make([]eventbus.SubscribedHandler, 0, len(subscription.handlers))
+	for _, handler := range subscription.handlers {
+		if !handled.handlers[handler.ID] {
+			pending = append(pending, handler)
+		}
+	}
+	bus.mu.Unlock()
+
+	errs := make([]error, len(pending))
+	wg := sync.WaitGroup{}
+	for i, handler := range pending {
+		wg.Add(1)
+		go func(i int, h eventbus.Handler) {
+			defer wg.Done()
+			errs[i] = h(context.Background(), event)
+		}(i, handler.Handler)
+	}
+	wg.Wait()
+
+	bus.mu.Lock()
+	for i, handler := range pending {
+		if errs[i] == nil {
+			handled.handlers[handler.ID] = true
+		}
+	}
+	bus.mu.Unlock()
+
+	if err := errors.Join(errs...); err != nil {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to handle event, it will be delivered again", "topic", key.topic, "group", key.group, "id", msg.ID)
+		return
+	}
+	if err := bus.client.XAck(context.Background(), streamKey(key.topic), key.group, msg.ID).Err(); err != nil {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to acknowledge event", "topic", key.topic, "group", key.group, "id", msg.ID)
+		return
+	}
+	bus.forget(subscription, msg.ID)
+}
+
+func (bus *redisStreamEventBus) forget(subscription *streamSubscription, id string) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	delete(subscription.handled, id)
+}
+
+// forgetHandled drops handlers kept for events first delivered longer ago than every delivery could take,
+// such events were claimed by another consumer of the group meanwhile.
+func (bus *redisStreamEventBus) forgetHandled(subscription *streamSubscription) {
+	horizon := time.Duration(bus.maxDeliveries+1) * bus.maxRetryBackoff
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	for id, handled := range subscription.handled {
+		if time.Since(handled.at) > horizon {
+			delete(subscription.handled, id)
+		}
+	}
+}
+
+func (bus *redisStreamEventBus) decode(topic string, msg redis.XMessage) (eventbus.Event, error) {
+	eventType, ok := bus.registry.GetEventType(topic)
+	if !ok {
+		return nil, fmt.Errorf("unknown event of topic %s, check event registry configuration", topic)
+	}
+
+	payload, ok := msg.Values["event"].(string)
+	if !ok {
+		return nil, fmt.Errorf("event %s of topic %s has no payload", msg.ID, topic)
+	}
+	event := eventType.NewEvent()
+	if err := json.Unmarshal([]byte(payload), event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// deadLetter moves event out of pending entries of the group into the dead-letter stream of topic, kept for inspection.
+func (bus *redisStreamEventBus) deadLetter(key subscriptionKey, subscription *streamSubscription, msg redis.XMessage, deliveries int64, reason string) {
+	ctx := context.Background()
+	if err := bus.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: deadLetterKey(key.topic),
+		MaxLen: bus.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"event":      msg.Values["event"],
+			"id":         msg.ID,
+			"group":      key.group,
+			"deliveries": deliveries,
+			"reason":     reason,
+		},
+	}).Err(); err != nil {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to move event to dead-letter stream", "topic", key.topic, "group", key.group, "id", msg.ID)
+		return
+	}
+	if err := bus.client.XAck(ctx, streamKey(key.topic), key.group, msg.ID).Err(); err != nil {
+		bus.logger.LogErr(REDIS_STREAM_EVENTBUS, err, "failed to acknowledge dead-lettered event", "topic", key.topic, "group", key.group, "id", msg.ID)
+		return
+	}
+	bus.forget(subscription, msg.ID)
+	bus.logger.LogInfo(REDIS_STREAM_EVENTBUS, "event moved to dead-letter stream", "topic", key.topic, "group", key.group, "id", msg.ID, "deliveries", deliveries, "reason", reason)
+}
+
+// backoff is how long an event delivered deliveries times waits before the next delivery.
+func (bus *redisStreamEventBus) backoff(deliveries int64) time.Duration {
+	backoff := bus.retryBackoff
+	for i := int64(1); i < deliveries && backoff < bus.maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, bus.maxRetryBackoff)
+}
+
+// DestroyIdleGroups destroys consumer groups on streams of topics whose consumers have all been idle for longer than idle,
+// left behind by instances which are gone, except groups in keep. Events still pending for them are dropped along.
+// Consumers read at least every block timeout, and redis 7.2 or later counts every read as activity,
+// so a live consumer never looks idle. A live group destroyed anyway is created again on its next read.
+// Returns the destroyed groups as topic/group.
+func DestroyIdleGroups(ctx context.Context, client *redis.Client, topics []string, idle time.Duration, keep ...string) ([]string, error) {
+	destroyed := []string{}
+	for _, topic := range topics {
+		groups, err := client.XInfoGroups(ctx, streamKey(topic)).Result()
+		if err != nil {
+			if strings.Contains(err.Error(), "no such key") {
+				continue
+			}
+			return destroyed, err
+		}
+
+		for _, group := range groups {
+			if slices.Contains(keep, group.Name) {
+				continue
+			}
+			consumers, err := client.XInfoConsumers(ctx, streamKey(topic), group.Name).Result()
+			if err != nil {
+				return destroyed, err
+			}
+			if slices.ContainsFunc(consumers, func(c redis.XInfoConsumer) bool { return c.Idle <= idle }) {
+				continue
+			}
+			if err := client.XGroupDestroy(ctx, streamKey(topic), group.Name).Err(); err != nil {
+				return destroyed, err
+			}
+			destroyed = append(destroyed, topic+"/"+group.Name)
+		}
+	}
+	return destroyed, nil
+}
+
+func streamKey(topic string) string {
+	return fmt.Sprintf("eventbus:%s", topic)
+}
+
+func deadLetterKey(topic string) string {
+	return fmt.Sprintf("eventbus:%s:dead", topic)
+}
+
+// sleep waits for d unless ctx is done first, it tells whether the wait completed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,302 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/eventbus"
+)
+
+func TestRedisStreamEventBus(t *testing.T) {
+	endpoint, cleanup := setupRedisContainer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	registry := eventbus.NewEventRegistry()
+	registry.Register("test.event", &TestEvent{})
+
+	newClient := func(t *testing.T) *redis.Client {
+		client := redis.NewClient(&redis.Options{Addr: endpoint})
+		t.Cleanup(func() {
+			client.FlushAll(ctx)
+			client.Close()
+		})
+		return client
+	}
+	pending := func(client *redis.Client, group string) int64 {
+		summary, err := client.XPending(ctx, streamKey("test.event"), group).Result()
+		require.NoError(t, err)
+		return summary.Count
+	}
+
+	t.Run("acknowledge handled event", func(t *testing.T) {
+		client := newClient(t)
+		bus := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "group", "consumer")
+
+		received := make(chan eventbus.Event, 1)
+		handler := func(ctx context.Context, event eventbus.Event) error {
+			received <- event
+			return nil
+		}
+		defer bus.Unsubscribe(subscribe(t, bus, "test.event", handler))
+
+		original := &TestEvent{ID: "test-123", Time: time.Now().UTC(), Message: "test message"}
+		require.NoError(t, bus.Publish(ctx, original))
+
+		select {
+		case event := <-received:
+			assert.Equal(t, original.ID, event.(*TestEvent).ID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+		assert.Eventually(t, func() bool { return pending(client, "group") == 0 }, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("every group receives event published before it reads", func(t *testing.T) {
+		client := newClient(t)
+		first := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "first", "consumer")
+		second := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "second", "consumer")
+
+		var handled atomic.Int32
+		handler := func(ctx context.Context, event eventbus.Event) error {
+			handled.Add(1)
+			return nil
+		}
+		defer first.Unsubscribe(subscribe(t, first, "test.event", handler))
+		// second is away, the event waits for it in the stream
+		require.NoError(t, second.Unsubscribe(subscribe(t, second, "test.event", handler)))
+
+		require.NoError(t, first.Publish(ctx, &TestEvent{ID: "test-123"}))
+		assert.Eventually(t, func() bool { return handled.Load() == 1 }, 5*time.Second, 50*time.Millisecond)
+
+		defer second.Unsubscribe(subscribe(t, second, "test.event", handler))
+		assert.Eventually(t, func() bool { return handled.Load() == 2 }, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("competing subscribers of instances share events", func(t *testing.T) {
+		client := newClient(t)
+		first := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "first", "first")
+		second := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "second", "second")
+
+		var broadcast, competing atomic.Int32
+		broadcastHandler := func(ctx context.Context, event eventbus.Event) error {
+			broadcast.Add(1)
+			return nil
+		}
+		competingHandler := func(ctx context.Context, event eventbus.Event) error {
+			competing.Add(1)
+			return nil
+		}
+		for _, bus := range []eventbus.EventBus{first, second} {
+			defer bus.Unsubscribe(subscribe(t, bus, "test.event", broadcastHandler))
+			defer bus.Unsubscribe(subscribe(t, bus, "test.event", competingHandler, eventbus.WithCompetingConsumers("seatmanager")))
+		}
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, first.Publish(ctx, &TestEvent{ID: "test-123"}))
+		}
+
+		assert.Eventually(t, func() bool {
+			return broadcast.Load() == 20 && competing.Load() == 10 && pending(client, "seatmanager") == 0
+		}, 5*time.Second, 50*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, int32(10), competing.Load())
+	})
+
+	t.Run("retry failed event then move it to dead-letter stream", func(t *testing.T) {
+		client := newClient(t)
+		bus := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "group", "consumer",
+			WithRetries(3, 100*time.Millisecond, 200*time.Millisecond))
+
+		var deliveries atomic.Int32
+		handler := func(ctx context.Context, event eventbus.Event) error {
+			deliveries.Add(1)
+			return errors.New("seats are not ready yet")
+		}
+		defer bus.Unsubscribe(subscribe(t, bus, "test.event", handler))
+
+		require.NoError(t, bus.Publish(ctx, &TestEvent{ID: "test-123"}))
+
+		assert.Eventually(t, func() bool {
+			return client.XLen(ctx, deadLetterKey("test.event")).Val() == 1
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, int32(3), deliveries.Load())
+		assert.Equal(t, int64(0), pending(client, "group"))
+
+		dead, err := client.XRange(ctx, deadLetterKey("test.event"), "-", "+").Result()
+		require.NoError(t, err)
+		event := &TestEvent{}
+		require.NoError(t, json.Unmarshal([]byte(dead[0].Values["event"].(string)), event))
+		assert.Equal(t, "test-123", event.ID)
+		assert.Equal(t, "3", dead[0].Values["deliveries"])
+	})
+
+	t.Run("recover failed event once handler succeeds", func(t *testing.T) {
+		client := newClient(t)
+		bus := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "group", "consumer",
+			WithRetries(5, 100*time.Millisecond, 100*time.Millisecond))
+
+		var deliveries atomic.Int32
+		handler := func(ctx context.Context, event eventbus.Event) error {
+			if deliveries.Add(1) < 2 {
+				return errors.New("redis is reconnecting")
+			}
+			return nil
+		}
+		defer bus.Unsubscribe(subscribe(t, bus, "test.event", handler))
+
+		require.NoError(t, bus.Publish(ctx, &TestEvent{ID: "test-123"}))
+
+		assert.Eventually(t, func() bool {
+			return deliveries.Load() == 2 && pending(client, "group") == 0
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, int64(0), client.XLen(ctx, deadLetterKey("test.event")).Val())
+	})
+
+	t.Run("retry only handlers which failed", func(t *testing.T) {
+		client := newClient(t)
+		bus := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "group", "consumer",
+			WithRetries(5, 100*time.Millisecond, 100*time.Millisecond))
+
+		var succeeded, failing atomic.Int32
+		defer bus.Unsubscribe(subscribe(t, bus, "test.event", func(ctx context.Context, event eventbus.Event) error {
+			succeeded.Add(1)
+			return nil
+		}))
+		defer bus.Unsubscribe(subscribe(t, bus, "test.event", func(ctx context.Context, event eventbus.Event) error {
+			if failing.Add(1) < 3 {
+				return errors.New("redis is reconnecting")
+			}
+			return nil
+		}))
+
+		require.NoError(t, bus.Publish(ctx, &TestEvent{ID: "test-123"}))
+
+		assert.Eventually(t, func() bool {
+			return failing.Load() == 3 && pending(client, "group") == 0
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, int32(1), succeeded.Load(), "handler which succeeded is not run again")
+	})
+
+	t.Run("single worker handles events in order", func(t *testing.T) {
+		client := newClient(t)
+		bus := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "group", "consumer", WithWorkers(1))
+
+		received := make(chan string, 20)
+		var running atomic.Int32
+		defer bus.Unsubscribe(subscribe(t, bus, "test.event", func(ctx context.Context, event eventbus.Event) error {
+			if running.Add(1) > 1 {
+				t.Error("events handled at once by a single worker")
+			}
+			defer running.Add(-1)
+			time.Sleep(5 * time.Millisecond)
+			received <- event.(*TestEvent).ID
+			return nil
+		}))
+
+		published := []string{}
+		for i := 0; i < 20; i++ {
+			id := fmt.Sprintf("test-%d", i)
+			published = append(published, id)
+			require.NoError(t, bus.Publish(ctx, &TestEvent{ID: id}))
+		}
+
+		handled := []string{}
+		for range published {
+			select {
+			case id := <-received:
+				handled = append(handled, id)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for events")
+			}
+		}
+		assert.Equal(t, published, handled)
+	})
+
+	t.Run("reclaim event left pending by crashed consumer", func(t *testing.T) {
+		client := newClient(t)
+		crashed := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "group", "crashed")
+		require.NoError(t, crashed.(*redisStreamEventBus).createGroup(ctx, subscriptionKey{"test.event", "group"}))
+		require.NoError(t, crashed.Publish(ctx, &TestEvent{ID: "test-123"}))
+
+		// crashed consumer reads the event but never acknowledges it
+		_, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    "group",
+			Consumer: "crashed",
+			Streams:  []string{streamKey("test.event"), ">"},
+		}).Result()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), pending(client, "group"))
+
+		alive := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "group", "alive",
+			WithRetries(5, 100*time.Millisecond, 100*time.Millisecond))
+		received := make(chan eventbus.Event, 1)
+		handler := func(ctx context.Context, event eventbus.Event) error {
+			received <- event
+			return nil
+		}
+		defer alive.Unsubscribe(subscribe(t, alive, "test.event", handler))
+
+		select {
+		case event := <-received:
+			assert.Equal(t, "test-123", event.(*TestEvent).ID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for reclaimed event")
+		}
+		assert.Eventually(t, func() bool { return pending(client, "group") == 0 }, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("destroy groups left idle by instances which are gone", func(t *testing.T) {
+		client := newClient(t)
+		gone := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "gone", "gone-1")
+		require.NoError(t, gone.(*redisStreamEventBus).createGroup(ctx, subscriptionKey{"test.event", "gone"}))
+		_, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    "gone",
+			Consumer: "gone-1",
+			Streams:  []string{streamKey("test.event"), ">"},
+			Block:    -1,
+		}).Result()
+		require.ErrorIs(t, err, redis.Nil)
+
+		handler := func(ctx context.Context, event eventbus.Event) error { return nil }
+		alive := NewRedisStreamEventBus(log.NewNoopLogger(), client, registry, "alive", "alive-1")
+		defer alive.Unsubscribe(subscribe(t, alive, "test.event", handler))
+		time.Sleep(200 * time.Millisecond)
+
+		destroyed, err := DestroyIdleGroups(ctx, client, []string{"test.event", "unknown.event"}, 100*time.Millisecond, "alive")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test.event/gone"}, destroyed)
+
+		groups, err := client.XInfoGroups(ctx, streamKey("test.event")).Result()
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Equal(t, "alive", groups[0].Name)
+	})
+}
+
+func TestStreamBackoff(t *testing.T) {
+	bus := NewRedisStreamEventBus(log.NewNoopLogger(), nil, eventbus.NewEventRegistry(), "group", "consumer",
+		WithRetries(10, time.Second, 5*time.Second)).(*redisStreamEventBus)
+
+	assert.Equal(t, time.Second, bus.backoff(1))
+	assert.Equal(t, 2*time.Second, bus.backoff(2))
+	assert.Equal(t, 4*time.Second, bus.backoff(3))
+	assert.Equal(t, 5*time.Second, bus.backoff(4))
+	assert.Equal(t, 5*time.Second, bus.backoff(60))
+}
+
+func subscribe(t *testing.T, bus eventbus.EventBus, topic string, handler eventbus.Handler, opts ...eventbus.SubscribeOption) eventbus.SubscriptionID {
+	id, err := bus.Subscribe(topic, handler, opts...)
+	require.NoError(t, err)
+	return id
+}
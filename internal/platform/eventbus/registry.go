@@ -1,6 +1,9 @@
 package eventbus
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 type EventRegistry struct {
 	eventTypes map[string]Event
@@ -25,3 +28,16 @@ func (r *EventRegistry) GetEventType(topic string) (Event, bool) {
 	et, ok := r.eventTypes[topic]
 	return et, ok
 }
+
+// Topics lists registered topics in order.
+func (r *EventRegistry) Topics() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topics := make([]string, 0, len(r.eventTypes))
+	for topic := range r.eventTypes {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
@@ -0,0 +1,299 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are upper bounds in seconds for latencies of in-process work and round trips to redis.
+var DefaultLatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Registry keeps metrics of the process and writes them in the Prometheus text exposition format.
+// Metrics are looked up by name, asking for an already registered one returns it,
+// so components of every restaurant share the same metric and tell themselves apart by labels.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+type family interface {
+	write(ctx context.Context, w io.Writer) error
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]family{}}
+}
+
+// Counter returns counter vector of name, registering it if it does not exist yet.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[name].(*CounterVec); ok {
+		return existing
+	}
+	counter := &CounterVec{meta: meta{name: name, help: help, labels: labels}, values: map[string]*counterValue{}}
+	r.families[name] = counter
+	return counter
+}
+
+// Histogram returns histogram vector of name with upper bounds of buckets, registering it if it does not exist yet.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[name].(*HistogramVec); ok {
+		return existing
+	}
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	histogram := &HistogramVec{meta: meta{name: name, help: help, labels: labels}, buckets: sorted, values: map[string]*histogramValue{}}
+	r.families[name] = histogram
+	return histogram
+}
+
+// GaugeCollector reports current values of a gauge while metrics are written, observe is called once per label set.
+type GaugeCollector func(ctx context.Context, observe func(value float64, labelValues ...string))
+
+// GaugeFunc registers gauge of name whose values are collected on every scrape,
+// collectors added to an existing gauge report next to the ones before.
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect GaugeCollector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[name].(*gaugeFunc); ok {
+		existing.collectors = append(existing.collectors, collect)
+		return
+	}
+	r.families[name] = &gaugeFunc{meta: meta{name: name, help: help, labels: labels}, collectors: []GaugeCollector{collect}}
+}
+
+// WriteTo writes every metric sorted by name.
+func (r *Registry) WriteTo(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]family, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		families = append(families, r.families[name])
+	}
+	r.mu.Unlock()
+
+	for _, family := range families {
+		if err := family.write(ctx, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type meta struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (m meta) writeHeader(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, escapeHelp(m.help), m.name, kind)
+	return err
+}
+
+// labelPairs formats labels with values as {a="1",b="2"}, extra pairs go last such as le of buckets.
+func (m meta) labelPairs(values []string, extra ...string) string {
+	pairs := []string{}
+	for i, label := range m.labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, escapeLabelValue(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabelValue(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec counts events per label set, values only go up.
+// A nil CounterVec counts nothing, so components could leave metrics out.
+type CounterVec struct {
+	meta
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// Inc adds one to the counter of label values, given in order of labels of the counter.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if c == nil || delta < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := strings.Join(labelValues, "\xff")
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labelValues: labelValues}
+		c.values[key] = value
+	}
+	value.value += delta
+}
+
+func (c *CounterVec) write(ctx context.Context, w io.Writer) error {
+	if err := c.writeHeader(w, "counter"); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(value.labelValues), formatFloat(value.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HistogramVec buckets observed values per label set, a nil HistogramVec observes nothing.
+type HistogramVec struct {
+	meta
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := strings.Join(labelValues, "\xff")
+	histogram, ok := h.values[key]
+	if !ok {
+		histogram = &histogramValue{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = histogram
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			histogram.counts[i]++
+		}
+	}
+	histogram.count++
+	histogram.sum += value
+}
+
+// ObserveSince observes seconds passed since start.
+func (h *HistogramVec) ObserveSince(start time.Time, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *HistogramVec) write(ctx context.Context, w io.Writer) error {
+	if err := h.writeHeader(w, "histogram"); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.values) {
+		value := h.values[key]
+		for i, bound := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(value.labelValues, "le", formatFloat(bound)), value.counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelPairs(value.labelValues, "le", "+Inf"), value.count,
+			h.name, h.labelPairs(value.labelValues), formatFloat(value.sum),
+			h.name, h.labelPairs(value.labelValues), value.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type gaugeFunc struct {
+	meta
+	collectors []GaugeCollector
+}
+
+func (g *gaugeFunc) write(ctx context.Context, w io.Writer) error {
+	if err := g.writeHeader(w, "gauge"); err != nil {
+		return err
+	}
+
+	var err error
+	for _, collect := range g.collectors {
+		collect(ctx, func(value float64, labelValues ...string) {
+			if err == nil {
+				_, err = fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(labelValues), formatFloat(value))
+			}
+		})
+	}
+	return err
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
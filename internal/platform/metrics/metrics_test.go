@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWriteTo(t *testing.T) {
+	registry := NewRegistry()
+
+	retries := registry.Counter("retries_total", "Retries.", "restaurant")
+	retries.Inc("default")
+	retries.Add(2, "default")
+	// asking again by name shares the counter
+	registry.Counter("retries_total", "Retries.", "restaurant").Inc(`quote"d`)
+
+	latency := registry.Histogram("latency_seconds", "Latency.", []float64{0.5, 0.1}, "topic")
+	latency.Observe(0.05, "party.ready")
+	latency.Observe(0.3, "party.ready")
+	latency.Observe(2, "party.ready")
+
+	registry.GaugeFunc("queue_parties", "Queued parties.", []string{"area"}, func(ctx context.Context, observe func(float64, ...string)) {
+		observe(3, "table")
+	})
+	registry.GaugeFunc("queue_parties", "Queued parties.", []string{"area"}, func(ctx context.Context, observe func(float64, ...string)) {
+		observe(1, "counter")
+	})
+
+	var nilCounter *CounterVec
+	nilCounter.Inc("ignored")
+
+	out := &strings.Builder{}
+	require.NoError(t, registry.WriteTo(context.Background(), out))
+	assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{topic="party.ready",le="0.1"} 1
+latency_seconds_bucket{topic="party.ready",le="0.5"} 2
+latency_seconds_bucket{topic="party.ready",le="+Inf"} 3
+latency_seconds_sum{topic="party.ready"} 2.35
+latency_seconds_count{topic="party.ready"} 3
+# HELP queue_parties Queued parties.
+# TYPE queue_parties gauge
+queue_parties{area="table"} 3
+queue_parties{area="counter"} 1
+# HELP retries_total Retries.
+# TYPE retries_total counter
+retries_total{restaurant="default"} 3
+retries_total{restaurant="quote\"d"} 1
+`, out.String())
+}
@@ -0,0 +1,13 @@
+package ratelimit
+
+import "context"
+
+// Limiter counts attempts per key over a fixed window shared by every server instance,
+// guarding endpoints whose secrets are short enough to be guessed, such as party recovery codes.
+type Limiter interface {
+	// Allow records an attempt of key and reports whether it is still under the limit of current window.
+	Allow(ctx context.Context, key string) (bool, error)
+
+	// Reached reports whether key already made as many attempts as the limit of current window, without recording one.
+	Reached(ctx context.Context, key string) (bool, error)
+}
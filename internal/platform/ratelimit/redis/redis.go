@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	log "queue-bite/internal/config/logger"
+	"queue-bite/internal/platform/ratelimit"
+)
+
+var REDIS_RATE_LIMIT = "ratelimit/redis"
+
+// countAttemptScript increments the counter of key and starts its window on the first attempt,
+// in one step so the counter never outlives a failed expire.
+// KEYS[1]: attempt counter
+// ARGV[1]: window in milliseconds
+const countAttemptScript = `
+local attempts = redis.call('INCR', KEYS[1])
+if attempts == 1 then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return attempts
+`
+
+// redisLimiter keeps a counter per key, expiring a window after its first attempt.
+type redisLimiter struct {
+	logger log.Logger
+	client *redis.Client
+	prefix string
+	limit  int
+	window time.Duration
+
+	countScript *redis.Script
+}
+
+func NewRedisLimiter(logger log.Logger, client *redis.Client, name string, limit int, window time.Duration) ratelimit.Limiter {
+	return &redisLimiter{
+		logger: logger,
+		client: client,
+		prefix: fmt.Sprintf("ratelimit:%s:", name),
+		limit:  limit,
+		window: window,
+
+		countScript: redis.NewScript(countAttemptScript),
+	}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	attempts, err := l.countScript.Run(ctx, l.client, []string{l.prefix + key}, l.window.Milliseconds()).Int()
+	if err != nil {
+		l.logger.LogErr(REDIS_RATE_LIMIT, err, "could not run count attempt script", "key", l.prefix+key)
+		return false, fmt.Errorf("could not run count attempt script: %w", err)
+	}
+	if attempts > l.limit {
+		l.logger.LogDebug(REDIS_RATE_LIMIT, "attempt over limit", "key", l.prefix+key, "attempts", attempts)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (l *redisLimiter) Reached(ctx context.Context, key string) (bool, error) {
+	attempts, err := l.client.Get(ctx, l.prefix+key).Int()
+	if err != nil && err != redis.Nil {
+		l.logger.LogErr(REDIS_RATE_LIMIT, err, "could not get attempt counter", "key", l.prefix+key)
+		return false, fmt.Errorf("could not get attempt counter: %w", err)
+	}
+	return attempts >= l.limit, nil
+}
@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	log "queue-bite/internal/config/logger"
+)
+
+func TestRedisLimiter(t *testing.T) {
+	endpoint, cleanup := setupRedisContainer(t)
+	defer cleanup()
+
+	client := redis.NewClient(&redis.Options{Addr: endpoint})
+	defer client.Close()
+
+	ctx := context.Background()
+
+	t.Run("reject attempts over limit within window", func(t *testing.T) {
+		limiter := NewRedisLimiter(log.NewNoopLogger(), client, "limit", 3, time.Minute)
+		for i := 0; i < 3; i++ {
+			allowed, err := limiter.Allow(ctx, "client-1")
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		}
+
+		allowed, err := limiter.Allow(ctx, "client-1")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		allowed, err = limiter.Allow(ctx, "client-2")
+		require.NoError(t, err)
+		assert.True(t, allowed, "other keys keep their own counter")
+	})
+
+	t.Run("tell limit reached without recording an attempt", func(t *testing.T) {
+		limiter := NewRedisLimiter(log.NewNoopLogger(), client, "reached", 2, time.Minute)
+		for i := 0; i < 2; i++ {
+			reached, err := limiter.Reached(ctx, "client-1")
+			require.NoError(t, err)
+			assert.False(t, reached)
+			_, err = limiter.Allow(ctx, "client-1")
+			require.NoError(t, err)
+		}
+
+		reached, err := limiter.Reached(ctx, "client-1")
+		require.NoError(t, err)
+		assert.True(t, reached)
+	})
+
+	t.Run("allow attempts again once window passes", func(t *testing.T) {
+		limiter := NewRedisLimiter(log.NewNoopLogger(), client, "window", 1, 200*time.Millisecond)
+		allowed, err := limiter.Allow(ctx, "client-1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, err = limiter.Allow(ctx, "client-1")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		time.Sleep(300 * time.Millisecond)
+		allowed, err = limiter.Allow(ctx, "client-1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+}
+
+func setupRedisContainer(t *testing.T) (string, func()) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7-alpine",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForLog("Ready to accept connections"),
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+
+	require.NoError(t, err)
+
+	endpoint, err := container.Endpoint(ctx, "")
+	require.NoError(t, err)
+
+	cleanup := func() {
+		require.NoError(t, container.Terminate(ctx))
+	}
+
+	return endpoint, cleanup
+}
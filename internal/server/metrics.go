@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	"queue-bite/internal/platform/metrics"
+)
+
+// registerMetrics reports state of waitlist, seats and connected clients of every restaurant as gauges read on scrape.
+// Waitlist and seats are shared by instances, connected clients are counted per instance.
+func (s *Server) registerMetrics(registry *metrics.Registry) {
+	areaLabels := []string{"restaurant", "area"}
+	for _, restaurant := range s.restaurants {
+		restaurant := restaurant
+		restaurantID := string(restaurant.id)
+
+		registry.GaugeFunc("queuebite_queue_parties", "Parties in the waitlist queue.", areaLabels,
+			restaurant.collectQueueStatus(s.logger, func(observe func(float64, ...string), area d.SeatingArea, total, _ int, _ float64) {
+				observe(float64(total), restaurantID, string(area))
+			}))
+		registry.GaugeFunc("queuebite_queue_waiting_parties", "Parties waiting for their seats to be ready.", areaLabels,
+			restaurant.collectQueueStatus(s.logger, func(observe func(float64, ...string), area d.SeatingArea, _, waiting int, _ float64) {
+				observe(float64(waiting), restaurantID, string(area))
+			}))
+		registry.GaugeFunc("queuebite_queue_wait_seconds", "Estimated wait of a party joining the queue now.", areaLabels,
+			restaurant.collectQueueStatus(s.logger, func(observe func(float64, ...string), area d.SeatingArea, _, _ int, wait float64) {
+				observe(wait, restaurantID, string(area))
+			}))
+
+		registry.GaugeFunc("queuebite_occupied_seats", "Seats taken by parties being served.", areaLabels,
+			func(ctx context.Context, observe func(float64, ...string)) {
+				for _, area := range d.SeatingAreas {
+					seats, err := restaurant.hostdesk.GetOccupiedSeats(ctx, area)
+					if err != nil {
+						s.logger.LogErr(log.Server, err, "could not collect occupied seats", "restaurant", restaurantID, "area", area)
+						continue
+					}
+					observe(float64(seats), restaurantID, string(area))
+				}
+			})
+		registry.GaugeFunc("queuebite_preserved_seats", "Seats held for parties which are ready to check in.", areaLabels,
+			func(ctx context.Context, observe func(float64, ...string)) {
+				for _, area := range d.SeatingAreas {
+					seats, err := restaurant.hostdesk.GetPreservedSeats(ctx, area)
+					if err != nil {
+						s.logger.LogErr(log.Server, err, "could not collect preserved seats", "restaurant", restaurantID, "area", area)
+						continue
+					}
+					observe(float64(seats), restaurantID, string(area))
+				}
+			})
+
+		registry.GaugeFunc("queuebite_sse_clients", "Server sent events clients connected to this instance.", []string{"restaurant", "kind"},
+			func(ctx context.Context, observe func(float64, ...string)) {
+				parties, hosts := s.sse.CountClients(restaurant.id)
+				observe(float64(parties), restaurantID, "party")
+				observe(float64(hosts), restaurantID, "host")
+			})
+	}
+}
+
+// collectQueueStatus reads queue status of every area of restaurant and hands it to report.
+func (rt *restaurant) collectQueueStatus(
+	logger log.Logger,
+	report func(observe func(float64, ...string), area d.SeatingArea, total, waiting int, waitSeconds float64),
+) metrics.GaugeCollector {
+	return func(ctx context.Context, observe func(float64, ...string)) {
+		for _, area := range d.SeatingAreas {
+			status, err := rt.waitlist.GetQueueStatus(ctx, area)
+			if err != nil {
+				logger.LogErr(log.Server, err, "could not collect queue status", "restaurant", rt.id, "area", area)
+				continue
+			}
+			report(observe, area, status.TotalParties, status.WaitingParties, status.CurrentWaitTime.Seconds())
+		}
+	}
+}
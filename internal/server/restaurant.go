@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"queue-bite/internal/config"
+	log "queue-bite/internal/config/logger"
+	d "queue-bite/internal/domain"
+	hs "queue-bite/internal/features/history/service"
+	hds "queue-bite/internal/features/hostdesk/service"
+	rs "queue-bite/internal/features/reservation/service"
+	smd "queue-bite/internal/features/seatmanager/domain"
+	sms "queue-bite/internal/features/seatmanager/service"
+	st "queue-bite/internal/features/servicetime/service"
+	wrepo "queue-bite/internal/features/waitlist/repository"
+	ws "queue-bite/internal/features/waitlist/service"
+	"queue-bite/internal/platform"
+	dlr "queue-bite/internal/platform/deadline/redis"
+	eb "queue-bite/internal/platform/eventbus"
+	"queue-bite/internal/platform/metrics"
+	"queue-bite/internal/platform/ratelimit"
+	rlr "queue-bite/internal/platform/ratelimit/redis"
+	"queue-bite/pkg/session"
+)
+
+// RestaurantComponents are implementations serving a single restaurant.
+type RestaurantComponents struct {
+	ID                            d.RestaurantID
+	WaitlistRepo                  wrepo.WaitlistRepositoy
+	HostDesk                      hds.HostDesk
+	Reservations                  rs.ReservationBook
+	History                       hs.PartyHistory
+	ServiceTimeEstimator          st.ServiceTimeEstimator
+	PartyProcessingStrategy       sms.PartyProcessingStrategy
+	PartySelectionStrategyFactory func(ws.QueuedPartyProvider) sms.PartySelectionStrategy
+}
+
+// restaurant holds services of a single venue, every venue keeps its own waitlist and seats.
+type restaurant struct {
+	id                d.RestaurantID
+	waitlist          ws.Waitlist
+	hostdesk          hds.HostDesk
+	reservations      rs.ReservationBook
+	history           hs.PartyHistory
+	seatmanager       sms.SeatManager
+	cookieQueuedParty *session.CookieConfig
+	// resumeAttempts limits failed guesses of recovery codes, per client and per code
+	resumeAttempts ratelimit.Limiter
+	// restaurantResumeAttempts counts failed guesses of recovery codes of all clients together, to slow them down
+	restaurantResumeAttempts ratelimit.Limiter
+}
+
+func newRestaurant(
+	cfg *config.Config,
+	logger log.Logger,
+	redis *platform.RedisComponent,
+	eventbus eb.EventBus,
+	registry *metrics.Registry,
+	cookieQueuedParty session.CookieConfig,
+	components *RestaurantComponents,
+) *restaurant {
+	waitlist := ws.NewWaitlistService(logger, components.ID, components.WaitlistRepo, components.ServiceTimeEstimator, eventbus,
+		ws.WithSeatingForecast(components.HostDesk, cfg.Waitlist.ForecastPushThreshold))
+	partySelection := components.PartySelectionStrategyFactory(waitlist)
+	seatManager := sms.NewSeatManager(logger, components.ID, eventbus, waitlist, components.HostDesk,
+		components.PartyProcessingStrategy, partySelection, cfg.SeatManager.PreserveMaxRetries,
+		sms.WithCheckInTimeout(
+			dlr.NewRedisDeadlineQueue(logger, redis.Client, "check-in:"+string(components.ID)),
+			cfg.SeatManager.CheckInTimeout,
+			cfg.SeatManager.CheckInPollInterval,
+			smd.CheckInTimeoutPolicy(cfg.SeatManager.CheckInTimeoutPolicy),
+		),
+		sms.WithStarvationGuard(cfg.SeatManager.MaxPartySkips, cfg.SeatManager.StarvationOverdueLimit),
+		sms.WithReservations(components.Reservations),
+		sms.WithHistory(components.History),
+		sms.WithMetrics(registry))
+
+	// every restaurant keeps its own party cookie so parties could queue at several venues
+	cookieQueuedParty.WithPath(components.ID.Path(""))
+
+	return &restaurant{
+		id:                components.ID,
+		waitlist:          waitlist,
+		hostdesk:          components.HostDesk,
+		reservations:      components.Reservations,
+		history:           components.History,
+		seatmanager:       seatManager,
+		cookieQueuedParty: &cookieQueuedParty,
+		resumeAttempts: rlr.NewRedisLimiter(logger, redis.Client, "resume:"+string(components.ID),
+			cfg.Recovery.MaxAttempts, cfg.Recovery.AttemptWindow),
+		restaurantResumeAttempts: rlr.NewRedisLimiter(logger, redis.Client, "resume-restaurant",
+			cfg.Recovery.MaxRestaurantAttempts, cfg.Recovery.AttemptWindow),
+	}
+}
+
+// withRestaurant makes restaurant of the route available to handlers and views.
+func (rt *restaurant) withRestaurant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(d.WithRestaurantID(r.Context(), rt.id)))
+	})
+}
+
+func (rt *restaurant) watch(ctx context.Context) error {
+	return rt.seatmanager.WatchSeatVacancy(ctx)
+}
+
+func (rt *restaurant) unwatch(ctx context.Context) error {
+	return rt.seatmanager.UnwatchSeatVacancy(ctx)
+}
@@ -1,15 +1,19 @@
 package server
 
 import (
+	"crypto/subtle"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	log "queue-bite/internal/config/logger"
+	hdb "queue-bite/internal/features/hostdashboard/handler"
 	sm "queue-bite/internal/features/seatmanager/handler"
 	sse "queue-bite/internal/features/sse/handler"
 	"queue-bite/internal/platform"
+	"queue-bite/internal/platform/metrics"
 	"queue-bite/pkg/utils"
 )
 
@@ -25,25 +29,75 @@ func (s *Server) RegisterRoutes() http.Handler {
 	}))
 	r.Handle("/assets/*", http.FileServer(http.FS(Files)))
 
-	r.Get("/", redirect("/waitlist", http.StatusTemporaryRedirect))
+	// the first restaurant is home of links without restaurant
+	r.Get("/", redirect(s.restaurants[0].id.Path("/waitlist"), http.StatusTemporaryRedirect))
+	r.Get("/waitlist", redirect(s.restaurants[0].id.Path("/waitlist"), http.StatusTemporaryRedirect))
 	r.Get("/healthz", healthHandler(s.redis))
+	r.Get("/metrics", metricsHandler(s.logger, s.metrics))
 
-	cookieQueuedParty := &s.cookieCfgs.QueuedPartyCookie
+	for _, restaurant := range s.restaurants {
+		r.Route(restaurant.id.Path(""), func(r chi.Router) {
+			r.Use(restaurant.withRestaurant)
+			s.registerRestaurantRoutes(r, restaurant)
+		})
+	}
+
+	return r
+}
+
+func (s *Server) registerRestaurantRoutes(r chi.Router, restaurant *restaurant) {
+	cookieQueuedParty := restaurant.cookieQueuedParty
 	seatManagerHandler := sm.NewSeatManagerHandler()
 
 	r.Route("/waitlist", func(r chi.Router) {
 		vitrineHandler := sm.NewVitrineHandler()
 
-		r.Get("/", vitrineHandler.HandleVitrineDisplay(s.logger, s.cookieManager, cookieQueuedParty, s.waitlist, s.hostdesk))
-		r.Post("/join", seatManagerHandler.HandleNewPartyArrival(s.logger, s.validate, s.translators, s.cookieManager, cookieQueuedParty, s.seatmanager, s.hostdesk))
-		r.Post("/check-in", seatManagerHandler.HandlePartyCheckIn(s.logger, s.seatmanager, s.cookieManager, cookieQueuedParty))
+		r.Get("/", vitrineHandler.HandleVitrineDisplay(s.logger, s.cookieManager, cookieQueuedParty, s.streamTokens, s.recoveryLinks, restaurant.waitlist, restaurant.hostdesk))
+		r.Post("/join", seatManagerHandler.HandleNewPartyArrival(s.logger, s.validate, s.translators, s.cookieManager, cookieQueuedParty, s.streamTokens, s.recoveryLinks, restaurant.seatmanager, restaurant.waitlist, restaurant.hostdesk))
+		r.Post("/check-in", seatManagerHandler.HandlePartyCheckIn(s.logger, restaurant.seatmanager, s.cookieManager, cookieQueuedParty))
+		r.Post("/leave", seatManagerHandler.HandlePartyLeave(s.logger, restaurant.seatmanager, s.cookieManager, cookieQueuedParty))
+		r.Get("/resume", seatManagerHandler.HandleResumeLink(s.logger, s.cookieManager, cookieQueuedParty, s.recoveryLinks, restaurant.waitlist))
+		r.Post("/resume", seatManagerHandler.HandleResumeCode(s.logger, s.cookieManager, cookieQueuedParty, s.streamTokens, s.recoveryLinks,
+			restaurant.resumeAttempts, restaurant.restaurantResumeAttempts, s.cfg.Recovery.RestaurantBackoff, s.cfg.Server.ClientIPHeader, restaurant.waitlist))
 	})
 
-	r.Get("/sse/waitlist/{partyID}", sse.HandleQueuedPartyServerSentEventConn(s.logger, s.sse, s.waitlist))
+	r.Get("/sse/waitlist/{partyID}", sse.HandleQueuedPartyServerSentEventConn(s.logger, s.sse, s.streamTokens, s.cookieManager, cookieQueuedParty, restaurant.waitlist))
 
-	r.Get("/yummy", seatManagerHandler.HandleServingDisplay(s.logger, s.cookieManager, cookieQueuedParty, s.hostdesk))
+	r.Get("/yummy", seatManagerHandler.HandleServingDisplay(s.logger, s.cookieManager, cookieQueuedParty, restaurant.hostdesk))
 
-	return r
+	r.Route("/host", func(r chi.Router) {
+		r.Use(basicAuth("host desk", s.cfg.HostDashboard.Username, s.cfg.HostDashboard.Password))
+		hostDashboardHandler := hdb.NewHostDashboardHandler()
+
+		r.Get("/", hostDashboardHandler.HandleDashboardDisplay(s.logger, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Get("/parties", hostDashboardHandler.HandlePartiesDisplay(s.logger, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Post("/parties/{partyID}/ready", hostDashboardHandler.HandleReadyParty(s.logger, restaurant.seatmanager, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Post("/parties/{partyID}/remove", hostDashboardHandler.HandleRemoveParty(s.logger, restaurant.seatmanager, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Post("/parties/{partyID}/complete", hostDashboardHandler.HandleCompleteParty(s.logger, restaurant.seatmanager, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Post("/parties/{partyID}/check-in", hostDashboardHandler.HandleCheckInParty(s.logger, restaurant.seatmanager, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Post("/reservations", hostDashboardHandler.HandleBookReservation(s.logger, s.validate, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Post("/reservations/{reservationID}/arrive", hostDashboardHandler.HandleReservationArrival(s.logger, restaurant.seatmanager, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Post("/reservations/{reservationID}/cancel", hostDashboardHandler.HandleCancelReservation(s.logger, restaurant.seatmanager, restaurant.waitlist, restaurant.hostdesk, restaurant.reservations))
+		r.Get("/history", hostDashboardHandler.HandleDailyStats(s.logger, restaurant.history))
+		r.Get("/sse", sse.HandleHostDeskServerSentEventConn(s.logger, s.sse))
+	})
+}
+
+// basicAuth guards staff-only routes with HTTP basic authentication.
+func basicAuth(realm, username, password string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok ||
+				subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 func redirect(path string, status int) http.HandlerFunc {
@@ -66,3 +120,13 @@ func healthHandler(systemComponents ...platform.SystemComponents) http.HandlerFu
 		}
 	}
 }
+
+// metricsHandler writes metrics in the Prometheus text exposition format.
+func metricsHandler(logger log.Logger, registry *metrics.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := registry.WriteTo(r.Context(), w); err != nil {
+			logger.LogErr(log.Server, err, "failed to write metrics")
+		}
+	}
+}
@@ -11,14 +11,10 @@ import (
 
 	"queue-bite/internal/config"
 	log "queue-bite/internal/config/logger"
-	hds "queue-bite/internal/features/hostdesk/service"
-	sms "queue-bite/internal/features/seatmanager/service"
-	st "queue-bite/internal/features/servicetime/service"
 	"queue-bite/internal/features/sse"
-	wrepo "queue-bite/internal/features/waitlist/repository"
-	ws "queue-bite/internal/features/waitlist/service"
 	"queue-bite/internal/platform"
 	eb "queue-bite/internal/platform/eventbus"
+	"queue-bite/internal/platform/metrics"
 	"queue-bite/pkg/session"
 )
 
@@ -29,13 +25,14 @@ type Server struct {
 	translators   *ut.UniversalTranslator
 	cookieManager *session.CookieManager
 	cookieCfgs    *config.QueueBiteCookies
+	streamTokens  *session.TokenSigner
+	recoveryLinks *session.TokenSigner
 
 	redis *platform.RedisComponent
 
-	waitlist    ws.Waitlist
-	hostdesk    hds.HostDesk
 	sse         sse.ServerSentEvents
-	seatmanager sms.SeatManager
+	restaurants []*restaurant
+	metrics     *metrics.Registry
 }
 
 func NewServer(
@@ -44,22 +41,29 @@ func NewServer(
 	redis *platform.RedisComponent,
 	eventRegistry *eb.EventRegistry,
 	eventbus eb.EventBus,
-	serviceTimeEstimator st.ServiceTimeEstimator,
-	waitlistRepo wrepo.WaitlistRepositoy,
-	hostdesk hds.HostDesk,
-	partyProcessingStrategy sms.PartyProcessingStrategy,
-	partySelectionStrategyFactory func(ws.QueuedPartyProvider) sms.PartySelectionStrategy,
-) *http.Server {
-	cookieManager, err := session.NewCookieManager(cfg.CookieEncryptionKey)
+	registry *metrics.Registry,
+	restaurants []*RestaurantComponents,
+) (*http.Server, error) {
+	cookieManager, err := session.NewCookieManager(cfg.CookieEncryptionKey, cfg.PreviousCookieKeys()...)
 	if err != nil {
 		logger.LogErr(log.Server, err, "cookie encryption key setup", "encryption key", cfg.CookieEncryptionKey)
 	}
+	streamTokens, err := session.NewTokenSigner(cfg.StreamToken.Key, cfg.StreamToken.TTL)
+	if err != nil {
+		logger.LogErr(log.Server, err, "stream token signing key setup")
+	}
+	recoveryLinks, err := session.NewTokenSigner(cfg.StreamToken.Key, cfg.Recovery.LinkTTL)
+	if err != nil {
+		logger.LogErr(log.Server, err, "recovery link signing key setup")
+	}
 	localeTrans := config.NewLocaleTranslations()
 	cookieCfgs := config.NewCookieConfigs(cfg)
-	sseManager := sse.NewServerSentEvent(logger, eventbus)
-	waitlist := ws.NewWaitlistService(logger, waitlistRepo, serviceTimeEstimator, eventbus)
-	partySelection := partySelectionStrategyFactory(waitlist)
-	seatManager := sms.NewSeatManager(logger, eventbus, waitlist, hostdesk, partyProcessingStrategy, partySelection, cfg.SeatManager.PreserveMaxRetries)
+	sseManager, err := sse.NewServerSentEvent(logger, eventbus,
+		sse.WithHeartbeat(cfg.SSE.HeartbeatInterval),
+		sse.WithRetry(cfg.SSE.RetryInterval))
+	if err != nil {
+		return nil, err
+	}
 
 	NewServer := &Server{
 		cfg:           cfg,
@@ -68,17 +72,27 @@ func NewServer(
 		translators:   localeTrans.Translators,
 		cookieManager: cookieManager,
 		cookieCfgs:    cookieCfgs,
+		streamTokens:  streamTokens,
+		recoveryLinks: recoveryLinks,
 
-		waitlist:    waitlist,
-		hostdesk:    hostdesk,
-		sse:         sseManager,
-		seatmanager: seatManager,
+		sse:     sseManager,
+		metrics: registry,
 
 		redis: platform.NewRedis(cfg, logger),
 	}
+	for _, components := range restaurants {
+		NewServer.restaurants = append(NewServer.restaurants,
+			newRestaurant(cfg, logger, redis, eventbus, registry, cookieCfgs.QueuedPartyCookie, components))
+	}
+	NewServer.registerMetrics(registry)
 
 	NewServer.RegisterEvents(eventRegistry)
-	seatManager.WatchSeatVacancy(context.Background())
+	for _, restaurant := range NewServer.restaurants {
+		if err := restaurant.watch(context.Background()); err != nil {
+			logger.LogErr(log.Server, err, "could not watch seat vacancy", "restaurant", restaurant.id)
+			return nil, err
+		}
+	}
 
 	// Declare Server config
 	server := &http.Server{
@@ -96,11 +110,13 @@ func NewServer(
 		NewServer.Cleanup(ctx)
 	})
 
-	return server
+	return server, nil
 }
 
 func (s *Server) Cleanup(ctx context.Context) {
-	if err := s.seatmanager.UnwatchSeatVacancy(ctx); err != nil {
-		s.logger.LogErr(log.Server, err, "failed to unwatch seats vacancy")
+	for _, restaurant := range s.restaurants {
+		if err := restaurant.unwatch(ctx); err != nil {
+			s.logger.LogErr(log.Server, err, "failed to unwatch seats vacancy", "restaurant id", restaurant.id)
+		}
 	}
 }
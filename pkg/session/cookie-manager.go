@@ -3,43 +3,54 @@ package session
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"queue-bite/pkg/utils"
+	"strings"
 	"time"
 )
 
+var ErrCookieUndecryptable = errors.New("cookie could not be decrypted by any configured key")
+
+// CookieManager encrypts cookie payloads with AES-GCM. A cookie is the id of the key sealing it
+// and a fresh random nonce followed by the ciphertext, so keys could be rotated: cookies are sealed
+// by the current key, and cookies of previous keys are still read and sealed again by the current one.
 type CookieManager struct {
-	encryptionKey []byte
-	block         cipher.Block
-	gcm           cipher.AEAD
-	nonce         []byte
+	// keys are the current key first, then previous keys still accepted
+	keys []cookieKey
 }
 
-func NewCookieManager(encryptionKey string) (*CookieManager, error) {
-	encryptKey := []byte(encryptionKey)
-	if len(encryptKey) != 32 {
-		return nil, fmt.Errorf("key must be exactly 32 bytes long")
-	}
-	block, err := aes.NewCipher([]byte(encryptionKey))
-	if err != nil {
-		return nil, err
-	}
+type cookieKey struct {
+	id  string
+	gcm cipher.AEAD
+}
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
+// NewCookieManager seals cookies with encryptionKey, and opens those sealed with any of previousKeys.
+// Every key must be exactly 32 bytes long.
+func NewCookieManager(encryptionKey string, previousKeys ...string) (*CookieManager, error) {
+	cm := &CookieManager{}
+	for _, key := range append([]string{encryptionKey}, previousKeys...) {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key must be exactly 32 bytes long")
+		}
+		block, err := aes.NewCipher([]byte(key))
+		if err != nil {
+			return nil, err
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		cm.keys = append(cm.keys, cookieKey{id: keyID(key), gcm: gcm})
 	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	return &CookieManager{
-		encryptionKey: []byte(encryptionKey),
-		block:         block,
-		gcm:           gcm,
-		nonce:         nonce,
-	}, nil
+	return cm, nil
 }
 
 func (cm *CookieManager) SetCookie(w http.ResponseWriter, conf *CookieConfig, payload interface{}) error {
@@ -48,8 +59,10 @@ func (cm *CookieManager) SetCookie(w http.ResponseWriter, conf *CookieConfig, pa
 		return fmt.Errorf("payload must match the JSON structure")
 	}
 
-	encrypted := cm.gcm.Seal(nil, cm.nonce, []byte(plaintext), nil)
-	value := base64.URLEncoding.EncodeToString(encrypted)
+	value, err := cm.seal(plaintext)
+	if err != nil {
+		return err
+	}
 
 	cookie := &http.Cookie{
 		Name:     conf.name,
@@ -67,23 +80,72 @@ func (cm *CookieManager) SetCookie(w http.ResponseWriter, conf *CookieConfig, pa
 	return nil
 }
 
-func (cm *CookieManager) GetCookie(r *http.Request, conf *CookieConfig, dest interface{}) error {
+// GetCookie decrypts cookie into dest. A cookie sealed by a previous key is set again sealed by the current key.
+// Cookies of the format without key id, sealed under a fixed nonce, are rejected, their parties join again.
+func (cm *CookieManager) GetCookie(w http.ResponseWriter, r *http.Request, conf *CookieConfig, dest interface{}) error {
 	cookie, err := r.Cookie(conf.name)
 	if err != nil {
 		return err
 	}
 
-	encrypted, err := base64.URLEncoding.DecodeString(cookie.Value)
+	decrypted, current, err := cm.open(cookie.Value)
 	if err != nil {
 		return err
 	}
 
-	decrypted, err := cm.gcm.Open(nil, cm.nonce, encrypted, nil)
-	if err != nil {
+	if err := json.Unmarshal(decrypted, dest); err != nil {
 		return err
 	}
+	if !current {
+		return cm.SetCookie(w, conf, dest)
+	}
+	return nil
+}
+
+// seal encrypts plaintext by the current key under a random nonce, as <key id>.<base64 of nonce and ciphertext>.
+func (cm *CookieManager) seal(plaintext []byte) (string, error) {
+	key := cm.keys[0]
+	nonce := make([]byte, key.gcm.NonceSize(), key.gcm.NonceSize()+len(plaintext)+key.gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := key.gcm.Seal(nonce, nonce, plaintext, nil)
+	return key.id + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts value by the key it names, tells whether value was sealed by the current key.
+func (cm *CookieManager) open(value string) ([]byte, bool, error) {
+	id, encoded, found := strings.Cut(value, ".")
+	if !found {
+		return nil, false, ErrCookieUndecryptable
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false, err
+	}
+	for i, key := range cm.keys {
+		if key.id != id {
+			continue
+		}
+		if len(sealed) < key.gcm.NonceSize() {
+			return nil, false, ErrCookieUndecryptable
+		}
+		nonce, ciphertext := sealed[:key.gcm.NonceSize()], sealed[key.gcm.NonceSize():]
+		plaintext, err := key.gcm.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		return plaintext, i == 0, nil
+	}
+	return nil, false, ErrCookieUndecryptable
+}
 
-	return json.Unmarshal(decrypted, dest)
+// keyID names key in cookies it seals without giving the key away.
+func keyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
 }
 
 func (cm *CookieManager) ClearCookie(w http.ResponseWriter, conf *CookieConfig) {
@@ -1,11 +1,17 @@
 package session
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCookieEncryptDecrypt(t *testing.T) {
@@ -42,7 +48,7 @@ func TestCookieEncryptDecrypt(t *testing.T) {
 	req.AddCookie(cookies[0])
 
 	var decoded Payload
-	if err := m.GetCookie(req, cfg, &decoded); err != nil {
+	if err := m.GetCookie(httptest.NewRecorder(), req, cfg, &decoded); err != nil {
 		t.Fatalf("failed to get cookie: %v", err)
 	}
 
@@ -79,3 +85,93 @@ func TestCookieExpiration(t *testing.T) {
 		assert.WithinDuration(t, now.Add(10*time.Minute), cfg.GetExpiration(), time.Millisecond)
 	})
 }
+
+func TestCookieKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	const (
+		oldKey = "12345678901234567890123456789012"
+		newKey = "abcdefghijabcdefghijabcdefghijab"
+	)
+	type Payload struct {
+		UserID string
+	}
+	cfg := NewCookieConfig("test_cookie", "example.com")
+
+	issue := func(t *testing.T, m *CookieManager) *http.Cookie {
+		w := httptest.NewRecorder()
+		require.NoError(t, m.SetCookie(w, cfg, &Payload{UserID: "u5566"}))
+		return w.Result().Cookies()[0]
+	}
+	read := func(t *testing.T, m *CookieManager, cookie *http.Cookie) (*Payload, *httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest("GET", "http://example.com", nil)
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		var decoded Payload
+		err := m.GetCookie(w, req, cfg, &decoded)
+		return &decoded, w, err
+	}
+
+	oldManager, err := NewCookieManager(oldKey)
+	require.NoError(t, err)
+	rotated, err := NewCookieManager(newKey, oldKey)
+	require.NoError(t, err)
+
+	t.Run("every cookie is sealed under a fresh nonce", func(t *testing.T) {
+		first, second := issue(t, oldManager), issue(t, oldManager)
+		assert.NotEqual(t, first.Value, second.Value)
+		assert.True(t, strings.HasPrefix(first.Value, keyID(oldKey)+"."))
+	})
+
+	t.Run("current key reads cookie without issuing it again", func(t *testing.T) {
+		decoded, w, err := read(t, rotated, issue(t, rotated))
+		require.NoError(t, err)
+		assert.Equal(t, "u5566", decoded.UserID)
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("previous key reads cookie and issues it under current key", func(t *testing.T) {
+		decoded, w, err := read(t, rotated, issue(t, oldManager))
+		require.NoError(t, err)
+		assert.Equal(t, "u5566", decoded.UserID)
+
+		reissued := w.Result().Cookies()
+		require.Len(t, reissued, 1)
+		assert.True(t, strings.HasPrefix(reissued[0].Value, keyID(newKey)+"."))
+
+		decoded, _, err = read(t, rotated, reissued[0])
+		require.NoError(t, err)
+		assert.Equal(t, "u5566", decoded.UserID)
+	})
+
+	t.Run("cookie of a key no longer configured is rejected", func(t *testing.T) {
+		newOnly, err := NewCookieManager(newKey)
+		require.NoError(t, err)
+		_, _, err = read(t, newOnly, issue(t, oldManager))
+		assert.ErrorIs(t, err, ErrCookieUndecryptable)
+	})
+
+	t.Run("tampered cookie is rejected", func(t *testing.T) {
+		cookie := issue(t, rotated)
+		id, sealed, _ := strings.Cut(cookie.Value, ".")
+		flipped := []byte(sealed)
+		flipped[0] ^= 'A' ^ 'B'
+		cookie.Value = id + "." + string(flipped)
+		_, _, err := read(t, rotated, cookie)
+		assert.Error(t, err)
+	})
+
+	t.Run("cookie without key id and nonce is rejected", func(t *testing.T) {
+		// cookies were sealed under an all-zero nonce without key id before keys could be rotated
+		block, err := aes.NewCipher([]byte(oldKey))
+		require.NoError(t, err)
+		gcm, err := cipher.NewGCM(block)
+		require.NoError(t, err)
+		legacy := gcm.Seal(nil, make([]byte, gcm.NonceSize()), []byte(`{"UserID":"u5566"}`), nil)
+		cookie := &http.Cookie{Name: "test_cookie", Value: base64.URLEncoding.EncodeToString(legacy)}
+
+		_, w, err := read(t, rotated, cookie)
+		assert.ErrorIs(t, err, ErrCookieUndecryptable)
+		assert.Empty(t, w.Result().Cookies())
+	})
+}
@@ -0,0 +1,96 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"queue-bite/pkg/utils"
+)
+
+var (
+	ErrTokenMalformed = errors.New("token is malformed")
+	ErrTokenInvalid   = errors.New("token signature does not match")
+	ErrTokenExpired   = errors.New("token is expired")
+)
+
+// TokenSigner issues short-lived tokens granting access to a resource, such as the status stream of a party,
+// to whoever holds the link. A token is the expiry and an HMAC-SHA256 signature of subject and expiry,
+// so it could not be forged or moved to another subject without the key.
+type TokenSigner struct {
+	utils.Clock
+	key []byte
+	ttl time.Duration
+}
+
+func NewTokenSigner(key string, ttl time.Duration) (*TokenSigner, error) {
+	if len(key) < 32 {
+		return nil, fmt.Errorf("key must be at least 32 bytes long")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("ttl must be positive")
+	}
+	return &TokenSigner{key: []byte(key), ttl: ttl}, nil
+}
+
+// Sign issues token for subject, valid for ttl from now.
+func (s *TokenSigner) Sign(subject string) string {
+	expiry := strconv.FormatInt(s.Now().Add(s.ttl).Unix(), 10)
+	return expiry + "." + base64.RawURLEncoding.EncodeToString(s.signature(subject, expiry))
+}
+
+// Verify checks token was issued for subject by this signer and is not expired yet.
+func (s *TokenSigner) Verify(token string, subject string) error {
+	expiry, encoded, found := strings.Cut(token, ".")
+	if !found {
+		return ErrTokenMalformed
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrTokenMalformed
+	}
+
+	if !hmac.Equal(signature, s.signature(subject, expiry)) {
+		return ErrTokenInvalid
+	}
+	if !s.Now().Before(time.Unix(expiresAt, 0)) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+// recoveryCodeAlphabet leaves out 0, 1, I and O so a code could be read aloud or typed without confusion.
+const recoveryCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// Code derives a short code of length from subject, stable for as long as the key is.
+// Code is only a few characters long, so whoever verifies it must limit the guesses.
+func (s *TokenSigner) Code(subject string, length int) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("code"))
+	mac.Write([]byte{0})
+	mac.Write([]byte(subject))
+	sum := mac.Sum(nil)
+
+	code := make([]byte, length)
+	for i := range code {
+		code[i] = recoveryCodeAlphabet[sum[i%len(sum)]%byte(len(recoveryCodeAlphabet))]
+	}
+	return string(code)
+}
+
+func (s *TokenSigner) signature(subject string, expiry string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(subject))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expiry))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSigner(t *testing.T) {
+	t.Parallel()
+
+	signer, err := NewTokenSigner("12345678901234567890123456789012", 30*time.Minute)
+	require.NoError(t, err)
+	signer.Fixed = time.Now()
+	token := signer.Sign("party-1")
+
+	t.Run("accept token of subject", func(t *testing.T) {
+		assert.NoError(t, signer.Verify(token, "party-1"))
+	})
+
+	t.Run("reject token of another subject", func(t *testing.T) {
+		assert.ErrorIs(t, signer.Verify(token, "party-2"), ErrTokenInvalid)
+	})
+
+	t.Run("reject tampered token", func(t *testing.T) {
+		expiry, signature, _ := strings.Cut(token, ".")
+		extended := strings.Replace(token, expiry, expiry+"0", 1)
+		assert.ErrorIs(t, signer.Verify(extended, "party-1"), ErrTokenInvalid)
+
+		flipped := []byte(signature)
+		flipped[0] ^= 'A' ^ 'B'
+		assert.Error(t, signer.Verify(expiry+"."+string(flipped), "party-1"))
+
+		assert.ErrorIs(t, signer.Verify("", "party-1"), ErrTokenMalformed)
+		assert.ErrorIs(t, signer.Verify("not-a-token", "party-1"), ErrTokenMalformed)
+		assert.ErrorIs(t, signer.Verify("soon."+signature, "party-1"), ErrTokenMalformed)
+	})
+
+	t.Run("reject token signed by another key", func(t *testing.T) {
+		other, err := NewTokenSigner("abcdefghijabcdefghijabcdefghijab", 30*time.Minute)
+		require.NoError(t, err)
+		other.Fixed = signer.Fixed
+		assert.ErrorIs(t, signer.Verify(other.Sign("party-1"), "party-1"), ErrTokenInvalid)
+	})
+
+	t.Run("reject expired token", func(t *testing.T) {
+		expired := *signer
+		expired.Fixed = signer.Fixed.Add(30*time.Minute + time.Second)
+		assert.ErrorIs(t, expired.Verify(token, "party-1"), ErrTokenExpired)
+	})
+
+	t.Run("derive stable code of subject", func(t *testing.T) {
+		code := signer.Code("party-1", 6)
+		assert.Len(t, code, 6)
+		assert.Equal(t, code, signer.Code("party-1", 6))
+		assert.NotEqual(t, code, signer.Code("party-2", 6))
+		assert.NotContains(t, code, "0")
+		assert.NotContains(t, code, "O")
+
+		expired := *signer
+		expired.Fixed = signer.Fixed.Add(time.Hour)
+		assert.Equal(t, code, expired.Code("party-1", 6), "code does not expire with tokens")
+	})
+
+	t.Run("reject short key", func(t *testing.T) {
+		_, err := NewTokenSigner("too-short", time.Minute)
+		assert.Error(t, err)
+	})
+}